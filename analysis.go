@@ -0,0 +1,529 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Aggregation describes a single computation over the elements of an array of objects.
+// Operation is one of "sum", "avg", "min", "max" or "count".
+type Aggregation struct {
+	Field     string `json:"field"`
+	Operation string `json:"operation"`
+	Output    string `json:"output"`
+}
+
+// Aggregate computes the requested aggregations over the array at path and returns
+// an object of results. Non-numeric or missing values are skipped and counted.
+func (a *App) Aggregate(input string, path string, aggregations []Aggregation) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	arr := resolvePath(validInput, path)
+	if !arr.IsArray() {
+		return JSONResponse{Success: false, Error: "路径不是数组: " + path}
+	}
+	elements := arr.Array()
+
+	var results, skipped strings.Builder
+	results.WriteString("{")
+	skipped.WriteString("{")
+	for idx, agg := range aggregations {
+		output := agg.Output
+		if output == "" {
+			output = agg.Operation + "_" + agg.Field
+		}
+		value, skippedCount, err := aggregateField(elements, agg)
+		if err != nil {
+			return JSONResponse{Success: false, Error: err.Error()}
+		}
+		key, _ := json.Marshal(output)
+		if idx > 0 {
+			results.WriteString(",")
+			skipped.WriteString(",")
+		}
+		results.Write(key)
+		results.WriteString(":")
+		results.WriteString(value)
+		skipped.Write(key)
+		skipped.WriteString(":")
+		skipped.WriteString(strconv.Itoa(skippedCount))
+	}
+	results.WriteString("}")
+	skipped.WriteString("}")
+
+	return indentedResponse(`{"results":` + results.String() + `,"skipped":` + skipped.String() + `}`)
+}
+
+// aggregateField runs one aggregation over the elements and returns the raw JSON result
+// together with the number of skipped elements
+func aggregateField(elements []gjson.Result, agg Aggregation) (string, int, error) {
+	var sum, min, max float64
+	count := 0
+	skipped := 0
+	for _, elem := range elements {
+		value := elem.Get(agg.Field)
+		if !value.Exists() || value.Type == gjson.Null {
+			skipped++
+			continue
+		}
+		if agg.Operation == "count" {
+			count++
+			continue
+		}
+		if value.Type != gjson.Number {
+			skipped++
+			continue
+		}
+		n := value.Float()
+		if count == 0 || n < min {
+			min = n
+		}
+		if count == 0 || n > max {
+			max = n
+		}
+		sum += n
+		count++
+	}
+
+	switch agg.Operation {
+	case "count":
+		return strconv.Itoa(count), skipped, nil
+	case "sum":
+		return formatFloat(sum), skipped, nil
+	case "avg":
+		if count == 0 {
+			return "null", skipped, nil
+		}
+		return formatFloat(sum / float64(count)), skipped, nil
+	case "min":
+		if count == 0 {
+			return "null", skipped, nil
+		}
+		return formatFloat(min), skipped, nil
+	case "max":
+		if count == 0 {
+			return "null", skipped, nil
+		}
+		return formatFloat(max), skipped, nil
+	default:
+		return "", 0, errors.New("不支持的聚合操作: " + agg.Operation)
+	}
+}
+
+// formatFloat renders a float64 as the shortest JSON number that round-trips
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// complexityTopStrings is the number of longest string values listed by ComplexityReport
+const complexityTopStrings = 5
+
+// complexityPreviewRunes limits the string preview shown for each long string value
+const complexityPreviewRunes = 40
+
+// PathMeasure pairs a JSON path with a size measurement (depth, key count, length)
+type PathMeasure struct {
+	Path    string `json:"path"`
+	Size    int    `json:"size"`
+	Preview string `json:"preview,omitempty"`
+}
+
+// ComplexityReport summarizes where a document is largest: the deepest path, the object
+// with the most keys, the longest array and the longest string values
+type ComplexityReport struct {
+	Deepest        *PathMeasure  `json:"deepest"`
+	WidestObject   *PathMeasure  `json:"widestObject"`
+	LongestArray   *PathMeasure  `json:"longestArray"`
+	LongestStrings []PathMeasure `json:"longestStrings"`
+}
+
+// ComplexityReport locates the problem areas of a large document in a single traversal.
+// Sizes are nesting depth, number of keys, number of elements and string length in characters.
+func (a *App) ComplexityReport(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	report := ComplexityReport{LongestStrings: []PathMeasure{}}
+	report.measure(gjson.Parse(validInput), "$", 0)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return JSONResponse{Success: false, Error: "生成报告失败: " + err.Error()}
+	}
+	return indentedResponse(string(data))
+}
+
+// measure records res at path and recurses into its children. Ties keep the first
+// occurrence in document order.
+func (r *ComplexityReport) measure(res gjson.Result, path string, depth int) {
+	if r.Deepest == nil || depth > r.Deepest.Size {
+		r.Deepest = &PathMeasure{Path: path, Size: depth}
+	}
+
+	switch {
+	case res.IsObject():
+		keys := 0
+		res.ForEach(func(key, value gjson.Result) bool {
+			keys++
+			r.measure(value, childJSONPath(path, key.String()), depth+1)
+			return true
+		})
+		if r.WidestObject == nil || keys > r.WidestObject.Size {
+			r.WidestObject = &PathMeasure{Path: path, Size: keys}
+		}
+	case res.IsArray():
+		length := 0
+		res.ForEach(func(_, value gjson.Result) bool {
+			r.measure(value, path+"["+strconv.Itoa(length)+"]", depth+1)
+			length++
+			return true
+		})
+		if r.LongestArray == nil || length > r.LongestArray.Size {
+			r.LongestArray = &PathMeasure{Path: path, Size: length}
+		}
+	case res.Type == gjson.String:
+		r.addString(res.String(), path)
+	}
+}
+
+// addString keeps LongestStrings sorted by descending length and capped at complexityTopStrings
+func (r *ComplexityReport) addString(s string, path string) {
+	runes := []rune(s)
+	size := len(runes)
+	pos := len(r.LongestStrings)
+	for pos > 0 && r.LongestStrings[pos-1].Size < size {
+		pos--
+	}
+	if pos >= complexityTopStrings {
+		return
+	}
+
+	preview := s
+	if size > complexityPreviewRunes {
+		preview = string(runes[:complexityPreviewRunes]) + "..."
+	}
+	r.LongestStrings = append(r.LongestStrings, PathMeasure{})
+	copy(r.LongestStrings[pos+1:], r.LongestStrings[pos:])
+	r.LongestStrings[pos] = PathMeasure{Path: path, Size: size, Preview: preview}
+	if len(r.LongestStrings) > complexityTopStrings {
+		r.LongestStrings = r.LongestStrings[:complexityTopStrings]
+	}
+}
+
+// KeyTypeSummary reports, for every distinct key name anywhere in the document, how often each
+// value type was observed, e.g. {"age": {"number": 2, "string": 1}}. Keys are aggregated by name
+// rather than path, so fields whose type differs between records stand out. Keys are listed in
+// order of first appearance.
+func (a *App) KeyTypeSummary(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	var order []string
+	counts := make(map[string]map[string]int)
+	var walk func(res gjson.Result)
+	walk = func(res gjson.Result) {
+		res.ForEach(func(key, value gjson.Result) bool {
+			if res.IsObject() {
+				name := key.String()
+				if counts[name] == nil {
+					counts[name] = make(map[string]int)
+					order = append(order, name)
+				}
+				counts[name][jsonTypeName(value)]++
+			}
+			if value.IsObject() || value.IsArray() {
+				walk(value)
+			}
+			return true
+		})
+	}
+	walk(gjson.Parse(validInput))
+
+	var builder strings.Builder
+	builder.WriteString("{")
+	for idx, name := range order {
+		if idx > 0 {
+			builder.WriteString(",")
+		}
+		key, _ := json.Marshal(name)
+		builder.Write(key)
+		builder.WriteString(":{")
+		first := true
+		for _, typeName := range jsonTypeNames {
+			if count := counts[name][typeName]; count > 0 {
+				if !first {
+					builder.WriteString(",")
+				}
+				first = false
+				builder.WriteString(`"` + typeName + `":` + strconv.Itoa(count))
+			}
+		}
+		builder.WriteString("}")
+	}
+	builder.WriteString("}")
+
+	return indentedResponse(builder.String())
+}
+
+// jsonTypeNames lists the JSON value types in the order KeyTypeSummary reports them
+var jsonTypeNames = []string{"string", "number", "boolean", "null", "object", "array"}
+
+// jsonTypeName returns the JSON type name of a value
+func jsonTypeName(res gjson.Result) string {
+	switch {
+	case res.IsObject():
+		return "object"
+	case res.IsArray():
+		return "array"
+	case res.Type == gjson.String:
+		return "string"
+	case res.Type == gjson.Number:
+		return "number"
+	case res.Type == gjson.True || res.Type == gjson.False:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// FieldPresenceReport reports, over the array of objects at arrayPath, how many records contain
+// each key. Keys present in every record are "always" present (required), the others
+// "sometimes" (optional). Elements that are not objects are skipped and counted.
+func (a *App) FieldPresenceReport(input string, arrayPath string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	arr := resolvePath(validInput, arrayPath)
+	if !arr.IsArray() {
+		return JSONResponse{Success: false, Error: "路径不是数组: " + arrayPath}
+	}
+
+	var order []string
+	counts := make(map[string]int)
+	records, skipped := 0, 0
+	arr.ForEach(func(_, elem gjson.Result) bool {
+		if !elem.IsObject() {
+			skipped++
+			return true
+		}
+		records++
+		seen := make(map[string]bool)
+		elem.ForEach(func(key, _ gjson.Result) bool {
+			name := key.String()
+			if seen[name] {
+				return true
+			}
+			seen[name] = true
+			if _, ok := counts[name]; !ok {
+				order = append(order, name)
+			}
+			counts[name]++
+			return true
+		})
+		return true
+	})
+	if records == 0 {
+		return JSONResponse{Success: false, Error: "数组中没有对象记录"}
+	}
+
+	var fields strings.Builder
+	fields.WriteString("{")
+	for idx, name := range order {
+		if idx > 0 {
+			fields.WriteString(",")
+		}
+		presence := "sometimes"
+		if counts[name] == records {
+			presence = "always"
+		}
+		key, _ := json.Marshal(name)
+		percent := math.Round(float64(counts[name])*10000/float64(records)) / 100
+		fields.Write(key)
+		fields.WriteString(`:{"count":` + strconv.Itoa(counts[name]))
+		fields.WriteString(`,"percent":` + formatFloat(percent))
+		fields.WriteString(`,"presence":"` + presence + `"}`)
+	}
+	fields.WriteString("}")
+
+	return indentedResponse(`{"records":` + strconv.Itoa(records) + `,"skipped":` + strconv.Itoa(skipped) + `,"fields":` + fields.String() + `}`)
+}
+
+// ArrayShape describes the shape of an array element: its JSON type and, for objects, its keys
+type ArrayShape struct {
+	Type string   `json:"type"`
+	Keys []string `json:"keys,omitempty"`
+}
+
+// ArrayOutlier is an element whose shape deviates from the dominant one
+type ArrayOutlier struct {
+	Index          int               `json:"index"`
+	Path           string            `json:"path"`
+	Type           string            `json:"type"`
+	MissingKeys    []string          `json:"missingKeys,omitempty"`
+	ExtraKeys      []string          `json:"extraKeys,omitempty"`
+	TypeMismatches map[string]string `json:"typeMismatches,omitempty"`
+}
+
+// HomogeneityReport is the result of CheckArrayHomogeneity
+type HomogeneityReport struct {
+	Total      int            `json:"total"`
+	Conforming int            `json:"conforming"`
+	Dominant   *ArrayShape    `json:"dominant"`
+	Outliers   []ArrayOutlier `json:"outliers"`
+}
+
+// CheckArrayHomogeneity finds the elements of the array at path that do not look like the rest.
+// The dominant shape is the most common combination of type and key set (ties go to the one seen
+// first). Other elements are reported with their missing and extra keys, as are elements whose
+// field types differ from the type most common for that field; null matches any type.
+func (a *App) CheckArrayHomogeneity(input string, path string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	arr := resolvePath(validInput, path)
+	if !arr.IsArray() {
+		return JSONResponse{Success: false, Error: "路径不是数组: " + path}
+	}
+	elements := arr.Array()
+	report := HomogeneityReport{Total: len(elements), Outliers: []ArrayOutlier{}}
+	if len(elements) == 0 {
+		return marshalHomogeneityReport(report)
+	}
+
+	fingerprints := make([]string, len(elements))
+	shapeCounts := make(map[string]int)
+	fieldTypes := make(map[string]map[string]int)
+	for idx, elem := range elements {
+		fingerprints[idx] = shapeFingerprint(elem)
+		shapeCounts[fingerprints[idx]]++
+		elem.ForEach(func(key, value gjson.Result) bool {
+			if elem.IsObject() && value.Type != gjson.Null {
+				name := key.String()
+				if fieldTypes[name] == nil {
+					fieldTypes[name] = make(map[string]int)
+				}
+				fieldTypes[name][jsonTypeName(value)]++
+			}
+			return true
+		})
+	}
+
+	dominantIdx := 0
+	for idx, fingerprint := range fingerprints {
+		if shapeCounts[fingerprint] > shapeCounts[fingerprints[dominantIdx]] {
+			dominantIdx = idx
+		}
+	}
+	dominant := elements[dominantIdx]
+	report.Dominant = &ArrayShape{Type: jsonTypeName(dominant)}
+	dominantKeys := make(map[string]bool)
+	if dominant.IsObject() {
+		report.Dominant.Keys = []string{}
+		dominant.ForEach(func(key, _ gjson.Result) bool {
+			if !dominantKeys[key.String()] {
+				dominantKeys[key.String()] = true
+				report.Dominant.Keys = append(report.Dominant.Keys, key.String())
+			}
+			return true
+		})
+	}
+
+	elementPath := path
+	if elementPath == "" {
+		elementPath = "$"
+	} else if !strings.HasPrefix(elementPath, "$") {
+		elementPath = "$." + elementPath
+	}
+	for idx, elem := range elements {
+		outlier := ArrayOutlier{Index: idx, Path: elementPath + "[" + strconv.Itoa(idx) + "]", Type: jsonTypeName(elem)}
+		deviates := outlier.Type != report.Dominant.Type
+		if elem.IsObject() && dominant.IsObject() {
+			present := make(map[string]bool)
+			elem.ForEach(func(key, value gjson.Result) bool {
+				name := key.String()
+				if present[name] {
+					return true
+				}
+				present[name] = true
+				if !dominantKeys[name] {
+					outlier.ExtraKeys = append(outlier.ExtraKeys, name)
+				}
+				if value.Type != gjson.Null {
+					if expected := dominantType(fieldTypes[name]); jsonTypeName(value) != expected {
+						if outlier.TypeMismatches == nil {
+							outlier.TypeMismatches = make(map[string]string)
+						}
+						outlier.TypeMismatches[name] = jsonTypeName(value) + " (expected " + expected + ")"
+					}
+				}
+				return true
+			})
+			for _, name := range report.Dominant.Keys {
+				if !present[name] {
+					outlier.MissingKeys = append(outlier.MissingKeys, name)
+				}
+			}
+			deviates = len(outlier.ExtraKeys) > 0 || len(outlier.MissingKeys) > 0 || outlier.TypeMismatches != nil
+		}
+		if deviates {
+			report.Outliers = append(report.Outliers, outlier)
+		} else {
+			report.Conforming++
+		}
+	}
+
+	return marshalHomogeneityReport(report)
+}
+
+// marshalHomogeneityReport returns report as an indented JSON response
+func marshalHomogeneityReport(report HomogeneityReport) JSONResponse {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return JSONResponse{Success: false, Error: "生成报告失败: " + err.Error()}
+	}
+	return indentedResponse(string(data))
+}
+
+// shapeFingerprint identifies the shape of an element: its type, plus the sorted key set for objects
+func shapeFingerprint(elem gjson.Result) string {
+	if !elem.IsObject() {
+		return jsonTypeName(elem)
+	}
+	var keys []string
+	elem.ForEach(func(key, _ gjson.Result) bool {
+		keys = append(keys, key.String())
+		return true
+	})
+	sort.Strings(keys)
+	encoded, _ := json.Marshal(keys)
+	return "object" + string(encoded)
+}
+
+// dominantType returns the most common type in counts, preferring the earlier entry of
+// jsonTypeNames on ties so the result does not depend on map order
+func dominantType(counts map[string]int) string {
+	best := ""
+	for _, typeName := range jsonTypeNames {
+		if counts[typeName] > counts[best] {
+			best = typeName
+		}
+	}
+	return best
+}
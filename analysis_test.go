@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	input := `{"orders": [
+		{"id": 1, "amount": 10.5, "qty": 2},
+		{"id": 2, "amount": 4, "qty": null},
+		{"id": 3, "amount": "n/a", "qty": 5},
+		{"id": 4, "qty": 1},
+		{"id": 5, "amount": -2.5, "qty": 3}
+	]}`
+	tests := []struct {
+		name string
+		agg  Aggregation
+		want string
+	}{
+		{"sum", Aggregation{Field: "amount", Operation: "sum", Output: "total"}, `{"results":{"total":12},"skipped":{"total":2}}`},
+		{"avg", Aggregation{Field: "amount", Operation: "avg", Output: "mean"}, `{"results":{"mean":4},"skipped":{"mean":2}}`},
+		{"min", Aggregation{Field: "amount", Operation: "min"}, `{"results":{"min_amount":-2.5},"skipped":{"min_amount":2}}`},
+		{"max", Aggregation{Field: "qty", Operation: "max"}, `{"results":{"max_qty":5},"skipped":{"max_qty":1}}`},
+		{"count counts non-numeric values", Aggregation{Field: "amount", Operation: "count"}, `{"results":{"count_amount":4},"skipped":{"count_amount":1}}`},
+		{"no numeric values", Aggregation{Field: "missing", Operation: "avg"}, `{"results":{"avg_missing":null},"skipped":{"avg_missing":5}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().Aggregate(input, "$.orders", []Aggregation{tt.agg})
+			if !resp.Success {
+				t.Fatalf("Aggregate failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("Aggregate = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	app := NewApp()
+	if resp := app.Aggregate(`{"a": 1}`, "$.a", []Aggregation{{Field: "x", Operation: "sum"}}); resp.Success {
+		t.Errorf("Aggregate on a number = %+v, want an error", resp)
+	}
+	if resp := app.Aggregate(`[{"x": 1}]`, "$", []Aggregation{{Field: "x", Operation: "median"}}); resp.Success {
+		t.Errorf("Aggregate with an unknown operation = %+v, want an error", resp)
+	}
+}
+
+func TestComplexityReport(t *testing.T) {
+	blob := strings.Repeat("x", 50)
+	input := `{
+		"meta": {"a": 1, "b": 2, "c": 3, "d": 4},
+		"list": [1, 2, 3, [4, 5]],
+		"deep": {"l1": {"l2": {"l3": "leaf"}}},
+		"strings": ["aa", "bbbbbb", "c", "dddd", "eeeee", "ffffff"],
+		"blob": "` + blob + `"
+	}`
+	resp := NewApp().ComplexityReport(input)
+	if !resp.Success {
+		t.Fatalf("ComplexityReport failed: %s", resp.Error)
+	}
+	var report ComplexityReport
+	if err := json.Unmarshal([]byte(resp.Data), &report); err != nil {
+		t.Fatalf("ComplexityReport returned invalid JSON: %v", err)
+	}
+
+	checks := []struct {
+		name string
+		got  *PathMeasure
+		want PathMeasure
+	}{
+		{"deepest", report.Deepest, PathMeasure{Path: "$.deep.l1.l2.l3", Size: 4}},
+		{"widest object", report.WidestObject, PathMeasure{Path: "$", Size: 5}},
+		{"longest array", report.LongestArray, PathMeasure{Path: "$.strings", Size: 6}},
+	}
+	for _, c := range checks {
+		if c.got == nil || *c.got != c.want {
+			t.Errorf("%s = %+v, want %+v", c.name, c.got, c.want)
+		}
+	}
+
+	var paths []string
+	for _, s := range report.LongestStrings {
+		paths = append(paths, s.Path)
+	}
+	// Ties keep document order
+	wantPaths := []string{"$.blob", "$.strings[1]", "$.strings[5]", "$.strings[4]", "$.deep.l1.l2.l3"}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("longest strings = %v, want %v", paths, wantPaths)
+	}
+	if preview := report.LongestStrings[0].Preview; preview != blob[:40]+"..." || report.LongestStrings[0].Size != 50 {
+		t.Errorf("longest string = %+v, want a 40 character preview of 50", report.LongestStrings[0])
+	}
+}
+
+func TestComplexityReportScalar(t *testing.T) {
+	resp := NewApp().ComplexityReport(`"text"`)
+	if !resp.Success {
+		t.Fatalf("ComplexityReport failed: %s", resp.Error)
+	}
+	if got, want := compactJSON(t, resp.Data), `{"deepest":{"path":"$","size":0},"widestObject":null,"longestArray":null,"longestStrings":[{"path":"$","size":4,"preview":"text"}]}`; got != want {
+		t.Errorf("ComplexityReport = %s, want %s", got, want)
+	}
+}
+
+func TestKeyTypeSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"mixed types across records",
+			`[{"id": 1, "age": 30, "tags": ["a"]}, {"id": 2, "age": "unknown", "tags": null}, {"id": "3", "age": null, "tags": "a"}]`,
+			`{"id":{"string":1,"number":2},"age":{"string":1,"number":1,"null":1},"tags":{"string":1,"null":1,"array":1}}`},
+		{"aggregated by name across paths",
+			`{"user": {"name": "a", "meta": {"name": true}}, "items": [{"name": {"first": "b"}}]}`,
+			`{"user":{"object":1},"name":{"string":1,"boolean":1,"object":1},"meta":{"object":1},"items":{"array":1},"first":{"string":1}}`},
+		{"consistent types", `[{"ok": true}, {"ok": false}]`, `{"ok":{"boolean":2}}`},
+		{"no keys", `[1, "a", [null]]`, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().KeyTypeSummary(tt.input)
+			if !resp.Success {
+				t.Fatalf("KeyTypeSummary failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("KeyTypeSummary = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldPresenceReport(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		want  string
+	}{
+		{"varying presence",
+			`{"rows": [{"id": 1, "name": "a", "email": null}, {"id": 2, "name": "b"}, {"id": 3, "phone": "x"}]}`, "$.rows",
+			`{"records":3,"skipped":0,"fields":{"id":{"count":3,"percent":100,"presence":"always"},"name":{"count":2,"percent":66.67,"presence":"sometimes"},"email":{"count":1,"percent":33.33,"presence":"sometimes"},"phone":{"count":1,"percent":33.33,"presence":"sometimes"}}}`},
+		{"non-objects skipped", `[{"a": 1}, 2, null, {"a": 2, "b": 3}]`, "$",
+			`{"records":2,"skipped":2,"fields":{"a":{"count":2,"percent":100,"presence":"always"},"b":{"count":1,"percent":50,"presence":"sometimes"}}}`},
+		{"duplicate keys counted once", `[{"a": 1, "a": 2}, {"b": 1}]`, "$",
+			`{"records":2,"skipped":0,"fields":{"a":{"count":1,"percent":50,"presence":"sometimes"},"b":{"count":1,"percent":50,"presence":"sometimes"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().FieldPresenceReport(tt.input, tt.path)
+			if !resp.Success {
+				t.Fatalf("FieldPresenceReport failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("FieldPresenceReport = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	app := NewApp()
+	if resp := app.FieldPresenceReport(`{"a": 1}`, "$.a"); resp.Success {
+		t.Errorf("FieldPresenceReport on a number = %+v, want an error", resp)
+	}
+	if resp := app.FieldPresenceReport(`[1, "x"]`, "$"); resp.Success {
+		t.Errorf("FieldPresenceReport without records = %+v, want an error", resp)
+	}
+}
+
+func TestCheckArrayHomogeneity(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		want  string
+	}{
+		{"mostly uniform records",
+			`{"users": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": "3", "name": "c"}, {"id": 4}, {"id": 5, "name": "e", "admin": true}, {"id": 6, "name": null}, "oops"]}`, "$.users",
+			`{"total":7,"conforming":3,"dominant":{"type":"object","keys":["id","name"]},"outliers":[{"index":2,"path":"$.users[2]","type":"object","typeMismatches":{"id":"string (expected number)"}},{"index":3,"path":"$.users[3]","type":"object","missingKeys":["name"]},{"index":4,"path":"$.users[4]","type":"object","extraKeys":["admin"]},{"index":6,"path":"$.users[6]","type":"string"}]}`},
+		{"scalars", `[1, 2, "x", 3]`, "$",
+			`{"total":4,"conforming":3,"dominant":{"type":"number"},"outliers":[{"index":2,"path":"$[2]","type":"string"}]}`},
+		{"path without $", `{"a": [[1], [2], {"k": 1}]}`, "a",
+			`{"total":3,"conforming":2,"dominant":{"type":"array"},"outliers":[{"index":2,"path":"$.a[2]","type":"object"}]}`},
+		{"tie goes to the first shape", `[{"a": 1}, {"b": 1}]`, "",
+			`{"total":2,"conforming":1,"dominant":{"type":"object","keys":["a"]},"outliers":[{"index":1,"path":"$[1]","type":"object","missingKeys":["a"],"extraKeys":["b"]}]}`},
+		{"empty array", `[]`, "$", `{"total":0,"conforming":0,"dominant":null,"outliers":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().CheckArrayHomogeneity(tt.input, tt.path)
+			if !resp.Success {
+				t.Fatalf("CheckArrayHomogeneity failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("CheckArrayHomogeneity = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if resp := NewApp().CheckArrayHomogeneity(`{"a": 1}`, "a"); resp.Success {
+		t.Errorf("CheckArrayHomogeneity on a number = %+v, want an error", resp)
+	}
+}
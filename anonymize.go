@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+)
+
+// AnonymizeConsistent replaces the values of keys matching any of keyPatterns (glob patterns
+// such as "*_id" or "email", case-insensitive) with fake values of the same shape. The same
+// original value always maps to the same fake value for a given seed, so references between
+// records stay linkable. Containers under a matching key are anonymized entirely; an empty
+// pattern list anonymizes every value. Booleans and nulls are kept.
+func (a *App) AnonymizeConsistent(input string, keyPatterns []string, seed string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	patterns := make([]string, 0, len(keyPatterns))
+	for _, pattern := range keyPatterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return JSONResponse{Success: false, Error: "无效的键模式: " + pattern}
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	anon := &anonymizer{key: []byte(seed), patterns: patterns}
+	return indentedResponse(anon.rewrite(gjson.Parse(validInput), len(patterns) == 0))
+}
+
+// anonymizer derives fake values from a keyed hash of the original value
+type anonymizer struct {
+	key      []byte
+	patterns []string
+}
+
+// matches reports whether an object key matches one of the patterns
+func (an *anonymizer) matches(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range an.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rewrite rebuilds res as compact JSON, anonymizing scalars when active is set
+func (an *anonymizer) rewrite(res gjson.Result, active bool) string {
+	switch {
+	case res.IsObject():
+		var parts []string
+		res.ForEach(func(key, value gjson.Result) bool {
+			parts = append(parts, key.Raw+":"+an.rewrite(value, active || an.matches(key.String())))
+			return true
+		})
+		return "{" + strings.Join(parts, ",") + "}"
+	case res.IsArray():
+		var parts []string
+		res.ForEach(func(_, value gjson.Result) bool {
+			parts = append(parts, an.rewrite(value, active))
+			return true
+		})
+		return "[" + strings.Join(parts, ",") + "]"
+	case active && res.Type == gjson.String:
+		fake, _ := json.Marshal(an.fakeString(res.String()))
+		return string(fake)
+	case active && res.Type == gjson.Number:
+		return an.fakeNumber(res.Raw)
+	default:
+		return res.Raw
+	}
+}
+
+// fakeString maps every letter and digit to a pseudo-random one of the same class, keeping
+// punctuation such as '@', '.' and '-' so emails, phone numbers and IDs keep their format
+func (an *anonymizer) fakeString(s string) string {
+	stream := an.stream("s:" + s)
+	var builder strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			builder.WriteRune('0' + rune(stream.next()%10))
+		case unicode.Is(unicode.Han, r):
+			builder.WriteRune(0x4e00 + rune(stream.next()%0x51a6))
+		case unicode.IsUpper(r):
+			builder.WriteRune('A' + rune(stream.next()%26))
+		case unicode.IsLetter(r):
+			builder.WriteRune('a' + rune(stream.next()%26))
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// fakeNumber replaces the mantissa digits of a JSON number, keeping its sign, number of
+// digits, decimal point and exponent so the magnitude stays similar
+func (an *anonymizer) fakeNumber(raw string) string {
+	stream := an.stream("n:" + raw)
+	mantissa, exponent := raw, ""
+	if idx := strings.IndexAny(raw, "eE"); idx != -1 {
+		mantissa, exponent = raw[:idx], raw[idx:]
+	}
+
+	out := []byte(mantissa)
+	leading := true
+	for idx, c := range out {
+		if c < '0' || c > '9' {
+			continue
+		}
+		// A multi-digit integer part must not start with zero
+		if leading && idx+1 < len(out) && out[idx+1] >= '0' && out[idx+1] <= '9' {
+			out[idx] = '1' + byte(stream.next()%9)
+		} else {
+			out[idx] = '0' + byte(stream.next()%10)
+		}
+		leading = false
+	}
+	return string(out) + exponent
+}
+
+// hashStream yields pseudo-random values from HMAC-SHA256 in counter mode
+type hashStream struct {
+	key     []byte
+	data    string
+	counter uint32
+	buf     []byte
+}
+
+// stream starts a deterministic value stream for one original value
+func (an *anonymizer) stream(data string) *hashStream {
+	return &hashStream{key: an.key, data: data}
+}
+
+// next returns the next pseudo-random value
+func (h *hashStream) next() uint32 {
+	if len(h.buf) < 4 {
+		mac := hmac.New(sha256.New, h.key)
+		var counter [4]byte
+		binary.BigEndian.PutUint32(counter[:], h.counter)
+		mac.Write(counter[:])
+		mac.Write([]byte(h.data))
+		h.buf = mac.Sum(nil)
+		h.counter++
+	}
+	value := binary.BigEndian.Uint32(h.buf)
+	h.buf = h.buf[4:]
+	return value
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestAnonymizeConsistent(t *testing.T) {
+	input := `{
+		"users": [
+			{"user_id": 1042, "email": "alice@example.com", "name": "Alice", "active": true, "score": -3.25},
+			{"user_id": 2077, "email": "bob@example.org", "name": "Bob", "active": false, "score": 12}
+		],
+		"orders": [{"order_id": "A-17", "user_id": 1042, "note": null}]
+	}`
+	resp := NewApp().AnonymizeConsistent(input, []string{"*_id", "EMAIL", "name", "score"}, "seed")
+	if !resp.Success {
+		t.Fatalf("AnonymizeConsistent failed: %s", resp.Error)
+	}
+	out := gjson.Parse(resp.Data)
+
+	// The same original value maps to the same fake value, so references stay linkable
+	if first, ref := out.Get("users.0.user_id").Raw, out.Get("orders.0.user_id").Raw; first != ref {
+		t.Errorf("user_id 1042 anonymized to %s and %s", first, ref)
+	}
+	if out.Get("users.0.user_id").Raw == "1042" || out.Get("users.0.email").String() == "alice@example.com" {
+		t.Errorf("values were not anonymized:\n%s", resp.Data)
+	}
+
+	formats := []struct {
+		path    string
+		pattern string
+	}{
+		{"users.0.user_id", `^[1-9][0-9]{3}$`},
+		{"users.1.user_id", `^[1-9][0-9]{3}$`},
+		{"users.0.email", `^[a-z]{5}@[a-z]{7}\.[a-z]{3}$`},
+		{"users.0.name", `^[A-Z][a-z]{4}$`},
+		{"users.0.score", `^-[0-9]\.[0-9]{2}$`},
+		{"users.1.score", `^[1-9][0-9]$`},
+		{"orders.0.order_id", `^[A-Z]-[0-9]{2}$`},
+	}
+	for _, f := range formats {
+		if got := out.Get(f.path).String(); !regexp.MustCompile(f.pattern).MatchString(got) {
+			t.Errorf("%s = %q, want a value matching %s", f.path, got, f.pattern)
+		}
+	}
+
+	// Booleans, nulls and unmatched keys are kept
+	if out.Get("users.0.active").Raw != "true" || out.Get("orders.0.note").Raw != "null" {
+		t.Errorf("booleans or nulls were changed:\n%s", resp.Data)
+	}
+
+	// The seed makes the output reproducible
+	if again := NewApp().AnonymizeConsistent(input, []string{"*_id", "EMAIL", "name", "score"}, "seed"); again.Data != resp.Data {
+		t.Errorf("AnonymizeConsistent is not reproducible for the same seed")
+	}
+	if other := NewApp().AnonymizeConsistent(input, []string{"*_id", "EMAIL", "name", "score"}, "other"); other.Data == resp.Data {
+		t.Errorf("AnonymizeConsistent ignores the seed")
+	}
+}
+
+func TestAnonymizeConsistentPatterns(t *testing.T) {
+	app := NewApp()
+	resp := app.AnonymizeConsistent(`{"a": "x", "b": {"c": 1}}`, nil, "s")
+	if !resp.Success || gjson.Get(resp.Data, "a").String() == "x" {
+		t.Errorf("AnonymizeConsistent without patterns = %+v, want every value anonymized", resp)
+	}
+	resp = app.AnonymizeConsistent(`{"secret": {"k": "v", "n": [1, 2]}, "keep": "v"}`, []string{"secret"}, "s")
+	if !resp.Success || gjson.Get(resp.Data, "secret.k").String() == "v" || gjson.Get(resp.Data, "keep").String() != "v" {
+		t.Errorf("AnonymizeConsistent of a container = %+v", resp)
+	}
+	if resp := app.AnonymizeConsistent(`{}`, []string{"[a"}, "s"); resp.Success {
+		t.Errorf("AnonymizeConsistent with an invalid pattern = %+v, want an error", resp)
+	}
+}
@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/pretty"
@@ -40,28 +48,57 @@ func (a *App) startup(ctx context.Context) {
 	if len(os.Args) > 1 {
 		filePath := os.Args[1]
 		a.openFile(filePath)
+	} else {
+		// Piped input is read in the background, so a pipe that is never closed does not
+		// keep the window from opening
+		go func() {
+			if content, ok := readPipedInput(0); ok {
+				a.openContent(content)
+			}
+		}()
 	}
 
 	// Start a local server to listen for file open requests from other instances
 	go a.startSingleInstanceServer()
 }
 
-// openFile reads a file and emits an event to the frontend
+// openFile reads a file and emits an event to the frontend. The file is decoded like ReadFile:
+// UTF-16 and UTF-32 files with a BOM are transcoded, and invalid UTF-8 bytes are replaced with
+// U+FFFD and reported in the event's warning.
 func (a *App) openFile(filePath string) {
-	if _, err := os.Stat(filePath); err == nil {
-		content, err := os.ReadFile(filePath)
-		if err == nil {
-			go func() {
-				// Wait for frontend to be ready
-				time.Sleep(1000 * time.Millisecond)
-				wailsruntime.EventsEmit(a.ctx, "open-file", map[string]string{
-					"path":    filePath,
-					"name":    filepath.Base(filePath),
-					"content": string(content),
-				})
-			}()
-		}
+	resp := a.ReadFile(filePath, "", true)
+	if !resp.Success {
+		return
 	}
+	go func() {
+		// Wait for frontend to be ready
+		time.Sleep(1000 * time.Millisecond)
+		wailsruntime.EventsEmit(a.ctx, "open-file", map[string]string{
+			"path":    filePath,
+			"name":    filepath.Base(filePath),
+			"content": resp.Data,
+			"warning": resp.Warning,
+		})
+	}()
+}
+
+// openContent repairs and formats JSON piped to this or another instance and emits it to the
+// frontend as a new tab. Content that cannot be repaired is shown as it is, with the error.
+func (a *App) openContent(content string) {
+	resp := a.ProcessJSON(content, "4", false, true)
+	if resp.Success && resp.Data != "" {
+		content = resp.Data
+	}
+	go func() {
+		// Wait for frontend to be ready
+		time.Sleep(1000 * time.Millisecond)
+		wailsruntime.EventsEmit(a.ctx, "open-content", map[string]interface{}{
+			"name":     "标准输入",
+			"content":  content,
+			"repaired": resp.Repaired,
+			"error":    resp.Error,
+		})
+	}()
 }
 
 // startSingleInstanceServer listens on a local port for other instances
@@ -80,14 +117,17 @@ func (a *App) startSingleInstanceServer() {
 		}
 		go func(c net.Conn) {
 			defer c.Close()
-			buf := make([]byte, 1024)
-			n, err := c.Read(buf)
-			if err == nil && n > 0 {
-				filePath := string(buf[:n])
-				a.openFile(filePath)
-				// Bring window to front
-				wailsruntime.WindowShow(a.ctx)
+			msg, err := readInstanceMessage(c)
+			if err != nil {
+				return
+			}
+			if msg.kind == "content" {
+				a.openContent(msg.payload)
+			} else {
+				a.openFile(msg.payload)
 			}
+			// Bring window to front
+			wailsruntime.WindowShow(a.ctx)
 		}(conn)
 	}
 }
@@ -141,10 +181,53 @@ type JSONResponse struct {
 	Data     string `json:"data"`
 	Error    string `json:"error"`
 	Repaired bool   `json:"repaired"`
+	Warning  string `json:"warning"`
+}
+
+// FormatOptions holds the formatting settings of ProcessJSONOpts. The JSON tags match the
+// formatOptions of a frontend tab, so its settings can be passed as they are.
+type FormatOptions struct {
+	// Indent is "tab" or a number of spaces, see resolveIndent
+	Indent         string `json:"indent"`
+	TrimWhitespace bool   `json:"trimWhitespace"`
+	KeepOrder      bool   `json:"keepOrder"`
+	// DuplicateKeyStrategy sets how keys that occur more than once in an object are handled:
+	// "keep-first", "keep-last", "rename" or "error". The default "" keeps them all when key
+	// order is preserved and the last one otherwise. With whitespace trimming, keys that only
+	// differ by surrounding whitespace count as duplicates.
+	DuplicateKeyStrategy string `json:"duplicateKeyStrategy,omitempty"`
+	// SortKeys sorts the members of every object by key. Unlike KeepOrder false, values keep
+	// their source text, so large numbers are not rounded and duplicate keys are kept.
+	SortKeys bool `json:"sortKeys,omitempty"`
+	// TruncatedBase64 is RepairOptions.TruncatedBase64: "pad" or "marker" for a base64 value
+	// cut off by the end of the input
+	TruncatedBase64 string `json:"truncatedBase64,omitempty"`
+	// HashComments is RepairOptions.HashComments: also skip # line comments
+	HashComments bool `json:"hashComments,omitempty"`
+	// PreserveSpecialWhitespace is RepairOptions.PreserveSpecialWhitespace: keep non-breaking,
+	// ideographic and other Unicode spaces at the end of unquoted values, e.g. in CJK text
+	PreserveSpecialWhitespace bool `json:"preserveSpecialWhitespace,omitempty"`
+	// TrailingNewline ends the formatted output with a single newline, as POSIX tools and git expect
+	TrailingNewline bool `json:"trailingNewline,omitempty"`
+	// ExpansionWarningRatio sets a Warning when repairing made the document more than this many
+	// times as large as the input, e.g. by filling a sparse array with nulls or closing many
+	// nested structures, which often means the repair guessed wrong. 0 disables the warning.
+	ExpansionWarningRatio float64 `json:"expansionWarningRatio,omitempty"`
 }
 
 // ProcessJSON handles the flow: Validate -> Repair (if needed) -> Format
 func (a *App) ProcessJSON(input string, indent string, trimWhitespace bool, keepOrder bool) JSONResponse {
+	return a.ProcessJSONOpts(input, FormatOptions{Indent: indent, TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ProcessJSONOpts is ProcessJSON with its settings in a FormatOptions, so callers only set the
+// fields they need
+func (a *App) ProcessJSONOpts(input string, opts FormatOptions) JSONResponse {
+	indent, trimWhitespace, keepOrder := opts.Indent, opts.TrimWhitespace, opts.KeepOrder
+	if opts.SortKeys {
+		// Sorting is done on the source text, not by remarshaling
+		keepOrder = true
+	}
 	if input == "" {
 		return JSONResponse{Success: true, Data: "", Repaired: false}
 	}
@@ -154,14 +237,24 @@ func (a *App) ProcessJSON(input string, indent string, trimWhitespace bool, keep
 
 	// 1. Try strict validation first
 	if !gjson.Valid(input) {
-		// 2. If invalid, try to repair
-		repairedText, err := JSONRepair(input, trimWhitespace)
+		// 2. If invalid, try to repair. Input with only whitespace or comments is treated
+		// like empty input, so live formatting does not flash errors while typing.
+		repairedText, err := JSONRepairWithOptions(input, RepairOptions{
+			TrimWhitespace:            trimWhitespace,
+			AllowEmpty:                true,
+			PreserveSpecialWhitespace: opts.PreserveSpecialWhitespace,
+			TruncatedBase64:           opts.TruncatedBase64,
+			HashComments:              opts.HashComments,
+		})
 		if err != nil {
 			return JSONResponse{
 				Success: false,
 				Error:   "无法解析 JSON: " + err.Error(),
 			}
 		}
+		if repairedText == "" {
+			return JSONResponse{Success: true, Data: "", Repaired: false}
+		}
 
 		// Verify repaired JSON is actually valid
 		if !gjson.Valid(repairedText) {
@@ -173,6 +266,16 @@ func (a *App) ProcessJSON(input string, indent string, trimWhitespace bool, keep
 		finalJSON = repairedText
 		repaired = true
 	}
+	warning := expansionWarning(input, finalJSON, opts.ExpansionWarningRatio)
+
+	// Resolve duplicate keys before branching, so both formatting paths agree on them
+	if opts.DuplicateKeyStrategy != "" {
+		resolved, err := ResolveDuplicateKeys(finalJSON, opts.DuplicateKeyStrategy, trimWhitespace)
+		if err != nil {
+			return JSONResponse{Success: false, Error: "重复键处理失败: " + err.Error()}
+		}
+		finalJSON = resolved
+	}
 
 	// 3. Format the result
 	var formatted []byte
@@ -184,11 +287,15 @@ func (a *App) ProcessJSON(input string, indent string, trimWhitespace bool, keep
 		trimmedCompact := a.reconstructAndTrim(gjson.Parse(finalJSON))
 		finalJSON = trimmedCompact
 	}
+	if opts.SortKeys {
+		finalJSON = sortedKeysJSON(gjson.Parse(finalJSON))
+	}
 
 	if !keepOrder {
-		// If we don't need to keep order, standard json package sorts keys alphabetically
+		// If we don't need to keep order, standard json package sorts keys alphabetically.
+		// Numbers keep their source token instead of going through float64.
 		var obj interface{}
-		if err := json.Unmarshal([]byte(finalJSON), &obj); err != nil {
+		if err := unmarshalKeepNumbers([]byte(finalJSON), &obj); err != nil {
 			return JSONResponse{Success: false, Error: "解析错误: " + err.Error()}
 		}
 
@@ -196,23 +303,10 @@ func (a *App) ProcessJSON(input string, indent string, trimWhitespace bool, keep
 			obj = a.trimStrings(obj)
 		}
 
-		if indent == "tab" {
-			formatted, err = json.MarshalIndent(obj, "", "\t")
-		} else if indent == "2" {
-			formatted, err = json.MarshalIndent(obj, "", "  ")
-		} else {
-			formatted, err = json.MarshalIndent(obj, "", "    ")
-		}
+		formatted, err = json.MarshalIndent(obj, "", resolveIndent(indent))
 	} else {
 		// We want to keep order. If trimWhitespace was handled above, finalJSON is already trimmed.
-		var indentStr string
-		if indent == "tab" {
-			indentStr = "\t"
-		} else if indent == "2" {
-			indentStr = "  "
-		} else {
-			indentStr = "    "
-		}
+		indentStr := resolveIndent(indent)
 
 		var buf bytes.Buffer
 		err = json.Indent(&buf, []byte(finalJSON), "", indentStr)
@@ -234,8 +328,69 @@ func (a *App) ProcessJSON(input string, indent string, trimWhitespace bool, keep
 
 	return JSONResponse{
 		Success:  true,
-		Data:     string(formatted),
+		Data:     withTrailingNewline(string(formatted), opts.TrailingNewline),
 		Repaired: repaired,
+		Warning:  warning,
+	}
+}
+
+// expansionWarning returns the warning for a repair that grew input into repaired beyond
+// maxRatio. Sizes are compared before formatting, which adds whitespace of its own.
+func expansionWarning(input string, repaired string, maxRatio float64) string {
+	if maxRatio <= 0 || repaired == input {
+		return ""
+	}
+	ratio := float64(len(repaired)) / float64(len(input))
+	if ratio <= maxRatio {
+		return ""
+	}
+	return fmt.Sprintf("修复后的内容是输入的 %.1f 倍，请检查修复结果是否正确", ratio)
+}
+
+// withTrailingNewline appends a single trailing newline to s when enabled
+func withTrailingNewline(s string, enabled bool) string {
+	if !enabled || s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
+
+// DetectIndent samples the first indented lines of an already formatted document and
+// returns its indentation: "tab", "2", "4" or another number of spaces. Minified input
+// or input without indented lines returns "none".
+func (a *App) DetectIndent(input string) JSONResponse {
+	const maxSamples = 20
+	tabs, spaces := 0, 0
+	unit := 0
+	for _, line := range strings.Split(input, "\n") {
+		if tabs+spaces >= maxSamples {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == '\t' {
+			tabs++
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " "))
+		if width == 0 {
+			continue
+		}
+		spaces++
+		// The indent unit is the greatest common divisor of all sampled widths
+		for width != 0 {
+			unit, width = width, unit%width
+		}
+	}
+
+	switch {
+	case tabs == 0 && spaces == 0:
+		return JSONResponse{Success: true, Data: "none"}
+	case tabs >= spaces:
+		return JSONResponse{Success: true, Data: "tab"}
+	default:
+		return JSONResponse{Success: true, Data: strconv.Itoa(unit)}
 	}
 }
 
@@ -263,6 +418,37 @@ func (a *App) trimStrings(i interface{}) interface{} {
 	}
 }
 
+// sortedKeysJSON rebuilds res as compact JSON with the members of every object sorted by key.
+// Keys and values keep their source text and members with the same key keep their order.
+func sortedKeysJSON(res gjson.Result) string {
+	if res.IsArray() {
+		var elements []string
+		res.ForEach(func(_, value gjson.Result) bool {
+			elements = append(elements, sortedKeysJSON(value))
+			return true
+		})
+		return "[" + strings.Join(elements, ",") + "]"
+	}
+	if res.IsObject() {
+		type member struct {
+			key  string
+			text string
+		}
+		var members []member
+		res.ForEach(func(key, value gjson.Result) bool {
+			members = append(members, member{key.String(), key.Raw + ":" + sortedKeysJSON(value)})
+			return true
+		})
+		sort.SliceStable(members, func(i, j int) bool { return members[i].key < members[j].key })
+		texts := make([]string, len(members))
+		for idx, m := range members {
+			texts[idx] = m.text
+		}
+		return "{" + strings.Join(texts, ",") + "}"
+	}
+	return res.Raw
+}
+
 // reconstructAndTrim recursively traverses a gjson.Result and reconstructs the JSON string
 // while trimming strings and preserving original key order.
 func (a *App) reconstructAndTrim(res gjson.Result) string {
@@ -291,7 +477,7 @@ func (a *App) reconstructAndTrim(res gjson.Result) string {
 			}
 			// Trim key
 			trimmedKey := strings.Trim(key.String(), " \n\t\r\f\b")
-			sb.WriteString(fmt.Sprintf("%q:", trimmedKey))
+			sb.WriteString(canonicalString(trimmedKey) + ":")
 			sb.WriteString(a.reconstructAndTrim(value))
 			first = false
 			return true
@@ -300,28 +486,45 @@ func (a *App) reconstructAndTrim(res gjson.Result) string {
 		return sb.String()
 	}
 	if res.Type == gjson.String {
-		return fmt.Sprintf("%q", strings.Trim(res.String(), " \n\t\r\f\b"))
+		return canonicalString(strings.Trim(res.String(), " \n\t\r\f\b"))
 	}
 	// For other types (Number, True, False, Null), use Raw
 	return res.Raw
 }
 
+// maxIndentWidth caps numeric indent widths
+const maxIndentWidth = 16
+
+// resolveIndent returns the indentation for an indent setting: "tab", or a number of spaces
+// such as "2", "3" or "8", capped at maxIndentWidth. Anything else means 4 spaces.
+func resolveIndent(indent string) string {
+	if indent == "tab" {
+		return "\t"
+	}
+	width, err := strconv.Atoi(indent)
+	if err != nil || width <= 0 {
+		return "    "
+	}
+	if width > maxIndentWidth {
+		width = maxIndentWidth
+	}
+	return strings.Repeat(" ", width)
+}
+
 // FormatJSON beautifies the JSON string
 func (a *App) FormatJSON(input string, indent string, trimWhitespace bool, keepOrder bool) JSONResponse {
+	return a.FormatJSONOpts(input, FormatOptions{Indent: indent, TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// FormatJSONOpts is FormatJSON with its settings in a FormatOptions
+func (a *App) FormatJSONOpts(input string, opts FormatOptions) JSONResponse {
 	// If it's invalid or we need to trim whitespace or sort keys, use ProcessJSON which handles these cases
-	if !gjson.Valid(input) || trimWhitespace || !keepOrder {
-		return a.ProcessJSON(input, indent, trimWhitespace, keepOrder)
+	if !gjson.Valid(input) || opts.TrimWhitespace || !opts.KeepOrder || opts.SortKeys || opts.DuplicateKeyStrategy != "" {
+		return a.ProcessJSONOpts(input, opts)
 	}
 
 	// For valid JSON without trimming and keeping order, use json.Indent to preserve order
-	var indentStr string
-	if indent == "tab" {
-		indentStr = "\t"
-	} else if indent == "2" {
-		indentStr = "  "
-	} else {
-		indentStr = "    "
-	}
+	indentStr := resolveIndent(opts.Indent)
 
 	var buf bytes.Buffer
 	err := json.Indent(&buf, []byte(input), "", indentStr)
@@ -329,28 +532,131 @@ func (a *App) FormatJSON(input string, indent string, trimWhitespace bool, keepO
 		return JSONResponse{Success: false, Error: err.Error()}
 	}
 
-	return JSONResponse{Success: true, Data: buf.String()}
+	return JSONResponse{Success: true, Data: withTrailingNewline(buf.String(), opts.TrailingNewline)}
+}
+
+// FormatJSONWithBraceStyle beautifies the JSON string with the given brace placement:
+// "kr" (default, opening brace on the key line) or "allman" (opening brace on its own line)
+func (a *App) FormatJSONWithBraceStyle(input string, indent string, trimWhitespace bool, keepOrder bool, braceStyle string) JSONResponse {
+	resp := a.FormatJSON(input, indent, trimWhitespace, keepOrder)
+	if !resp.Success || resp.Data == "" {
+		return resp
+	}
+
+	indentStr := resolveIndent(indent)
+
+	var sb strings.Builder
+	writeBraceStyled(&sb, gjson.Parse(resp.Data), indentStr, 0, braceStyle == "allman")
+	resp.Data = sb.String()
+	return resp
+}
+
+// writeBraceStyled pretty-prints res, placing opening braces/brackets of non-empty
+// containers on their own line when allman is true
+func writeBraceStyled(sb *strings.Builder, res gjson.Result, indentStr string, depth int, allman bool) {
+	if !res.IsObject() && !res.IsArray() {
+		sb.WriteString(res.Raw)
+		return
+	}
+
+	openChar, closeChar := "[", "]"
+	if res.IsObject() {
+		openChar, closeChar = "{", "}"
+	}
+	if !hasChildren(res) {
+		sb.WriteString(openChar + closeChar)
+		return
+	}
+
+	sb.WriteString(openChar)
+	first := true
+	res.ForEach(func(key, value gjson.Result) bool {
+		if !first {
+			sb.WriteString(",")
+		}
+		first = false
+		sb.WriteString("\n")
+		sb.WriteString(strings.Repeat(indentStr, depth+1))
+		if res.IsObject() {
+			sb.WriteString(key.Raw)
+			sb.WriteString(":")
+			if allman && hasChildren(value) {
+				sb.WriteString("\n")
+				sb.WriteString(strings.Repeat(indentStr, depth+1))
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+		writeBraceStyled(sb, value, indentStr, depth+1, allman)
+		return true
+	})
+	sb.WriteString("\n")
+	sb.WriteString(strings.Repeat(indentStr, depth))
+	sb.WriteString(closeChar)
+}
+
+// hasChildren reports whether res is a non-empty object or array
+func hasChildren(res gjson.Result) bool {
+	if !res.IsObject() && !res.IsArray() {
+		return false
+	}
+	found := false
+	res.ForEach(func(key, value gjson.Result) bool {
+		found = true
+		return false
+	})
+	return found
 }
 
 // MinifyJSON removes all whitespace
 func (a *App) MinifyJSON(input string, trimWhitespace bool, keepOrder bool) JSONResponse {
+	return a.MinifyJSONOpts(input, FormatOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// MinifyJSONOpts is MinifyJSON with its settings in a FormatOptions. Indent is ignored; invalid
+// input is repaired with the other options as in ProcessJSONOpts.
+func (a *App) MinifyJSONOpts(input string, opts FormatOptions) JSONResponse {
+	trimWhitespace, keepOrder := opts.TrimWhitespace, opts.KeepOrder
+	if opts.SortKeys {
+		// Sorting is done on the source text, not by remarshaling
+		keepOrder = true
+	}
 	finalJSON := input
+	repaired := false
+	warning := ""
 	if !gjson.Valid(input) {
-		resp := a.ProcessJSON(input, "0", trimWhitespace, keepOrder)
+		repairOpts := opts
+		repairOpts.Indent = "0"
+		repairOpts.TrailingNewline = false
+		resp := a.ProcessJSONOpts(input, repairOpts)
 		if !resp.Success {
 			return resp
 		}
 		finalJSON = resp.Data
+		repaired, warning = resp.Repaired, resp.Warning
+	}
+
+	// Resolve duplicate keys before branching, as in ProcessJSONOpts
+	if opts.DuplicateKeyStrategy != "" {
+		resolved, err := ResolveDuplicateKeys(finalJSON, opts.DuplicateKeyStrategy, trimWhitespace)
+		if err != nil {
+			return JSONResponse{Success: false, Error: "重复键处理失败: " + err.Error()}
+		}
+		finalJSON = resolved
 	}
 
 	if trimWhitespace && keepOrder {
 		// Use reconstructAndTrim to preserve order while trimming
 		finalJSON = a.reconstructAndTrim(gjson.Parse(finalJSON))
 	}
+	if opts.SortKeys {
+		finalJSON = sortedKeysJSON(gjson.Parse(finalJSON))
+	}
 
 	if !keepOrder {
+		// Numbers keep their source token, as in ProcessJSON
 		var obj interface{}
-		if err := json.Unmarshal([]byte(finalJSON), &obj); err != nil {
+		if err := unmarshalKeepNumbers([]byte(finalJSON), &obj); err != nil {
 			return JSONResponse{Success: false, Error: err.Error()}
 		}
 
@@ -362,7 +668,7 @@ func (a *App) MinifyJSON(input string, trimWhitespace bool, keepOrder bool) JSON
 		if err != nil {
 			return JSONResponse{Success: false, Error: err.Error()}
 		}
-		return JSONResponse{Success: true, Data: string(minified)}
+		return JSONResponse{Success: true, Data: withTrailingNewline(string(minified), opts.TrailingNewline), Repaired: repaired, Warning: warning}
 	}
 
 	var buf bytes.Buffer
@@ -371,23 +677,189 @@ func (a *App) MinifyJSON(input string, trimWhitespace bool, keepOrder bool) JSON
 		return JSONResponse{Success: false, Error: err.Error()}
 	}
 
-	return JSONResponse{Success: true, Data: buf.String()}
+	return JSONResponse{Success: true, Data: withTrailingNewline(buf.String(), opts.TrailingNewline), Repaired: repaired, Warning: warning}
+}
+
+// RepairJSONSeq repairs every record of an RS (0x1E) separated JSON text sequence.
+// With asArray the records are returned as a formatted JSON array, otherwise they are
+// re-emitted as json-seq with each compact record prefixed by RS and followed by a newline.
+func (a *App) RepairJSONSeq(input string, asArray bool) JSONResponse {
+	records, err := JSONRepairSequence(input, RepairOptions{})
+	if err != nil {
+		return JSONResponse{Success: false, Error: "无法解析 JSON: " + err.Error()}
+	}
+	if len(records) == 0 {
+		return JSONResponse{Success: false, Error: "输入内容为空"}
+	}
+
+	if asArray {
+		return indentedResponse("[" + strings.Join(records, ",") + "]")
+	}
+
+	var sb strings.Builder
+	for _, record := range records {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(record)); err != nil {
+			return JSONResponse{Success: false, Error: "修复后的 JSON 仍然无效"}
+		}
+		sb.WriteRune(codeRecordSeparator)
+		sb.Write(buf.Bytes())
+		sb.WriteString("\n")
+	}
+	return JSONResponse{Success: true, Data: sb.String()}
+}
+
+// RepairMarkdownBlocks repairs JSON embedded in a markdown document with prose around its
+// code fences. By default the first ```json (or bare ```) block is returned formatted; with
+// all set, every such block is repaired and returned as a JSON array.
+func (a *App) RepairMarkdownBlocks(input string, all bool) JSONResponse {
+	blocks, err := JSONRepairFencedBlocks(input, RepairOptions{})
+	if err != nil {
+		return JSONResponse{Success: false, Error: "无法解析 JSON: " + err.Error()}
+	}
+	if !all {
+		resp := a.ProcessJSON(blocks[0], "4", false, true)
+		resp.Repaired = resp.Repaired || blocks[0] != input
+		return resp
+	}
+
+	originals := extractFencedBlocks(input)
+	if len(originals) == 0 {
+		originals = []string{input}
+	}
+	changed := len(blocks) > 1
+	for i, block := range blocks {
+		if !gjson.Valid(block) {
+			return JSONResponse{Success: false, Error: "修复后的 JSON 仍然无效"}
+		}
+		changed = changed || block != originals[i]
+	}
+	resp := indentedResponse("[" + strings.Join(blocks, ",") + "]")
+	resp.Repaired = resp.Success && changed
+	return resp
+}
+
+// RepairStrict repairs input like ProcessJSON, but only succeeds when the result is also accepted
+// by encoding/json's strict decoder. gjson.Valid alone is more permissive than the standard
+// library in some edge cases, so input that passes it is still repaired when the decoder rejects it.
+func (a *App) RepairStrict(input string) JSONResponse {
+	if strings.TrimSpace(input) == "" {
+		return JSONResponse{Success: false, Error: "输入内容为空"}
+	}
+
+	candidate := input
+	repaired := false
+	if strictDecode(input) != nil {
+		repairedText, err := JSONRepair(input, false)
+		if err != nil {
+			return JSONResponse{Success: false, Error: "无法解析 JSON: " + err.Error()}
+		}
+		candidate = repairedText
+		repaired = true
+	}
+
+	resp := a.ProcessJSON(candidate, "4", false, true)
+	if !resp.Success {
+		return resp
+	}
+	if err := strictDecode(resp.Data); err != nil {
+		return JSONResponse{Success: false, Error: "修复结果未通过标准库严格校验: " + err.Error()}
+	}
+	resp.Repaired = resp.Repaired || repaired
+	return resp
+}
+
+// strictDecode parses text with encoding/json using UseNumber and rejects trailing content.
+// Invalid UTF-8 is rejected too, since the decoder would silently replace it with U+FFFD.
+func strictDecode(text string) error {
+	if !utf8.ValidString(text) {
+		return errors.New("包含无效的 UTF-8 字节")
+	}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("JSON 之后存在多余内容")
+	}
+	return nil
+}
+
+// ConvertOptions holds the settings of a conversion, so callers only set the fields they need.
+// Converters ignore the fields that do not apply to them.
+type ConvertOptions struct {
+	TrimWhitespace bool `json:"trimWhitespace"`
+	KeepOrder      bool `json:"keepOrder"`
+	// NullPolicy sets how the YAML/TOML converters handle null values, which TOML cannot
+	// represent at all: "" keeps them where the format allows it, "skip" omits null fields and
+	// array elements, "empty" substitutes an empty string and "error" rejects the input.
+	NullPolicy string `json:"nullPolicy,omitempty"`
+	// TOMLDatetimes makes the TOML converter write strings holding an RFC 3339 timestamp as
+	// bare TOML datetimes instead of quoted strings
+	TOMLDatetimes bool `json:"tomlDatetimes,omitempty"`
+	// PreciseFields are key patterns (globs such as "price" or "*_amount", case-insensitive)
+	// whose numeric values must never go through float64. The converters treat them as strings.
+	PreciseFields []string `json:"preciseFields,omitempty"`
+	// GoPointers makes the Go generator use pointer types for fields that are null in, or
+	// missing from, some samples, so an absent value can be told apart from the zero value
+	GoPointers bool `json:"goPointers,omitempty"`
+	// GoOmitEmpty adds ",omitempty" to the json tag of those nullable Go fields
+	GoOmitEmpty bool `json:"goOmitEmpty,omitempty"`
+	// PathComments makes the code generators document each field with its source JSON path
+	PathComments bool `json:"pathComments,omitempty"`
+	// JavaBuilder makes the Java generator emit immutable classes with final fields, a private
+	// constructor and a static Builder instead of mutable getters/setters
+	JavaBuilder bool `json:"javaBuilder,omitempty"`
+	// CSharpNullable makes the C# generator emit #nullable enable and mark fields that are null
+	// in, or missing from, some samples with ?
+	CSharpNullable bool `json:"csharpNullable,omitempty"`
+	// GoTagTemplate is the struct tag of every generated Go field, e.g.
+	// `json:"{key},omitempty" bson:"{key}"`. {key} is replaced with the JSON key; empty means
+	// the default json tag.
+	GoTagTemplate string `json:"goTagTemplate,omitempty"`
+	// JavaAnnotationTemplate is written above every generated Java field, e.g.
+	// @JsonProperty("{key}"). {key} is replaced with the JSON key; empty writes no annotation.
+	JavaAnnotationTemplate string `json:"javaAnnotationTemplate,omitempty"`
+	// CSharpAttributeTemplate is written above every generated C# property, e.g.
+	// [JsonPropertyName("{key}")]. {key} is replaced with the JSON key; empty writes no attribute.
+	CSharpAttributeTemplate string `json:"csharpAttributeTemplate,omitempty"`
+	// CombinedOutput makes the code generators emit a single ready-to-compile file: imports once
+	// at the top and everything wrapped in CodeNamespace
+	CombinedOutput bool `json:"combinedOutput,omitempty"`
+	// CodeNamespace is the package (Java, Go) or namespace (C#, TypeScript) of combined output
+	CodeNamespace string `json:"codeNamespace,omitempty"`
 }
 
 // ConvertToYAML converts JSON to YAML
 func (a *App) ConvertToYAML(input string, trimWhitespace bool, keepOrder bool) JSONResponse {
+	return a.ConvertToYAMLOpts(input, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToYAMLOpts is ConvertToYAML with its settings in a ConvertOptions
+func (a *App) ConvertToYAMLOpts(input string, opts ConvertOptions) JSONResponse {
+	if opts.KeepOrder {
+		return a.convertToOrderedYAML(input, &opts)
+	}
+
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, false)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
+	obj, err = applyNullPolicy(obj, opts.NullPolicy, "$")
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
 	yamlData, err := yaml.Marshal(obj)
 	if err != nil {
 		return JSONResponse{Success: false, Error: err.Error()}
@@ -396,17 +868,273 @@ func (a *App) ConvertToYAML(input string, trimWhitespace bool, keepOrder bool) J
 	return JSONResponse{Success: true, Data: string(yamlData)}
 }
 
+// convertToOrderedYAML converts JSON to YAML through a yaml.Node tree built in document order,
+// so keys keep their original order instead of being sorted by yaml.Marshal
+func (a *App) convertToOrderedYAML(input string, opts *ConvertOptions) JSONResponse {
+	resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, true)
+	if !resp.Success {
+		return resp
+	}
+	if err := checkNullPolicy(opts.NullPolicy); err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	node, err := a.yamlNode(gjson.Parse(resp.Data), "$", false, opts)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	yamlData, err := yaml.Marshal(node)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	return JSONResponse{Success: true, Data: string(yamlData)}
+}
+
+// yamlNode builds the YAML node for res, applying the null policy and keeping the numbers of
+// precise fields as strings like the unordered conversion does
+func (a *App) yamlNode(res gjson.Result, path string, precise bool, opts *ConvertOptions) (*yaml.Node, error) {
+	switch {
+	case res.IsObject():
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		var err error
+		res.ForEach(func(key, value gjson.Result) bool {
+			if value.Type == gjson.Null && opts.NullPolicy == "skip" {
+				return true
+			}
+			var child *yaml.Node
+			child, err = a.yamlNode(value, childJSONPath(path, key.String()), precise || isPreciseField(key.String(), opts.PreciseFields), opts)
+			if err != nil {
+				return false
+			}
+			node.Content = append(node.Content, yamlStringNode(key.String()), child)
+			return true
+		})
+		return node, err
+	case res.IsArray():
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		var err error
+		idx := 0
+		res.ForEach(func(_, value gjson.Result) bool {
+			elemPath := path + "[" + strconv.Itoa(idx) + "]"
+			idx++
+			if value.Type == gjson.Null && opts.NullPolicy == "skip" {
+				return true
+			}
+			var child *yaml.Node
+			child, err = a.yamlNode(value, elemPath, precise, opts)
+			if err != nil {
+				return false
+			}
+			node.Content = append(node.Content, child)
+			return true
+		})
+		return node, err
+	case res.Type == gjson.String:
+		return yamlStringNode(res.String()), nil
+	case res.Type == gjson.Number:
+		if precise {
+			return yamlStringNode(res.Raw), nil
+		}
+		// Keep the source token instead of the float64 rendering (1000000 rather than 1e+06)
+		tag := "!!int"
+		if strings.ContainsAny(res.Raw, ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: res.Raw}, nil
+	case res.Type == gjson.True || res.Type == gjson.False:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: res.Raw}, nil
+	default:
+		converted, err := applyNullPolicy(nil, opts.NullPolicy, path)
+		if err != nil {
+			return nil, err
+		}
+		if converted != nil {
+			return yamlStringNode(""), nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// yamlStringNode returns a string scalar quoted the same way yaml.Marshal quotes strings,
+// e.g. "y", "true" and "1.5" are quoted so they do not read back as other types
+func yamlStringNode(s string) *yaml.Node {
+	node := &yaml.Node{}
+	node.Encode(s)
+	return node
+}
+
+// applyNullPolicy returns obj with null values handled according to policy. path is the
+// JSONPath of obj, used in the error message of the "error" policy.
+func applyNullPolicy(obj interface{}, policy string, path string) (interface{}, error) {
+	if err := checkNullPolicy(policy); err != nil {
+		return nil, err
+	}
+	if policy == "" {
+		return obj, nil
+	}
+
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		// Keys are visited in sorted order, the order they are exported in, so the "error"
+		// policy always reports the first offending null of the output
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make(map[string]interface{}, len(v))
+		for _, key := range keys {
+			value := v[key]
+			if value == nil && policy == "skip" {
+				continue
+			}
+			converted, err := applyNullPolicy(value, policy, childJSONPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = converted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for idx, value := range v {
+			if value == nil && policy == "skip" {
+				continue
+			}
+			converted, err := applyNullPolicy(value, policy, path+"["+strconv.Itoa(idx)+"]")
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, converted)
+		}
+		return result, nil
+	case nil:
+		if policy == "error" {
+			return nil, errors.New("无法导出 null 值: " + path)
+		}
+		// Only reached for "empty" (and a null document under "skip")
+		return "", nil
+	}
+	return obj, nil
+}
+
+// checkNullPolicy rejects unknown null policies
+func checkNullPolicy(policy string) error {
+	switch policy {
+	case "", "skip", "empty", "error":
+		return nil
+	}
+	return errors.New("不支持的 null 处理策略: " + policy)
+}
+
+// pathComment returns an indented line comment holding path using the language's comment marker,
+// or an empty string when path comments are disabled
+func (opts *ConvertOptions) pathComment(marker string, path string) string {
+	if opts == nil || !opts.PathComments {
+		return ""
+	}
+	return "    " + marker + " " + path + "\n"
+}
+
+// codeNamespace returns the package or namespace of combined output without surrounding spaces
+func (opts *ConvertOptions) codeNamespace() string {
+	return strings.TrimSpace(opts.CodeNamespace)
+}
+
+// childJSONPath appends key to a JSONPath, using bracket notation for keys that are not identifiers
+func childJSONPath(path string, key string) string {
+	for i, r := range key {
+		if !(r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			quoted, _ := json.Marshal(key)
+			return path + "[" + string(quoted) + "]"
+		}
+	}
+	if key == "" {
+		return path + `[""]`
+	}
+	return path + "." + key
+}
+
+// orderedDefinitions returns the generated definitions with root first, followed by the
+// definitions it depends on breadth first, in the order their names appear in the dependent
+// definition. Fields are generated in sorted key order, so the output does not depend on map
+// iteration order. Definitions that nothing refers to come last, sorted by name.
+func orderedDefinitions(root string, definitions map[string]string) []string {
+	ordered := make([]string, 0, len(definitions))
+	visited := make(map[string]bool, len(definitions))
+	queue := []string{root}
+	visited[root] = true
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		definition, ok := definitions[name]
+		if !ok {
+			continue
+		}
+		ordered = append(ordered, definition)
+		words := strings.FieldsFunc(definition, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		})
+		for _, word := range words {
+			if _, ok := definitions[word]; ok && !visited[word] {
+				visited[word] = true
+				queue = append(queue, word)
+			}
+		}
+	}
+
+	var rest []string
+	for name := range definitions {
+		if !visited[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		ordered = append(ordered, definitions[name])
+	}
+	return ordered
+}
+
+// sortedKeys returns the keys of obj in sorted order, so generated fields do not depend on map
+// iteration order
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// indentLines prefixes every non-empty line of s with four spaces
+func indentLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "    " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ConvertToJavaClass converts JSON to Java class
 func (a *App) ConvertToJavaClass(input string, trimWhitespace bool, keepOrder bool, className string) JSONResponse {
+	return a.ConvertToJavaClassOpts(input, className, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToJavaClassOpts is ConvertToJavaClass with its settings in a ConvertOptions
+func (a *App) ConvertToJavaClassOpts(input string, className string, opts ConvertOptions) JSONResponse {
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
@@ -414,27 +1142,46 @@ func (a *App) ConvertToJavaClass(input string, trimWhitespace bool, keepOrder bo
 		className = "RootClass"
 	}
 
-	javaCode := a.generateJavaClass(className, obj)
+	javaCode := a.generateJavaClass(className, obj, &opts)
 	return JSONResponse{Success: true, Data: javaCode}
 }
 
 // generateJavaClass generates Java class code from interface{}
-func (a *App) generateJavaClass(className string, obj interface{}) string {
+func (a *App) generateJavaClass(className string, obj interface{}, opts *ConvertOptions) string {
 	var builder strings.Builder
 	classes := make(map[string]string)
 
-	a.collectJavaClasses(className, obj, classes)
+	a.collectJavaClasses(className, obj, "$", classes, opts)
+
+	if !opts.CombinedOutput {
+		builder.WriteString("import java.util.*;\n\n")
+		for _, classDef := range orderedDefinitions(className, classes) {
+			builder.WriteString(classDef)
+			builder.WriteString("\n")
+		}
+		return builder.String()
+	}
 
-	for _, classDef := range classes {
+	if namespace := opts.codeNamespace(); namespace != "" {
+		builder.WriteString("package " + namespace + ";\n\n")
+	}
+	builder.WriteString("import java.util.*;\n")
+	for i, classDef := range orderedDefinitions(className, classes) {
+		// A Java file holds a single public class
+		if i > 0 {
+			classDef = strings.TrimPrefix(classDef, "public ")
+		}
+		builder.WriteString("\n")
 		builder.WriteString(classDef)
 		builder.WriteString("\n")
 	}
-
 	return builder.String()
 }
 
-// collectJavaClasses recursively collects all Java class definitions
-func (a *App) collectJavaClasses(className string, obj interface{}, classes map[string]string) {
+// collectJavaClasses recursively collects all Java class definitions. With opts.JavaBuilder,
+// classes get final fields, a private constructor and a static Builder class. Fields are
+// sorted by key so the output does not depend on map iteration order.
+func (a *App) collectJavaClasses(className string, obj interface{}, path string, classes map[string]string, opts *ConvertOptions) {
 	if _, exists := classes[className]; exists {
 		return
 	}
@@ -442,38 +1189,57 @@ func (a *App) collectJavaClasses(className string, obj interface{}, classes map[
 	switch v := obj.(type) {
 	case map[string]interface{}:
 		var builder strings.Builder
-		builder.WriteString("import java.util.*;\n\n")
-		builder.WriteString("public class ")
+		if opts.JavaBuilder {
+			builder.WriteString("public final class ")
+		} else {
+			builder.WriteString("public class ")
+		}
 		builder.WriteString(className)
 		builder.WriteString(" {\n")
 
-		for key, value := range v {
-			fieldName := toCamelCase(key)
+		keys := sortedKeys(v)
+		for _, key := range keys {
+			value := v[key]
+			fieldName := javaFieldName(key)
 			javaType := a.getJavaType(value, className, fieldName)
-			builder.WriteString("    private ")
+			builder.WriteString(opts.pathComment("//", childJSONPath(path, key)))
+			if annotation := applyKeyTemplate(opts.JavaAnnotationTemplate, "", key); annotation != "" {
+				builder.WriteString("    " + annotation + "\n")
+			}
+			if opts.JavaBuilder {
+				builder.WriteString("    private final ")
+			} else {
+				builder.WriteString("    private ")
+			}
 			builder.WriteString(javaType)
 			builder.WriteString(" ")
 			builder.WriteString(fieldName)
 			builder.WriteString(";\n")
 
 			if nestedMap, ok := value.(map[string]interface{}); ok {
-				nestedClassName := strings.ToUpper(fieldName[:1]) + fieldName[1:]
-				a.collectJavaClasses(nestedClassName, nestedMap, classes)
+				nestedClassName := upperFirst(fieldName)
+				a.collectJavaClasses(nestedClassName, nestedMap, childJSONPath(path, key), classes, opts)
 			} else if nestedArray, ok := value.([]interface{}); ok && len(nestedArray) > 0 {
-				if nestedMap, ok := nestedArray[0].(map[string]interface{}); ok {
-					nestedClassName := strings.ToUpper(fieldName[:1]) + fieldName[1:]
-					a.collectJavaClasses(nestedClassName, nestedMap, classes)
+				if nestedMap, ok := mergeSamples(nestedArray).(map[string]interface{}); ok {
+					nestedClassName := upperFirst(fieldName)
+					a.collectJavaClasses(nestedClassName, nestedMap, childJSONPath(path, key)+"[*]", classes, opts)
 				}
 			}
 		}
 
 		builder.WriteString("\n")
-		for key := range v {
-			fieldName := toCamelCase(key)
-			capitalized := strings.ToUpper(fieldName[:1]) + fieldName[1:]
+		if opts.JavaBuilder {
+			a.writeJavaBuilder(&builder, className, keys, v)
+			builder.WriteString("}")
+			classes[className] = builder.String()
+			return
+		}
+		for _, key := range keys {
+			fieldName := javaFieldName(key)
+			capitalized := upperFirst(fieldName)
 
 			builder.WriteString("    public ")
-			builder.WriteString(capitalized)
+			builder.WriteString(a.getJavaType(v[key], className, fieldName))
 			builder.WriteString(" get")
 			builder.WriteString(capitalized)
 			builder.WriteString("() {\n")
@@ -502,11 +1268,82 @@ func (a *App) collectJavaClasses(className string, obj interface{}, classes map[
 
 	case []interface{}:
 		if len(v) > 0 {
-			a.collectJavaClasses(className, v[0], classes)
+			a.collectJavaClasses(className, mergeSamples(v), path+"[*]", classes, opts)
 		}
 	}
 }
 
+// writeJavaBuilder writes the private constructor, getters and static Builder class of an
+// immutable Java class, with the fields of obj in the order of keys
+func (a *App) writeJavaBuilder(builder *strings.Builder, className string, keys []string, obj map[string]interface{}) {
+	builder.WriteString("    private ")
+	builder.WriteString(className)
+	builder.WriteString("(Builder builder) {\n")
+	for _, key := range keys {
+		fieldName := javaFieldName(key)
+		builder.WriteString("        this.")
+		builder.WriteString(fieldName)
+		builder.WriteString(" = builder.")
+		builder.WriteString(fieldName)
+		builder.WriteString(";\n")
+	}
+	builder.WriteString("    }\n\n")
+
+	for _, key := range keys {
+		fieldName := javaFieldName(key)
+		capitalized := upperFirst(fieldName)
+		builder.WriteString("    public ")
+		builder.WriteString(a.getJavaType(obj[key], className, fieldName))
+		builder.WriteString(" get")
+		builder.WriteString(capitalized)
+		builder.WriteString("() {\n")
+		builder.WriteString("        return this.")
+		builder.WriteString(fieldName)
+		builder.WriteString(";\n")
+		builder.WriteString("    }\n\n")
+	}
+
+	builder.WriteString("    public static Builder builder() {\n")
+	builder.WriteString("        return new Builder();\n")
+	builder.WriteString("    }\n\n")
+
+	builder.WriteString("    public static final class Builder {\n")
+	for _, key := range keys {
+		fieldName := javaFieldName(key)
+		builder.WriteString("        private ")
+		builder.WriteString(a.getJavaType(obj[key], className, fieldName))
+		builder.WriteString(" ")
+		builder.WriteString(fieldName)
+		builder.WriteString(";\n")
+	}
+	builder.WriteString("\n        private Builder() {\n        }\n\n")
+	for _, key := range keys {
+		fieldName := javaFieldName(key)
+		builder.WriteString("        public Builder ")
+		builder.WriteString(fieldName)
+		builder.WriteString("(")
+		builder.WriteString(a.getJavaType(obj[key], className, fieldName))
+		builder.WriteString(" ")
+		builder.WriteString(fieldName)
+		builder.WriteString(") {\n")
+		builder.WriteString("            this.")
+		builder.WriteString(fieldName)
+		builder.WriteString(" = ")
+		builder.WriteString(fieldName)
+		builder.WriteString(";\n")
+		builder.WriteString("            return this;\n")
+		builder.WriteString("        }\n\n")
+	}
+	builder.WriteString("        public ")
+	builder.WriteString(className)
+	builder.WriteString(" build() {\n")
+	builder.WriteString("            return new ")
+	builder.WriteString(className)
+	builder.WriteString("(this);\n")
+	builder.WriteString("        }\n")
+	builder.WriteString("    }\n")
+}
+
 // getJavaType returns Java type for a value
 func (a *App) getJavaType(value interface{}, className string, fieldName string) string {
 	switch v := value.(type) {
@@ -520,10 +1357,10 @@ func (a *App) getJavaType(value interface{}, className string, fieldName string)
 	case string:
 		return "String"
 	case map[string]interface{}:
-		return strings.ToUpper(fieldName[:1]) + fieldName[1:]
+		return upperFirst(fieldName)
 	case []interface{}:
 		if len(v) > 0 {
-			elemType := a.getJavaType(v[0], className, fieldName)
+			elemType := a.getJavaType(mergeSamples(v), className, fieldName)
 			return "List<" + elemType + ">"
 		}
 		return "List<Object>"
@@ -536,15 +1373,20 @@ func (a *App) getJavaType(value interface{}, className string, fieldName string)
 
 // ConvertToGoStruct converts JSON to Go struct
 func (a *App) ConvertToGoStruct(input string, trimWhitespace bool, keepOrder bool, structName string) JSONResponse {
+	return a.ConvertToGoStructOpts(input, structName, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToGoStructOpts is ConvertToGoStruct with its settings in a ConvertOptions
+func (a *App) ConvertToGoStructOpts(input string, structName string, opts ConvertOptions) JSONResponse {
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
@@ -552,18 +1394,32 @@ func (a *App) ConvertToGoStruct(input string, trimWhitespace bool, keepOrder boo
 		structName = "RootStruct"
 	}
 
-	goCode := a.generateGoStruct(structName, obj)
+	goCode := a.generateGoStruct(structName, obj, &opts)
 	return JSONResponse{Success: true, Data: goCode}
 }
 
 // generateGoStruct generates Go struct code from interface{}
-func (a *App) generateGoStruct(structName string, obj interface{}) string {
+func (a *App) generateGoStruct(structName string, obj interface{}, opts *ConvertOptions) string {
 	var builder strings.Builder
 	structs := make(map[string]string)
 
-	a.collectGoStructs(structName, obj, structs)
+	a.collectGoStructs(structName, obj, nil, "$", structs, opts)
 
-	for _, structDef := range structs {
+	if !opts.CombinedOutput {
+		for _, structDef := range orderedDefinitions(structName, structs) {
+			builder.WriteString(structDef)
+			builder.WriteString("\n")
+		}
+		return builder.String()
+	}
+
+	packageName := opts.codeNamespace()
+	if packageName == "" {
+		packageName = "main"
+	}
+	builder.WriteString("package " + packageName + "\n")
+	for _, structDef := range orderedDefinitions(structName, structs) {
+		builder.WriteString("\n")
 		builder.WriteString(structDef)
 		builder.WriteString("\n")
 	}
@@ -571,8 +1427,50 @@ func (a *App) generateGoStruct(structName string, obj interface{}) string {
 	return builder.String()
 }
 
+// defaultGoTagTemplate is the struct tag emitted for every Go field when no template is set
+const defaultGoTagTemplate = `json:"{key}"`
+
+// applyKeyTemplate substitutes key for every {key} placeholder in template, falling back to
+// defaultTemplate when template is empty
+func applyKeyTemplate(template string, defaultTemplate string, key string) string {
+	if strings.TrimSpace(template) == "" {
+		template = defaultTemplate
+	}
+	return strings.ReplaceAll(template, "{key}", key)
+}
+
+// addTagOption appends option to the value of the name entry of a Go struct tag, e.g. turns
+// json:"id" bson:"id" into json:"id,omitempty" bson:"id". The tag is split into its key:"value"
+// entries like reflect.StructTag does. Tags without a name entry, tags that already have the
+// option and tags that do not follow the convention are returned unchanged.
+func addTagOption(tag string, name string, option string) string {
+	var entries []string
+	changed := false
+	for rest := strings.TrimSpace(tag); rest != ""; rest = strings.TrimSpace(rest) {
+		colon := strings.Index(rest, ":")
+		if colon <= 0 {
+			return tag
+		}
+		quoted, err := strconv.QuotedPrefix(rest[colon+1:])
+		if err != nil {
+			return tag
+		}
+		key := rest[:colon]
+		rest = rest[colon+1+len(quoted):]
+		if value, _ := strconv.Unquote(quoted); key == name && !slices.Contains(strings.Split(value, ",")[1:], option) {
+			quoted = strconv.Quote(value + "," + option)
+			changed = true
+		}
+		entries = append(entries, key+":"+quoted)
+	}
+	if !changed {
+		return tag
+	}
+	return strings.Join(entries, " ")
+}
+
 // collectGoStructs recursively collects all Go struct definitions
-func (a *App) collectGoStructs(structName string, obj interface{}, structs map[string]string) {
+func (a *App) collectGoStructs(structName string, obj interface{}, optional map[string]bool, path string, structs map[string]string, opts *ConvertOptions) {
 	if _, exists := structs[structName]; exists {
 		return
 	}
@@ -584,24 +1482,34 @@ func (a *App) collectGoStructs(structName string, obj interface{}, structs map[s
 		builder.WriteString(structName)
 		builder.WriteString(" struct {\n")
 
-		for key, value := range v {
+		for _, key := range sortedKeys(v) {
+			value := v[key]
 			fieldName := toPascalCase(key)
+			nullable := value == nil || optional[key]
 			goType := a.getGoType(value, structName, fieldName)
+			if _, isSlice := value.([]interface{}); opts.GoPointers && nullable && value != nil && !isSlice {
+				goType = "*" + goType
+			}
+			tag := applyKeyTemplate(opts.GoTagTemplate, defaultGoTagTemplate, key)
+			if opts.GoOmitEmpty && nullable {
+				tag = addTagOption(tag, "json", "omitempty")
+			}
+			builder.WriteString(opts.pathComment("//", childJSONPath(path, key)))
 			builder.WriteString("    ")
 			builder.WriteString(fieldName)
 			builder.WriteString(" ")
 			builder.WriteString(goType)
-			builder.WriteString(" `json:\"")
-			builder.WriteString(key)
-			builder.WriteString("\"`\n")
+			builder.WriteString(" `")
+			builder.WriteString(tag)
+			builder.WriteString("`\n")
 
 			if nestedMap, ok := value.(map[string]interface{}); ok {
 				nestedStructName := fieldName
-				a.collectGoStructs(nestedStructName, nestedMap, structs)
+				a.collectGoStructs(nestedStructName, nestedMap, nil, childJSONPath(path, key), structs, opts)
 			} else if nestedArray, ok := value.([]interface{}); ok && len(nestedArray) > 0 {
-				if nestedMap, ok := nestedArray[0].(map[string]interface{}); ok {
+				if _, ok := mergeSamples(nestedArray).(map[string]interface{}); ok {
 					nestedStructName := fieldName
-					a.collectGoStructs(nestedStructName, nestedMap, structs)
+					a.collectGoStructs(nestedStructName, nestedArray, nil, childJSONPath(path, key), structs, opts)
 				}
 			}
 		}
@@ -611,7 +1519,12 @@ func (a *App) collectGoStructs(structName string, obj interface{}, structs map[s
 
 	case []interface{}:
 		if len(v) > 0 {
-			a.collectGoStructs(structName, v[0], structs)
+			// A field is nullable when it is null in or missing from any element
+			if merged, optional, ok := mergeObjectSamples(v); ok {
+				a.collectGoStructs(structName, merged, optional, path+"[*]", structs, opts)
+				return
+			}
+			a.collectGoStructs(structName, mergeSamples(v), nil, path+"[*]", structs, opts)
 		}
 	}
 }
@@ -632,7 +1545,7 @@ func (a *App) getGoType(value interface{}, structName string, fieldName string)
 		return fieldName
 	case []interface{}:
 		if len(v) > 0 {
-			elemType := a.getGoType(v[0], structName, fieldName)
+			elemType := a.getGoType(mergeSamples(v), structName, fieldName)
 			return "[]" + elemType
 		}
 		return "[]interface{}"
@@ -645,15 +1558,20 @@ func (a *App) getGoType(value interface{}, structName string, fieldName string)
 
 // ConvertToPythonClass converts JSON to Python class
 func (a *App) ConvertToPythonClass(input string, trimWhitespace bool, keepOrder bool, className string) JSONResponse {
+	return a.ConvertToPythonClassOpts(input, className, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToPythonClassOpts is ConvertToPythonClass with its settings in a ConvertOptions
+func (a *App) ConvertToPythonClassOpts(input string, className string, opts ConvertOptions) JSONResponse {
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
@@ -661,27 +1579,39 @@ func (a *App) ConvertToPythonClass(input string, trimWhitespace bool, keepOrder
 		className = "RootClass"
 	}
 
-	pythonCode := a.generatePythonClass(className, obj)
+	pythonCode := a.generatePythonClass(className, obj, &opts)
 	return JSONResponse{Success: true, Data: pythonCode}
 }
 
 // generatePythonClass generates Python class code from interface{}
-func (a *App) generatePythonClass(className string, obj interface{}) string {
+func (a *App) generatePythonClass(className string, obj interface{}, opts *ConvertOptions) string {
 	var builder strings.Builder
 	classes := make(map[string]string)
 
-	a.collectPythonClasses(className, obj, classes)
+	a.collectPythonClasses(className, obj, "$", classes, opts)
+
+	if !opts.CombinedOutput {
+		for _, classDef := range orderedDefinitions(className, classes) {
+			builder.WriteString(classDef)
+			builder.WriteString("\n")
+		}
+		return builder.String()
+	}
 
-	for _, classDef := range classes {
+	// Postponed annotations let the root class refer to classes defined after it
+	builder.WriteString("from __future__ import annotations\n\n")
+	builder.WriteString("from typing import Any, Optional\n")
+	for _, classDef := range orderedDefinitions(className, classes) {
+		builder.WriteString("\n\n")
 		builder.WriteString(classDef)
-		builder.WriteString("\n")
 	}
+	builder.WriteString("\n")
 
 	return builder.String()
 }
 
 // collectPythonClasses recursively collects all Python class definitions
-func (a *App) collectPythonClasses(className string, obj interface{}, classes map[string]string) {
+func (a *App) collectPythonClasses(className string, obj interface{}, path string, classes map[string]string, opts *ConvertOptions) {
 	if _, exists := classes[className]; exists {
 		return
 	}
@@ -693,9 +1623,11 @@ func (a *App) collectPythonClasses(className string, obj interface{}, classes ma
 		builder.WriteString(className)
 		builder.WriteString(":\n")
 
-		for key, value := range v {
+		for _, key := range sortedKeys(v) {
+			value := v[key]
 			fieldName := toSnakeCase(key)
 			pythonType := a.getPythonType(value, className, fieldName)
+			builder.WriteString(opts.pathComment("#", childJSONPath(path, key)))
 			builder.WriteString("    ")
 			builder.WriteString(fieldName)
 			builder.WriteString(": ")
@@ -704,11 +1636,11 @@ func (a *App) collectPythonClasses(className string, obj interface{}, classes ma
 
 			if nestedMap, ok := value.(map[string]interface{}); ok {
 				nestedClassName := toPascalCase(key)
-				a.collectPythonClasses(nestedClassName, nestedMap, classes)
+				a.collectPythonClasses(nestedClassName, nestedMap, childJSONPath(path, key), classes, opts)
 			} else if nestedArray, ok := value.([]interface{}); ok && len(nestedArray) > 0 {
-				if nestedMap, ok := nestedArray[0].(map[string]interface{}); ok {
+				if nestedMap, ok := mergeSamples(nestedArray).(map[string]interface{}); ok {
 					nestedClassName := toPascalCase(key)
-					a.collectPythonClasses(nestedClassName, nestedMap, classes)
+					a.collectPythonClasses(nestedClassName, nestedMap, childJSONPath(path, key)+"[*]", classes, opts)
 				}
 			}
 		}
@@ -717,7 +1649,7 @@ func (a *App) collectPythonClasses(className string, obj interface{}, classes ma
 
 	case []interface{}:
 		if len(v) > 0 {
-			a.collectPythonClasses(className, v[0], classes)
+			a.collectPythonClasses(className, mergeSamples(v), path+"[*]", classes, opts)
 		}
 	}
 }
@@ -738,7 +1670,7 @@ func (a *App) getPythonType(value interface{}, className string, fieldName strin
 		return toPascalCase(fieldName)
 	case []interface{}:
 		if len(v) > 0 {
-			elemType := a.getPythonType(v[0], className, fieldName)
+			elemType := a.getPythonType(mergeSamples(v), className, fieldName)
 			return "list[" + elemType + "]"
 		}
 		return "list"
@@ -763,15 +1695,20 @@ func toSnakeCase(s string) string {
 
 // ConvertToTypeScriptInterface converts JSON to TypeScript interface
 func (a *App) ConvertToTypeScriptInterface(input string, trimWhitespace bool, keepOrder bool, interfaceName string) JSONResponse {
+	return a.ConvertToTypeScriptInterfaceOpts(input, interfaceName, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToTypeScriptInterfaceOpts is ConvertToTypeScriptInterface with its settings in a ConvertOptions
+func (a *App) ConvertToTypeScriptInterfaceOpts(input string, interfaceName string, opts ConvertOptions) JSONResponse {
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
@@ -779,27 +1716,43 @@ func (a *App) ConvertToTypeScriptInterface(input string, trimWhitespace bool, ke
 		interfaceName = "RootInterface"
 	}
 
-	tsCode := a.generateTypeScriptInterface(interfaceName, obj)
+	tsCode := a.generateTypeScriptInterface(interfaceName, obj, &opts)
 	return JSONResponse{Success: true, Data: tsCode}
 }
 
 // generateTypeScriptInterface generates TypeScript interface code from interface{}
-func (a *App) generateTypeScriptInterface(interfaceName string, obj interface{}) string {
+func (a *App) generateTypeScriptInterface(interfaceName string, obj interface{}, opts *ConvertOptions) string {
 	var builder strings.Builder
 	interfaces := make(map[string]string)
 
-	a.collectTypeScriptInterfaces(interfaceName, obj, interfaces)
+	a.collectTypeScriptInterfaces(interfaceName, obj, nil, "$", interfaces, opts)
+
+	if !opts.CombinedOutput {
+		for _, interfaceDef := range orderedDefinitions(interfaceName, interfaces) {
+			builder.WriteString(interfaceDef)
+			builder.WriteString("\n")
+		}
+		return builder.String()
+	}
 
-	for _, interfaceDef := range interfaces {
-		builder.WriteString(interfaceDef)
+	body := strings.Join(orderedDefinitions(interfaceName, interfaces), "\n\n")
+	namespace := opts.codeNamespace()
+	if namespace == "" {
+		builder.WriteString(body)
 		builder.WriteString("\n")
+		return builder.String()
 	}
+	builder.WriteString("export namespace " + namespace + " {\n")
+	builder.WriteString(indentLines(body))
+	builder.WriteString("\n}\n")
 
 	return builder.String()
 }
 
-// collectTypeScriptInterfaces recursively collects all TypeScript interface definitions
-func (a *App) collectTypeScriptInterfaces(interfaceName string, obj interface{}, interfaces map[string]string) {
+// collectTypeScriptInterfaces recursively collects all TypeScript interface definitions.
+// samples holds the array elements obj was merged from, if any: fields missing from some of
+// them become optional and fields that are null in some of them T | null.
+func (a *App) collectTypeScriptInterfaces(interfaceName string, obj interface{}, samples []interface{}, path string, interfaces map[string]string, opts *ConvertOptions) {
 	if _, exists := interfaces[interfaceName]; exists {
 		return
 	}
@@ -811,22 +1764,35 @@ func (a *App) collectTypeScriptInterfaces(interfaceName string, obj interface{},
 		builder.WriteString(interfaceName)
 		builder.WriteString(" {\n")
 
-		for key, value := range v {
+		for _, key := range sortedKeys(v) {
+			value := v[key]
 			fieldName := toCamelCase(key)
 			tsType := a.getTypeScriptType(value, interfaceName, fieldName)
+			optional := false
+			if samples != nil {
+				var nullable bool
+				nullable, optional = fieldPresence(samples, key)
+				if nullable && value != nil {
+					tsType += " | null"
+				}
+			}
+			builder.WriteString(opts.pathComment("//", childJSONPath(path, key)))
 			builder.WriteString("    ")
 			builder.WriteString(fieldName)
+			if optional {
+				builder.WriteString("?")
+			}
 			builder.WriteString(": ")
 			builder.WriteString(tsType)
 			builder.WriteString(";\n")
 
 			if nestedMap, ok := value.(map[string]interface{}); ok {
-				nestedInterfaceName := strings.ToUpper(fieldName[:1]) + fieldName[1:]
-				a.collectTypeScriptInterfaces(nestedInterfaceName, nestedMap, interfaces)
+				nestedInterfaceName := upperFirst(fieldName)
+				a.collectTypeScriptInterfaces(nestedInterfaceName, nestedMap, nil, childJSONPath(path, key), interfaces, opts)
 			} else if nestedArray, ok := value.([]interface{}); ok && len(nestedArray) > 0 {
-				if nestedMap, ok := nestedArray[0].(map[string]interface{}); ok {
-					nestedInterfaceName := strings.ToUpper(fieldName[:1]) + fieldName[1:]
-					a.collectTypeScriptInterfaces(nestedInterfaceName, nestedMap, interfaces)
+				if _, ok := mergeSamples(nestedArray).(map[string]interface{}); ok {
+					nestedInterfaceName := upperFirst(fieldName)
+					a.collectTypeScriptInterfaces(nestedInterfaceName, nestedArray, nil, childJSONPath(path, key), interfaces, opts)
 				}
 			}
 		}
@@ -836,7 +1802,11 @@ func (a *App) collectTypeScriptInterfaces(interfaceName string, obj interface{},
 
 	case []interface{}:
 		if len(v) > 0 {
-			a.collectTypeScriptInterfaces(interfaceName, v[0], interfaces)
+			if merged, _, ok := mergeObjectSamples(v); ok {
+				a.collectTypeScriptInterfaces(interfaceName, merged, v, path+"[*]", interfaces, opts)
+				return
+			}
+			a.collectTypeScriptInterfaces(interfaceName, mergeSamples(v), nil, path+"[*]", interfaces, opts)
 		}
 	}
 }
@@ -854,10 +1824,10 @@ func (a *App) getTypeScriptType(value interface{}, interfaceName string, fieldNa
 	case string:
 		return "string"
 	case map[string]interface{}:
-		return strings.ToUpper(fieldName[:1]) + fieldName[1:]
+		return upperFirst(fieldName)
 	case []interface{}:
 		if len(v) > 0 {
-			elemType := a.getTypeScriptType(v[0], interfaceName, fieldName)
+			elemType := a.getTypeScriptType(mergeSamples(v), interfaceName, fieldName)
 			return elemType + "[]"
 		}
 		return "any[]"
@@ -870,15 +1840,20 @@ func (a *App) getTypeScriptType(value interface{}, interfaceName string, fieldNa
 
 // ConvertToCSharpClass converts JSON to C# class
 func (a *App) ConvertToCSharpClass(input string, trimWhitespace bool, keepOrder bool, className string) JSONResponse {
+	return a.ConvertToCSharpClassOpts(input, className, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToCSharpClassOpts is ConvertToCSharpClass with its settings in a ConvertOptions
+func (a *App) ConvertToCSharpClassOpts(input string, className string, opts ConvertOptions) JSONResponse {
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
@@ -886,27 +1861,46 @@ func (a *App) ConvertToCSharpClass(input string, trimWhitespace bool, keepOrder
 		className = "RootClass"
 	}
 
-	csharpCode := a.generateCSharpClass(className, obj)
+	csharpCode := a.generateCSharpClass(className, obj, &opts)
 	return JSONResponse{Success: true, Data: csharpCode}
 }
 
 // generateCSharpClass generates C# class code from interface{}
-func (a *App) generateCSharpClass(className string, obj interface{}) string {
+func (a *App) generateCSharpClass(className string, obj interface{}, opts *ConvertOptions) string {
 	var builder strings.Builder
 	classes := make(map[string]string)
 
-	a.collectCSharpClasses(className, obj, classes)
+	a.collectCSharpClasses(className, obj, nil, "$", classes, opts)
 
-	for _, classDef := range classes {
-		builder.WriteString(classDef)
+	if opts.CSharpNullable {
+		builder.WriteString("#nullable enable\n\n")
+	}
+	if !opts.CombinedOutput {
+		for _, classDef := range orderedDefinitions(className, classes) {
+			builder.WriteString(classDef)
+			builder.WriteString("\n")
+		}
+		return builder.String()
+	}
+
+	builder.WriteString("using System.Collections.Generic;\n\n")
+	body := strings.Join(orderedDefinitions(className, classes), "\n\n")
+	namespace := opts.codeNamespace()
+	if namespace == "" {
+		builder.WriteString(body)
 		builder.WriteString("\n")
+		return builder.String()
 	}
+	builder.WriteString("namespace " + namespace + "\n{\n")
+	builder.WriteString(indentLines(body))
+	builder.WriteString("\n}\n")
 
 	return builder.String()
 }
 
-// collectCSharpClasses recursively collects all C# class definitions
-func (a *App) collectCSharpClasses(className string, obj interface{}, classes map[string]string) {
+// collectCSharpClasses recursively collects all C# class definitions. Keys in optional
+// were null or absent in some sample and become nullable when the option is enabled.
+func (a *App) collectCSharpClasses(className string, obj interface{}, optional map[string]bool, path string, classes map[string]string, opts *ConvertOptions) {
 	if _, exists := classes[className]; exists {
 		return
 	}
@@ -918,9 +1912,14 @@ func (a *App) collectCSharpClasses(className string, obj interface{}, classes ma
 		builder.WriteString(className)
 		builder.WriteString("\n{\n")
 
-		for key, value := range v {
+		for _, key := range sortedKeys(v) {
+			value := v[key]
 			fieldName := toPascalCase(key)
-			csharpType := a.getCSharpType(value, className, fieldName)
+			csharpType := a.getCSharpType(value, className, fieldName, opts.CSharpNullable && (value == nil || optional[key]))
+			builder.WriteString(opts.pathComment("//", childJSONPath(path, key)))
+			if attribute := applyKeyTemplate(opts.CSharpAttributeTemplate, "", key); attribute != "" {
+				builder.WriteString("    " + attribute + "\n")
+			}
 			builder.WriteString("    public ")
 			builder.WriteString(csharpType)
 			builder.WriteString(" ")
@@ -929,11 +1928,11 @@ func (a *App) collectCSharpClasses(className string, obj interface{}, classes ma
 
 			if nestedMap, ok := value.(map[string]interface{}); ok {
 				nestedClassName := fieldName
-				a.collectCSharpClasses(nestedClassName, nestedMap, classes)
+				a.collectCSharpClasses(nestedClassName, nestedMap, nil, childJSONPath(path, key), classes, opts)
 			} else if nestedArray, ok := value.([]interface{}); ok && len(nestedArray) > 0 {
-				if nestedMap, ok := nestedArray[0].(map[string]interface{}); ok {
+				if _, ok := mergeSamples(nestedArray).(map[string]interface{}); ok {
 					nestedClassName := fieldName
-					a.collectCSharpClasses(nestedClassName, nestedMap, classes)
+					a.collectCSharpClasses(nestedClassName, nestedArray, nil, childJSONPath(path, key), classes, opts)
 				}
 			}
 		}
@@ -943,13 +1942,23 @@ func (a *App) collectCSharpClasses(className string, obj interface{}, classes ma
 
 	case []interface{}:
 		if len(v) > 0 {
-			a.collectCSharpClasses(className, v[0], classes)
+			// Nullability needs every element: a field is optional when any element lacks it
+			if opts.CSharpNullable {
+				if merged, optional, ok := mergeObjectSamples(v); ok {
+					a.collectCSharpClasses(className, merged, optional, path+"[*]", classes, opts)
+					return
+				}
+			}
+			a.collectCSharpClasses(className, mergeSamples(v), nil, path+"[*]", classes, opts)
 		}
 	}
 }
 
-// getCSharpType returns C# type for a value
-func (a *App) getCSharpType(value interface{}, className string, fieldName string) string {
+// getCSharpType returns C# type for a value, with a ? suffix when nullable
+func (a *App) getCSharpType(value interface{}, className string, fieldName string, nullable bool) string {
+	if nullable {
+		return a.getCSharpType(value, className, fieldName, false) + "?"
+	}
 	switch v := value.(type) {
 	case float64:
 		if v == float64(int64(v)) {
@@ -964,7 +1973,7 @@ func (a *App) getCSharpType(value interface{}, className string, fieldName strin
 		return fieldName
 	case []interface{}:
 		if len(v) > 0 {
-			elemType := a.getCSharpType(v[0], className, fieldName)
+			elemType := a.getCSharpType(mergeSamples(v), className, fieldName, false)
 			return "List<" + elemType + ">"
 		}
 		return "List<object>"
@@ -975,6 +1984,95 @@ func (a *App) getCSharpType(value interface{}, className string, fieldName strin
 	}
 }
 
+// mergeObjectSamples merges array elements that are all objects into one representative object
+// whose fields are the union of the keys of every element, each merged with mergeSamples. The
+// returned set marks keys that are null or missing in at least one element. ok is false if any
+// element is not an object.
+func mergeObjectSamples(elements []interface{}) (merged map[string]interface{}, optional map[string]bool, ok bool) {
+	values := make(map[string][]interface{})
+	optional = make(map[string]bool)
+	for _, elem := range elements {
+		obj, isMap := elem.(map[string]interface{})
+		if !isMap {
+			return nil, nil, false
+		}
+		for key, value := range obj {
+			values[key] = append(values[key], value)
+			if value == nil {
+				optional[key] = true
+			}
+		}
+	}
+	merged = make(map[string]interface{}, len(values))
+	for key, samples := range values {
+		merged[key] = mergeSamples(samples)
+		if len(samples) < len(elements) {
+			optional[key] = true
+		}
+	}
+	return merged, optional, true
+}
+
+// fieldPresence reports whether key is null in any of the object samples and whether it is
+// missing from any
+func fieldPresence(samples []interface{}, key string) (nullable bool, optional bool) {
+	for _, sample := range samples {
+		value, present := sample.(map[string]interface{})[key]
+		if !present {
+			optional = true
+		} else if value == nil {
+			nullable = true
+		}
+	}
+	return nullable, optional
+}
+
+// mergeSamples merges the values found at the same place in several array elements into one
+// representative value, so code generators see every field instead of only those of the first
+// element. Objects are merged key by key, arrays are concatenated so their elements can in turn
+// be merged (keeping which fields are missing from some of them), integers widen to a fraction
+// when any sample has one, and null is only kept when no sample has a value. With mixed types
+// the first non-null value wins.
+func mergeSamples(values []interface{}) interface{} {
+	var first interface{}
+	for _, value := range values {
+		if value != nil {
+			first = value
+			break
+		}
+	}
+
+	switch v := first.(type) {
+	case map[string]interface{}:
+		var objects []interface{}
+		for _, value := range values {
+			if obj, ok := value.(map[string]interface{}); ok {
+				objects = append(objects, obj)
+			}
+		}
+		merged, _, _ := mergeObjectSamples(objects)
+		return merged
+	case []interface{}:
+		var elements []interface{}
+		for _, value := range values {
+			if arr, ok := value.([]interface{}); ok {
+				elements = append(elements, arr...)
+			}
+		}
+		if len(elements) == 0 {
+			return v
+		}
+		return elements
+	case float64:
+		for _, value := range values {
+			if n, ok := value.(float64); ok && n != float64(int64(n)) {
+				return n
+			}
+		}
+	}
+	return first
+}
+
 // toCamelCase converts snake_case or kebab-case to camelCase
 func toCamelCase(s string) string {
 	s = strings.ReplaceAll(s, "_", " ")
@@ -992,6 +2090,24 @@ func toCamelCase(s string) string {
 	return result
 }
 
+// javaFieldName converts key to a camelCase Java field name. Keys without any letters or
+// digits, such as "" or "_", become "field" instead of an empty name.
+func javaFieldName(key string) string {
+	if name := toCamelCase(key); name != "" {
+		return name
+	}
+	return "field"
+}
+
+// upperFirst upper-cases the first rune of s
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
 // toPascalCase converts snake_case or kebab-case to PascalCase
 func toPascalCase(s string) string {
 	s = strings.ReplaceAll(s, "_", " ")
@@ -1009,17 +2125,25 @@ func toPascalCase(s string) string {
 	return result
 }
 
-// ConvertToSQL converts JSON to SQL CREATE TABLE statement
-func (a *App) ConvertToSQL(input string, trimWhitespace bool, keepOrder bool, databaseType string, tableName string) JSONResponse {
+// ConvertToSQL converts JSON to SQL CREATE TABLE statement. With includeInserts it also emits
+// an INSERT statement for the root object, or for each object element of a root array.
+func (a *App) ConvertToSQL(input string, trimWhitespace bool, keepOrder bool, databaseType string, tableName string, includeInserts bool) JSONResponse {
+	return a.ConvertToSQLOpts(input, databaseType, tableName, includeInserts, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToSQLOpts is ConvertToSQL with its settings in a ConvertOptions
+func (a *App) ConvertToSQLOpts(input string, databaseType string, tableName string, includeInserts bool, opts ConvertOptions) JSONResponse {
 	var obj interface{}
-	err := json.Unmarshal([]byte(input), &obj)
+	data := []byte(input)
+	err := unmarshalPrecise(data, &obj, true, opts.PreciseFields)
 	if err != nil {
-		resp := a.ProcessJSON(input, "4", trimWhitespace, keepOrder)
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
 		if !resp.Success {
 			return resp
 		}
-		json.Unmarshal([]byte(resp.Data), &obj)
-	} else if trimWhitespace {
+		data = []byte(resp.Data)
+		unmarshalPrecise(data, &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
 		obj = a.trimStrings(obj)
 	}
 
@@ -1027,18 +2151,27 @@ func (a *App) ConvertToSQL(input string, trimWhitespace bool, keepOrder bool, da
 		tableName = "table1"
 	}
 
-	sqlCode := a.generateSQL(obj, databaseType, tableName)
+	sqlCode := a.generateSQL(obj, databaseType, tableName, "$", &opts)
+	if includeInserts {
+		// Decode again keeping exact numbers, the values matter here, not just their types
+		var rows interface{}
+		unmarshalPrecise(data, &rows, false, opts.PreciseFields)
+		if opts.TrimWhitespace {
+			rows = a.trimStrings(rows)
+		}
+		sqlCode += a.generateSQLInserts(rows, databaseType, tableName)
+	}
 	return JSONResponse{Success: true, Data: sqlCode}
 }
 
 // generateSQL generates SQL CREATE TABLE statement from interface{}
-func (a *App) generateSQL(obj interface{}, databaseType string, tableName string) string {
+func (a *App) generateSQL(obj interface{}, databaseType string, tableName string, path string, opts *ConvertOptions) string {
 	switch v := obj.(type) {
 	case map[string]interface{}:
-		return a.generateSQLFromMap(v, databaseType, tableName, make(map[string]bool))
+		return a.generateSQLFromMap(v, databaseType, tableName, path, make(map[string]bool), opts)
 	case []interface{}:
 		if len(v) > 0 {
-			return a.generateSQL(v[0], databaseType, tableName)
+			return a.generateSQL(mergeSQLRows(v), databaseType, tableName, path+"[*]", opts)
 		}
 		return "-- No data to convert"
 	default:
@@ -1047,7 +2180,7 @@ func (a *App) generateSQL(obj interface{}, databaseType string, tableName string
 }
 
 // generateSQLFromMap generates SQL from map[string]interface{}
-func (a *App) generateSQLFromMap(data map[string]interface{}, databaseType string, tableName string, generatedTables map[string]bool) string {
+func (a *App) generateSQLFromMap(data map[string]interface{}, databaseType string, tableName string, path string, generatedTables map[string]bool, opts *ConvertOptions) string {
 	var builder strings.Builder
 
 	builder.WriteString("-- ")
@@ -1058,10 +2191,12 @@ func (a *App) generateSQLFromMap(data map[string]interface{}, databaseType strin
 	builder.WriteString(" (\n")
 
 	var columns []string
-	for key, value := range data {
+	keys := sortedKeys(data)
+	for _, key := range keys {
+		value := data[key]
 		columnName := toSnakeCase(key)
 		columnType := a.getSQLType(value, databaseType)
-		columns = append(columns, "    "+columnName+" "+columnType)
+		columns = append(columns, opts.pathComment("--", childJSONPath(path, key))+"    "+columnName+" "+columnType)
 	}
 
 	builder.WriteString(strings.Join(columns, ",\n"))
@@ -1086,17 +2221,18 @@ func (a *App) generateSQLFromMap(data map[string]interface{}, databaseType strin
 
 	generatedTables[tableName] = true
 
-	for key, value := range data {
+	for _, key := range keys {
+		value := data[key]
 		if nestedMap, ok := value.(map[string]interface{}); ok {
 			nestedTableName := toSnakeCase(key)
 			if !generatedTables[nestedTableName] {
-				builder.WriteString(a.generateSQLFromMap(nestedMap, databaseType, nestedTableName, generatedTables))
+				builder.WriteString(a.generateSQLFromMap(nestedMap, databaseType, nestedTableName, childJSONPath(path, key), generatedTables, opts))
 			}
 		} else if nestedArray, ok := value.([]interface{}); ok && len(nestedArray) > 0 {
-			if nestedMap, ok := nestedArray[0].(map[string]interface{}); ok {
+			if nestedMap, ok := mergeSQLRows(nestedArray).(map[string]interface{}); ok {
 				nestedTableName := toSnakeCase(key)
 				if !generatedTables[nestedTableName] {
-					builder.WriteString(a.generateSQLFromMap(nestedMap, databaseType, nestedTableName, generatedTables))
+					builder.WriteString(a.generateSQLFromMap(nestedMap, databaseType, nestedTableName, childJSONPath(path, key)+"[*]", generatedTables, opts))
 				}
 			}
 		}
@@ -1105,6 +2241,126 @@ func (a *App) generateSQLFromMap(data map[string]interface{}, databaseType strin
 	return builder.String()
 }
 
+// mergeSQLRows merges the rows of an array into one sample row for column type inference, so
+// every row contributes its columns and the widest number type. Unlike mergeSamples, which keeps
+// the first type it sees, a column holding different kinds of values becomes text.
+func mergeSQLRows(rows []interface{}) interface{} {
+	merged := mergeSamples(rows)
+	if obj, ok := merged.(map[string]interface{}); ok {
+		widenMixedColumns(obj, rows)
+	}
+	return merged
+}
+
+// widenMixedColumns replaces the sample of every column of merged whose values differ in kind
+// across rows with a string, descending into nested objects
+func widenMixedColumns(merged map[string]interface{}, rows []interface{}) {
+	for key, sample := range merged {
+		kinds := make(map[string]bool)
+		var values []interface{}
+		for _, row := range rows {
+			obj, ok := row.(map[string]interface{})
+			if !ok || obj[key] == nil {
+				continue
+			}
+			values = append(values, obj[key])
+			switch obj[key].(type) {
+			case float64, json.Number:
+				kinds["number"] = true
+			case string:
+				kinds["string"] = true
+			case bool:
+				kinds["boolean"] = true
+			case map[string]interface{}:
+				kinds["object"] = true
+			case []interface{}:
+				kinds["array"] = true
+			}
+		}
+		if len(kinds) > 1 {
+			merged[key] = ""
+		} else if nested, ok := sample.(map[string]interface{}); ok {
+			widenMixedColumns(nested, values)
+		}
+	}
+}
+
+// generateSQLInserts generates INSERT statements for the root object or the object elements
+// of a root array. Columns are sorted by key.
+func (a *App) generateSQLInserts(obj interface{}, databaseType string, tableName string) string {
+	var rows []map[string]interface{}
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		rows = append(rows, v)
+	case []interface{}:
+		for _, item := range v {
+			if row, ok := item.(map[string]interface{}); ok {
+				rows = append(rows, row)
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("-- ")
+	builder.WriteString(databaseType)
+	builder.WriteString(" INSERT statements\n")
+	for _, row := range rows {
+		keys := sortedKeys(row)
+		columns := make([]string, len(keys))
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			columns[i] = toSnakeCase(key)
+			values[i] = sqlLiteral(row[key], databaseType)
+		}
+		builder.WriteString("INSERT INTO " + tableName + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(values, ", ") + ");\n")
+	}
+	return builder.String()
+}
+
+// sqlLiteral returns value as a SQL literal. Booleans follow the column types of getSQLType,
+// objects and arrays are stored as JSON text.
+func sqlLiteral(value interface{}, databaseType string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if databaseType == "postgresql" {
+			if v {
+				return "TRUE"
+			}
+			return "FALSE"
+		}
+		if v {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return string(v)
+	case string:
+		return sqlString(v, databaseType)
+	default:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(v)
+		return sqlString(strings.TrimSuffix(buf.String(), "\n"), databaseType)
+	}
+}
+
+// sqlString quotes s as a SQL string literal, doubling single quotes
+func sqlString(s string, databaseType string) string {
+	quoted := "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	if databaseType == "sqlserver" {
+		return "N" + quoted
+	}
+	return quoted
+}
+
 // getSQLType returns SQL type for a value based on database type
 func (a *App) getSQLType(value interface{}, databaseType string) string {
 	switch v := value.(type) {
@@ -1243,6 +2499,9 @@ func (a *App) GetPathByOffset(input string, offset int) string {
 	return findPathRecursive(res, byteOffset, "$")
 }
 
+// findPathRecursive returns the path of the deepest value of res containing byteOffset. When
+// the offset falls on structural whitespace or punctuation between the children of a container
+// (or inside an empty one), the container's own path is returned.
 func findPathRecursive(res gjson.Result, byteOffset int, currentPath string) string {
 	if !res.IsObject() && !res.IsArray() {
 		return currentPath
@@ -1298,23 +2557,12 @@ func findPathRecursive(res gjson.Result, byteOffset int, currentPath string) str
 		return nextPath
 	}
 
-	// 如果没有找到匹配的子节点，且不是根节点，说明点击了空白处
-	// 为了避免误定位到父节点，返回空字符串
-	return ""
-}
-
-type PathInfo struct {
-	Offset int `json:"offset"`
-	Length int `json:"length"`
+	// 没有匹配的子节点，说明点击了容器内的空白处，返回所在容器的路径
+	return currentPath
 }
 
-// GetPathOffset returns the character offset and length of a JSON path in the input string
-func (a *App) GetPathOffset(input string, path string) PathInfo {
-	if path == "" || path == "$" {
-		return PathInfo{Offset: 0, Length: 1}
-	}
-
-	// Normalize path for gjson: $.store.book[0] -> store.book.0
+// toGJSONPath normalizes a JSONPath-like path for gjson: $.store.book[0] -> store.book.0
+func toGJSONPath(path string) string {
 	searchPath := path
 	if strings.HasPrefix(searchPath, "$.") {
 		searchPath = searchPath[2:]
@@ -1328,6 +2576,208 @@ func (a *App) GetPathOffset(input string, path string) PathInfo {
 	if strings.HasPrefix(searchPath, ".") {
 		searchPath = searchPath[1:]
 	}
+	return searchPath
+}
+
+// resolvePath returns the value at a JSONPath-like path, or the whole document for "" and "$"
+func resolvePath(input string, path string) gjson.Result {
+	searchPath := toGJSONPath(path)
+	if searchPath == "" {
+		return gjson.Parse(input)
+	}
+	return gjson.Get(input, searchPath)
+}
+
+// QueryJSON evaluates a gjson path such as store.book.#(price<10).title or friends.#.name and
+// returns the result as formatted JSON. Queries matching several values (#(...)# and #.field)
+// return them as an array. Invalid input is repaired first; a malformed query and a query
+// without a match are reported as errors so the UI can tell them apart from an empty result.
+func (a *App) QueryJSON(input string, query string) JSONResponse {
+	if err := checkQuerySyntax(query); err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	result := gjson.Get(validInput, strings.TrimSpace(query))
+	if !result.Exists() {
+		return JSONResponse{Success: false, Error: "查询没有匹配的结果: " + query}
+	}
+	resp := indentedResponse(compactRaw(result))
+	resp.Repaired = validInput != input
+	return resp
+}
+
+// checkQuerySyntax rejects gjson paths that gjson would silently treat as not found: empty
+// paths, empty path segments and unbalanced brackets in queries such as #(age>21)
+func checkQuerySyntax(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return errors.New("查询路径为空")
+	}
+
+	var stack []rune
+	closing := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var quote rune
+	prev := '.'
+	runes := []rune(query)
+	for idx := 0; idx < len(runes); idx++ {
+		char := runes[idx]
+		switch {
+		case char == '\\':
+			idx++
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' && len(stack) > 0:
+			quote = char
+		case char == '(' || char == '[' || char == '{':
+			stack = append(stack, char)
+		case closing[char] != 0:
+			if len(stack) == 0 || stack[len(stack)-1] != closing[char] {
+				return fmt.Errorf("查询路径语法错误: 第 %d 个字符 %q 没有匹配的左括号", idx+1, char)
+			}
+			stack = stack[:len(stack)-1]
+		case (char == '.' || char == '|') && len(stack) == 0:
+			if prev == '.' || prev == '|' {
+				return fmt.Errorf("查询路径语法错误: 第 %d 个字符处存在空的路径段", idx+1)
+			}
+		}
+		prev = char
+	}
+	if quote != 0 {
+		return errors.New("查询路径语法错误: 字符串缺少结束引号")
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("查询路径语法错误: 括号 %q 没有闭合", stack[len(stack)-1])
+	}
+	if prev == '.' || prev == '|' {
+		return errors.New("查询路径语法错误: 路径不能以 . 或 | 结尾")
+	}
+	return nil
+}
+
+// isPreciseField reports whether key matches one of the precise field patterns, see
+// ConvertOptions.PreciseFields
+func isPreciseField(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if ok, _ := path.Match(pattern, key); ok && pattern != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalPrecise decodes data like json.Unmarshal, except that numbers under precise fields
+// keep their source token: as json.Number, which marshals back unchanged, or as a plain string
+// when numbersAsStrings is set. Without numbersAsStrings, i.e. when the result is re-encoded,
+// integers too large for float64 (snowflake IDs and the like) keep their token as well.
+func unmarshalPrecise(data []byte, obj *interface{}, numbersAsStrings bool, preciseFields []string) error {
+	if len(preciseFields) == 0 && (numbersAsStrings || !hasLongDigitRun(data)) {
+		return json.Unmarshal(data, obj)
+	}
+	if err := unmarshalKeepNumbers(data, obj); err != nil {
+		return err
+	}
+	*obj = resolveNumbers(*obj, false, numbersAsStrings, preciseFields)
+	return nil
+}
+
+// unmarshalKeepNumbers decodes data like json.Unmarshal, but keeps every number as the
+// json.Number of its source token, which marshals back unchanged: 1.0, 1e3 and 0.10 are not
+// turned into 1, 1000 and 0.1
+func unmarshalKeepNumbers(data []byte, obj *interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// resolveNumbers converts the json.Number values produced by UseNumber back to float64,
+// except below precise fields
+func resolveNumbers(value interface{}, precise bool, numbersAsStrings bool, preciseFields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = resolveNumbers(item, precise || isPreciseField(key, preciseFields), numbersAsStrings, preciseFields)
+		}
+	case []interface{}:
+		for idx, item := range v {
+			v[idx] = resolveNumbers(item, precise, numbersAsStrings, preciseFields)
+		}
+	case json.Number:
+		if !precise {
+			f, _ := v.Float64()
+			if !numbersAsStrings && !strings.ContainsAny(string(v), ".eE") && strconv.FormatFloat(f, 'f', -1, 64) != string(v) {
+				// An integer float64 cannot hold exactly
+				return v
+			}
+			return f
+		}
+		if numbersAsStrings {
+			return string(v)
+		}
+	}
+	return value
+}
+
+// hasLongDigitRun reports whether data contains 16 or more consecutive digits, the shortest
+// integers that may not survive a round trip through float64
+func hasLongDigitRun(data []byte) bool {
+	run := 0
+	for _, b := range data {
+		if b >= '0' && b <= '9' {
+			run++
+			if run >= 16 {
+				return true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return false
+}
+
+// validJSON returns input as valid JSON, repairing it via ProcessJSON when needed
+func (a *App) validJSON(input string) (string, error) {
+	if strings.TrimSpace(input) == "" {
+		return "", errors.New("输入内容为空")
+	}
+	if gjson.Valid(input) {
+		return input, nil
+	}
+	resp := a.ProcessJSON(input, "4", false, true)
+	if !resp.Success {
+		return "", errors.New(resp.Error)
+	}
+	if resp.Data == "" {
+		return "", errors.New("输入内容为空")
+	}
+	return resp.Data, nil
+}
+
+type PathInfo struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+// GetPathOffset returns the character offset and length of a JSON path in the input string
+func (a *App) GetPathOffset(input string, path string) PathInfo {
+	if path == "" || path == "$" {
+		return PathInfo{Offset: 0, Length: 1}
+	}
+
+	searchPath := toGJSONPath(path)
 
 	res := gjson.Get(input, searchPath)
 	if res.Exists() {
@@ -1373,11 +2823,22 @@ func (a *App) GetPathOffset(input string, path string) PathInfo {
 	return PathInfo{Offset: -1, Length: 0}
 }
 
-// SaveFile saves content to a file, opening a dialog if filename is empty
-func (a *App) SaveFile(content string, defaultFilename string) JSONResponse {
+// SaveFile saves content to a file, opening a dialog if filename is empty.
+// The content is written in the given encoding (see ReadFile); an empty value means UTF-8.
+// With trailingNewline the file ends with a single newline.
+func (a *App) SaveFile(content string, defaultFilename string, encoding string, trailingNewline bool) JSONResponse {
 	var targetPath string
 	var err error
 
+	encName, enc, err := lookupEncoding(encoding)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	data, err := encodeWithEncoding(withTrailingNewline(content, trailingNewline), enc, encName)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
 	// Determine default directory: use last save path if available, otherwise use desktop
 	defaultDir := a.lastSavePath
 	if defaultDir == "" {
@@ -1425,7 +2886,7 @@ func (a *App) SaveFile(content string, defaultFilename string) JSONResponse {
 	}
 
 	// Write to file
-	err = os.WriteFile(targetPath, []byte(content), 0644)
+	err = os.WriteFile(targetPath, data, 0644)
 	if err != nil {
 		return JSONResponse{Success: false, Error: "写入文件失败: " + err.Error()}
 	}
@@ -1436,13 +2897,23 @@ func (a *App) SaveFile(content string, defaultFilename string) JSONResponse {
 	return JSONResponse{Success: true, Data: targetPath}
 }
 
-// WriteFileDirect writes content directly to a specified path without opening a dialog
-func (a *App) WriteFileDirect(content string, filePath string) JSONResponse {
+// WriteFileDirect writes content directly to a specified path without opening a dialog, using the given
+// encoding. With trailingNewline the file ends with a single newline.
+func (a *App) WriteFileDirect(content string, filePath string, encoding string, trailingNewline bool) JSONResponse {
 	if filePath == "" {
 		return JSONResponse{Success: false, Error: "文件路径不能为空"}
 	}
 
-	err := os.WriteFile(filePath, []byte(content), 0644)
+	encName, enc, err := lookupEncoding(encoding)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	data, err := encodeWithEncoding(withTrailingNewline(content, trailingNewline), enc, encName)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
 	if err != nil {
 		return JSONResponse{Success: false, Error: "写入文件失败: " + err.Error()}
 	}
@@ -1450,18 +2921,174 @@ func (a *App) WriteFileDirect(content string, filePath string) JSONResponse {
 	return JSONResponse{Success: true, Data: filePath}
 }
 
-// ReadFile reads content from a specified path
-func (a *App) ReadFile(filePath string) JSONResponse {
+// ExportArrayElements writes every element of a top-level array to its own indented .json
+// file in dir, which is created if needed. Files are named after the filenameField value of
+// each element, or its index when the field is empty or missing; names are sanitized and
+// made unique with a _2, _3, ... suffix, also against files already in dir, so existing
+// files are never overwritten. Data holds the written paths as a JSON array.
+func (a *App) ExportArrayElements(input string, dir string, filenameField string) JSONResponse {
+	if dir == "" {
+		return JSONResponse{Success: false, Error: "目录不能为空"}
+	}
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	root := gjson.Parse(validInput)
+	if !root.IsArray() {
+		return JSONResponse{Success: false, Error: "仅支持顶层为数组的 JSON"}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return JSONResponse{Success: false, Error: "创建目录失败: " + err.Error()}
+	}
+
+	// Names are compared case-insensitively, as on Windows and macOS file systems
+	used := make(map[string]bool)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return JSONResponse{Success: false, Error: "读取目录失败: " + err.Error()}
+	}
+	for _, entry := range entries {
+		if name := strings.ToLower(entry.Name()); strings.HasSuffix(name, ".json") {
+			used[strings.TrimSuffix(name, ".json")] = true
+		}
+	}
+
+	paths := []string{}
+	var writeErr string
+	idx := 0
+	root.ForEach(func(_, element gjson.Result) bool {
+		name := ""
+		if filenameField != "" && element.IsObject() {
+			element.ForEach(func(key, value gjson.Result) bool {
+				if key.String() != filenameField {
+					return true
+				}
+				if value.Type == gjson.String || value.Type == gjson.Number {
+					name = sanitizeFilename(value.String())
+				}
+				return false
+			})
+		}
+		if name == "" {
+			name = strconv.Itoa(idx)
+		}
+		idx++
+
+		var buf bytes.Buffer
+		json.Indent(&buf, []byte(element.Raw), "", "    ")
+		// The directory listing can be stale, so a name taken since then moves on to the next
+		// suffix instead of overwriting the file
+		unique := name
+		for n := 2; ; n++ {
+			if !used[strings.ToLower(unique)] {
+				used[strings.ToLower(unique)] = true
+				target := filepath.Join(dir, unique+".json")
+				err := writeNewFile(target, buf.Bytes())
+				if err == nil {
+					paths = append(paths, target)
+					return true
+				}
+				if !os.IsExist(err) {
+					writeErr = "写入文件失败: " + err.Error()
+					return false
+				}
+			}
+			unique = name + "_" + strconv.Itoa(n)
+		}
+	})
+	if writeErr != "" {
+		return JSONResponse{Success: false, Error: fmt.Sprintf("已写入 %d 个文件后失败: %s", len(paths), writeErr)}
+	}
+
+	data, _ := json.Marshal(paths)
+	return JSONResponse{Success: true, Data: string(data), Repaired: validInput != input}
+}
+
+// writeNewFile writes data to a file that must not exist yet. If it does, the error satisfies
+// os.IsExist and the file is left untouched.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// windowsReservedNames are device names that cannot be used as file names on Windows
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// maxFilenameRunes limits file names derived from field values
+const maxFilenameRunes = 100
+
+// sanitizeFilename turns a field value into a file name that is valid on Windows, macOS and
+// Linux: path separators, reserved and control characters become '_', surrounding spaces and
+// dots are removed and reserved device names are prefixed
+func sanitizeFilename(value string) string {
+	var builder strings.Builder
+	count := 0
+	for _, r := range value {
+		if count == maxFilenameRunes {
+			break
+		}
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) {
+			r = '_'
+		}
+		builder.WriteRune(r)
+		count++
+	}
+	name := strings.Trim(builder.String(), " .")
+	if base, _, _ := strings.Cut(name, "."); windowsReservedNames[strings.ToUpper(base)] {
+		name = "_" + name
+	}
+	return name
+}
+
+// ReadFile reads content from a specified path. encoding selects how the file is decoded
+// (utf-8, utf-16le, utf-16be, latin1, gbk); an empty value means UTF-8. A UTF-8 file with
+// invalid bytes is rejected, or with replaceInvalid read with those bytes replaced by U+FFFD.
+func (a *App) ReadFile(filePath string, encoding string, replaceInvalid bool) JSONResponse {
 	if filePath == "" {
 		return JSONResponse{Success: false, Error: "文件路径不能为空"}
 	}
 
+	encName, enc, err := lookupEncoding(encoding)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return JSONResponse{Success: false, Error: "读取文件失败: " + err.Error()}
 	}
 
-	return JSONResponse{Success: true, Data: string(content)}
+	if enc != nil {
+		text, err := decodeWithEncoding(content, enc, encName)
+		if err != nil {
+			return JSONResponse{Success: false, Error: err.Error()}
+		}
+		return JSONResponse{Success: true, Data: text}
+	}
+
+	// UTF-16/UTF-32 files with a BOM are transcoded, everything else must be valid UTF-8
+	text, _ := transcodeBOM(content)
+	if offsets := InvalidUTF8Offsets(text); len(offsets) > 0 {
+		if !replaceInvalid {
+			return JSONResponse{Success: false, Error: "文件包含无效的 UTF-8 字节，字节偏移: " + formatOffsets(offsets, 10)}
+		}
+		replacedText, replaced := ReplaceInvalidUTF8(text)
+		return JSONResponse{Success: true, Data: replacedText, Warning: fmt.Sprintf("已将 %d 处无效的 UTF-8 字节替换为 U+FFFD", replaced)}
+	}
+
+	return JSONResponse{Success: true, Data: text}
 }
 
 // RegisterAsDefaultEditor registers the current executable as the default editor for .json files on Windows
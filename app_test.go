@@ -0,0 +1,1302 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// processCompact runs ProcessJSONOpts and returns its output compacted
+func processCompact(t *testing.T, input string, opts FormatOptions) string {
+	t.Helper()
+	resp := NewApp().ProcessJSONOpts(input, opts)
+	if !resp.Success {
+		t.Fatalf("ProcessJSONOpts(%q) failed: %s", input, resp.Error)
+	}
+	return compactJSON(t, resp.Data)
+}
+
+func TestProcessJSONOptsTruncatedBase64(t *testing.T) {
+	input := `{"data": "SGVsbG8sIFdvcmxkISBIaSEhSGV`
+	if got := processCompact(t, input, FormatOptions{KeepOrder: true, TruncatedBase64: "marker"}); got != `{"data":"<truncated base64>"}` {
+		t.Errorf("marker: got %s", got)
+	}
+	if got := processCompact(t, input, FormatOptions{KeepOrder: true, TruncatedBase64: "pad"}); got != `{"data":"SGVsbG8sIFdvcmxkISBIaSEhSGV="}` {
+		t.Errorf("pad: got %s", got)
+	}
+}
+
+func TestConvertToYAMLKeepOrder(t *testing.T) {
+	input := `{"zeta": 1, "alpha": {"y": [true, null, "s"], "b": 2.50}, "mid": []}`
+	tests := []struct {
+		name      string
+		keepOrder bool
+		want      string
+	}{
+		{"document order", true, "zeta: 1\nalpha:\n    \"y\":\n        - true\n        - null\n        - s\n    b: 2.50\nmid: []\n"},
+		{"sorted", false, "alpha:\n    b: 2.5\n    \"y\":\n        - true\n        - null\n        - s\nmid: []\nzeta: 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToYAML(input, false, tt.keepOrder)
+			if !resp.Success || resp.Data != tt.want {
+				t.Errorf("ConvertToYAML(keepOrder %v) = %+v, want %q", tt.keepOrder, resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertPreciseFields(t *testing.T) {
+	input := `{"price": 12345678901234567.89, "Total_Amount": 0.10, "qty": 1.50}`
+	opts := ConvertOptions{PreciseFields: []string{"price", " *_AMOUNT "}}
+
+	resp := NewApp().ConvertToYAMLOpts(input, opts)
+	want := "Total_Amount: \"0.10\"\nprice: \"12345678901234567.89\"\nqty: 1.5\n"
+	if !resp.Success || resp.Data != want {
+		t.Errorf("ConvertToYAMLOpts = %+v, want %q", resp, want)
+	}
+
+	opts.KeepOrder = true
+	resp = NewApp().ConvertToTOMLOpts(input, opts)
+	want = "price = \"12345678901234567.89\"\nTotal_Amount = \"0.10\"\nqty = 1.50\n"
+	if !resp.Success || resp.Data != want {
+		t.Errorf("ConvertToTOMLOpts = %+v, want %q", resp, want)
+	}
+
+	resp = NewApp().ConvertToTypeScriptInterfaceOpts(input, "Order", opts)
+	if !resp.Success || !strings.Contains(resp.Data, "price: string;") || !strings.Contains(resp.Data, "qty: number;") {
+		t.Errorf("ConvertToTypeScriptInterfaceOpts = %+v", resp)
+	}
+
+	// Without the option every number is converted as usual
+	resp = NewApp().ConvertToYAML(input, false, false)
+	if !resp.Success || strings.Contains(resp.Data, `"`) {
+		t.Errorf("ConvertToYAML = %+v, want no quoted numbers", resp)
+	}
+}
+
+func TestMinifyJSONKeepsNumbers(t *testing.T) {
+	resp := NewApp().MinifyJSON(`{"b": 0.10, "a": 12345678901234567890, "c": 1e3}`, false, false)
+	if want := `{"a":12345678901234567890,"b":0.10,"c":1e3}`; !resp.Success || resp.Data != want {
+		t.Errorf("MinifyJSON = %+v, want %s", resp, want)
+	}
+}
+
+func TestMinifyJSONOptsRepair(t *testing.T) {
+	app := NewApp()
+	if resp := app.MinifyJSONOpts(`{a:1}`, FormatOptions{KeepOrder: true}); !resp.Success || !resp.Repaired || resp.Data != `{"a":1}` {
+		t.Errorf("MinifyJSONOpts = %+v, want a repaired {\"a\":1}", resp)
+	}
+	if resp := app.MinifyJSONOpts(`{"a": 1}`, FormatOptions{KeepOrder: true}); resp.Repaired {
+		t.Errorf("MinifyJSONOpts of valid input = %+v, want it not repaired", resp)
+	}
+
+	input := "{\n  # port of the server\n  \"port\": 8080\n"
+	resp := app.MinifyJSONOpts(input, FormatOptions{KeepOrder: true, HashComments: true})
+	if !resp.Success || resp.Data != `{"port":8080}` {
+		t.Errorf("MinifyJSONOpts with HashComments = %+v", resp)
+	}
+
+	resp = app.MinifyJSONOpts(strings.Repeat("[", 30), FormatOptions{KeepOrder: true, ExpansionWarningRatio: 1.5})
+	if !resp.Success || resp.Warning == "" {
+		t.Errorf("MinifyJSONOpts = %+v, want an expansion warning", resp)
+	}
+}
+
+func TestProcessJSONOptsHashComments(t *testing.T) {
+	input := "{\n  # port of the server\n  \"port\": 8080\n}"
+	if got := processCompact(t, input, FormatOptions{KeepOrder: true, HashComments: true}); got != `{"port":8080}` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestConvertToGoStructNullableFields(t *testing.T) {
+	input := `[{"name": "a", "age": 1, "tags": ["x"]}, {"name": "b", "age": null, "tags": null}, {"name": "c"}]`
+	tests := []struct {
+		name string
+		opts ConvertOptions
+		want []string
+	}{
+		{"default", ConvertOptions{}, []string{"Name string `json:\"name\"`", "Age int `json:\"age\"`", "Tags []string `json:\"tags\"`"}},
+		{"pointers", ConvertOptions{GoPointers: true}, []string{"Name string `json:\"name\"`", "Age *int `json:\"age\"`", "Tags []string `json:\"tags\"`"}},
+		{"omitempty", ConvertOptions{GoOmitEmpty: true}, []string{"Name string `json:\"name\"`", "Age int `json:\"age,omitempty\"`", "Tags []string `json:\"tags,omitempty\"`"}},
+		{"both", ConvertOptions{GoPointers: true, GoOmitEmpty: true}, []string{"Name string `json:\"name\"`", "Age *int `json:\"age,omitempty\"`"}},
+		{"template", ConvertOptions{GoOmitEmpty: true, GoTagTemplate: `json:"{key}" bson:"{key}"`}, []string{"Name string `json:\"name\" bson:\"name\"`", "Age int `json:\"age,omitempty\" bson:\"age\"`"}},
+		{"template with omitempty", ConvertOptions{GoOmitEmpty: true, GoTagTemplate: `json:"{key},omitempty"`}, []string{"Name string `json:\"name,omitempty\"`", "Age int `json:\"age,omitempty\"`"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToGoStructOpts(input, "Person", tt.opts)
+			if !resp.Success {
+				t.Fatalf("ConvertToGoStructOpts failed: %s", resp.Error)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(resp.Data, "    "+line+"\n") {
+					t.Errorf("ConvertToGoStructOpts output lacks %s:\n%s", line, resp.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertKeyTemplates(t *testing.T) {
+	input := `{"user_id": 1}`
+	tests := []struct {
+		name    string
+		convert func(*App, ConvertOptions) JSONResponse
+		opts    ConvertOptions
+		want    string
+	}{
+		{"go multiple tags", func(a *App, o ConvertOptions) JSONResponse { return a.ConvertToGoStructOpts(input, "Row", o) },
+			ConvertOptions{GoTagTemplate: `json:"{key}" db:"{key}" yaml:"{key}"`}, "    UserId int `json:\"user_id\" db:\"user_id\" yaml:\"user_id\"`\n"},
+		{"go default tag", func(a *App, o ConvertOptions) JSONResponse { return a.ConvertToGoStructOpts(input, "Row", o) },
+			ConvertOptions{GoTagTemplate: " "}, "    UserId int `json:\"user_id\"`\n"},
+		{"java annotation", func(a *App, o ConvertOptions) JSONResponse { return a.ConvertToJavaClassOpts(input, "Row", o) },
+			ConvertOptions{JavaAnnotationTemplate: `@JsonProperty("{key}")`}, "    @JsonProperty(\"user_id\")\n    private Integer userId;\n"},
+		{"csharp attribute", func(a *App, o ConvertOptions) JSONResponse { return a.ConvertToCSharpClassOpts(input, "Row", o) },
+			ConvertOptions{CSharpAttributeTemplate: `[JsonPropertyName("{key}")]`}, "    [JsonPropertyName(\"user_id\")]\n    public int UserId"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.convert(NewApp(), tt.opts)
+			if !resp.Success {
+				t.Fatalf("conversion failed: %s", resp.Error)
+			}
+			if !strings.Contains(resp.Data, tt.want) {
+				t.Errorf("output lacks %q:\n%s", tt.want, resp.Data)
+			}
+		})
+	}
+
+	// Without templates Java and C# get no annotations
+	if resp := NewApp().ConvertToJavaClassOpts(input, "Row", ConvertOptions{}); strings.Contains(resp.Data, "@") {
+		t.Errorf("Java output has an annotation without a template:\n%s", resp.Data)
+	}
+	if resp := NewApp().ConvertToCSharpClassOpts(input, "Row", ConvertOptions{}); strings.Contains(resp.Data, "[") {
+		t.Errorf("C# output has an attribute without a template:\n%s", resp.Data)
+	}
+}
+
+func TestAddTagOption(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{`json:"id"`, `json:"id,omitempty"`},
+		{`json:"id" bson:"_id"`, `json:"id,omitempty" bson:"_id"`},
+		{`bson:"_id"  json:"id,string"`, `bson:"_id" json:"id,string,omitempty"`},
+		{`json:"id,omitempty"`, `json:"id,omitempty"`},
+		{`json:"omitempty"`, `json:"omitempty,omitempty"`},
+		{`bson:"_id"`, `bson:"_id"`},
+		{`not a tag`, `not a tag`},
+	}
+	for _, tt := range tests {
+		if got := addTagOption(tt.tag, "json", "omitempty"); got != tt.want {
+			t.Errorf("addTagOption(%s) = %s, want %s", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestFormatJSONWithBraceStyle(t *testing.T) {
+	input := `{"a": {"b": [1, 2]}, "c": [], "d": {}, "e": "x"}`
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{"kr", "{\n  \"a\": {\n    \"b\": [\n      1,\n      2\n    ]\n  },\n  \"c\": [],\n  \"d\": {},\n  \"e\": \"x\"\n}"},
+		{"", "{\n  \"a\": {\n    \"b\": [\n      1,\n      2\n    ]\n  },\n  \"c\": [],\n  \"d\": {},\n  \"e\": \"x\"\n}"},
+		{"allman", "{\n  \"a\":\n  {\n    \"b\":\n    [\n      1,\n      2\n    ]\n  },\n  \"c\": [],\n  \"d\": {},\n  \"e\": \"x\"\n}"},
+	}
+	for _, tt := range tests {
+		resp := NewApp().FormatJSONWithBraceStyle(input, "2", false, true, tt.style)
+		if !resp.Success || resp.Data != tt.want {
+			t.Errorf("FormatJSONWithBraceStyle(%q) = %+v, want %q", tt.style, resp, tt.want)
+		}
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	app := NewApp()
+	opts := FormatOptions{Indent: "2", KeepOrder: true, TrailingNewline: true}
+	for _, input := range []string{`{"a": [1, 2]}`, `{a: [1, 2]`} {
+		first := app.ProcessJSONOpts(input, opts)
+		if !first.Success || !strings.HasSuffix(first.Data, "}\n") {
+			t.Fatalf("ProcessJSONOpts(%s) = %+v, want one trailing newline", input, first)
+		}
+		// Formatting the output again must not add a second newline
+		if again := app.FormatJSONOpts(first.Data, opts); again.Data != first.Data {
+			t.Errorf("FormatJSONOpts(%q) = %q, want it unchanged", first.Data, again.Data)
+		}
+	}
+
+	if resp := app.MinifyJSONOpts(`{"a": 1}`, opts); resp.Data != "{\"a\":1}\n" {
+		t.Errorf("MinifyJSONOpts = %q, want one trailing newline", resp.Data)
+	}
+	if resp := app.MinifyJSONOpts(`{"a": 1}`, FormatOptions{KeepOrder: true}); resp.Data != `{"a":1}` {
+		t.Errorf("MinifyJSONOpts without the option = %q", resp.Data)
+	}
+	if resp := app.ProcessJSON(`{"a": 1}`, "2", false, true); strings.HasSuffix(resp.Data, "\n") {
+		t.Errorf("ProcessJSON = %q, want no trailing newline by default", resp.Data)
+	}
+}
+
+func TestWriteFileDirectTrailingNewline(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		content         string
+		trailingNewline bool
+		want            string
+	}{
+		{`{"a":1}`, true, "{\"a\":1}\n"},
+		{"{\"a\":1}\n", true, "{\"a\":1}\n"},
+		{`{"a":1}`, false, `{"a":1}`},
+	} {
+		path := filepath.Join(dir, "out.json")
+		if resp := NewApp().WriteFileDirect(tt.content, path, "", tt.trailingNewline); !resp.Success {
+			t.Fatalf("WriteFileDirect failed: %s", resp.Error)
+		}
+		if data, _ := os.ReadFile(path); string(data) != tt.want {
+			t.Errorf("WriteFileDirect(%q, %v) wrote %q, want %q", tt.content, tt.trailingNewline, data, tt.want)
+		}
+	}
+}
+
+func TestRepairJSONSeq(t *testing.T) {
+	input := "\x1e{\"a\": 1}\n\x1e{'b': [1, 2,]\n"
+	app := NewApp()
+
+	resp := app.RepairJSONSeq(input, true)
+	if !resp.Success {
+		t.Fatalf("RepairJSONSeq failed: %s", resp.Error)
+	}
+	if got, want := compactJSON(t, resp.Data), `[{"a":1},{"b":[1,2]}]`; got != want {
+		t.Errorf("RepairJSONSeq as array = %s, want %s", got, want)
+	}
+
+	resp = app.RepairJSONSeq(input, false)
+	if want := "\x1e{\"a\":1}\n\x1e{\"b\":[1,2]}\n"; !resp.Success || resp.Data != want {
+		t.Errorf("RepairJSONSeq = %+v, want %q", resp, want)
+	}
+
+	if resp := app.RepairJSONSeq("\x1e \n", false); resp.Success {
+		t.Errorf("RepairJSONSeq on empty records = %+v, want an error", resp)
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"two spaces", "{\n  \"a\": {\n    \"b\": 1\n  }\n}", "2"},
+		{"four spaces", "{\n    \"a\": [\n        1\n    ]\n}", "4"},
+		{"three spaces", "{\n   \"a\": 1,\n   \"b\": 2\n}", "3"},
+		{"tabs", "{\n\t\"a\": {\n\t\t\"b\": 1\n\t}\n}", "tab"},
+		{"minified", `{"a":{"b":[1,2]}}`, "none"},
+		{"empty", "", "none"},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.DetectIndent(tt.input)
+			if !resp.Success || resp.Data != tt.want {
+				t.Errorf("DetectIndent(%q) = %+v, want %s", tt.input, resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathComments(t *testing.T) {
+	input := `{"user": {"address": {"city": "x"}}, "tags": [{"my-key": 1}]}`
+	app := NewApp()
+	opts := ConvertOptions{PathComments: true}
+	tests := []struct {
+		name    string
+		convert func(opts ConvertOptions) JSONResponse
+		want    []string
+	}{
+		{"go", func(opts ConvertOptions) JSONResponse { return app.ConvertToGoStructOpts(input, "Root", opts) }, []string{
+			"    // $.user\n    User User",
+			"    // $.user.address\n    Address Address",
+			"    // $.user.address.city\n    City string",
+			"    // $.tags[*][\"my-key\"]\n    MyKey int",
+		}},
+		{"java", func(opts ConvertOptions) JSONResponse { return app.ConvertToJavaClassOpts(input, "Root", opts) }, []string{
+			"    // $.user.address.city\n    private String city;",
+			"    // $.tags[*][\"my-key\"]\n    private Integer myKey;",
+		}},
+		{"python", func(opts ConvertOptions) JSONResponse { return app.ConvertToPythonClassOpts(input, "Root", opts) }, []string{
+			"    # $.user.address\n    address: Address",
+			"    # $.user.address.city\n    city: str",
+		}},
+		{"typescript", func(opts ConvertOptions) JSONResponse {
+			return app.ConvertToTypeScriptInterfaceOpts(input, "Root", opts)
+		}, []string{
+			"    // $.tags\n    tags: Tags[];",
+			"    // $.user.address.city\n    city: string;",
+		}},
+		{"csharp", func(opts ConvertOptions) JSONResponse { return app.ConvertToCSharpClassOpts(input, "Root", opts) }, []string{
+			"    // $.user.address.city\n    public string City",
+		}},
+		{"sql", func(opts ConvertOptions) JSONResponse { return app.ConvertToSQLOpts(input, "mysql", "t", false, opts) }, []string{
+			"    -- $.user.address.city\n    city VARCHAR(255)",
+		}},
+		{"c", func(opts ConvertOptions) JSONResponse { return app.ConvertToCStructOpts(input, "Root", opts) }, []string{
+			"    // $.user.address.city\n    char *city;",
+			"    // $.tags[*][\"my-key\"]\n    long long my_key;",
+		}},
+		{"zod", func(opts ConvertOptions) JSONResponse { return app.ConvertToZodSchemaOpts(input, "Root", opts) }, []string{
+			"    // $.user.address.city\n    city: z.string(),",
+			"    // $.tags\n    tags: z.array(TagsSchema),",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.convert(opts)
+			if !resp.Success {
+				t.Fatalf("conversion failed: %s", resp.Error)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(resp.Data, want) {
+					t.Errorf("output lacks %q:\n%s", want, resp.Data)
+				}
+			}
+			if resp := tt.convert(ConvertOptions{}); strings.Contains(resp.Data, "$.") {
+				t.Errorf("output without PathComments has path comments:\n%s", resp.Data)
+			}
+		})
+	}
+}
+
+func TestReadFileEncodings(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        []byte
+		replaceInvalid bool
+		want           string
+		wantErr        bool
+	}{
+		{"utf-8", []byte(`{"a": "é"}`), false, `{"a": "é"}`, false},
+		{"invalid utf-8 rejected", []byte("{\"a\": \"x\xffy\"}"), false, "", true},
+		{"invalid utf-8 replaced", []byte("{\"a\": \"x\xffy\"}"), true, "{\"a\": \"x\uFFFDy\"}", false},
+		{"utf-16le bom", []byte{0xff, 0xfe, '[', 0, '1', 0, ']', 0}, false, "[1]", false},
+		{"utf-16be bom", []byte{0xfe, 0xff, 0, '[', 0xd8, 0x3d, 0xde, 0x00, 0, ']'}, false, "[\U0001F600]", false},
+		{"utf-32le bom", []byte{0xff, 0xfe, 0, 0, '1', 0, 0, 0}, false, "1", false},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "in.json")
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			resp := app.ReadFile(path, "", tt.replaceInvalid)
+			if tt.wantErr {
+				if resp.Success || !strings.Contains(resp.Error, "字节偏移: 8") {
+					t.Errorf("ReadFile = %+v, want an error at byte offset 8", resp)
+				}
+				return
+			}
+			if !resp.Success || resp.Data != tt.want {
+				t.Errorf("ReadFile = %+v, want %q", resp, tt.want)
+			}
+			if tt.replaceInvalid && resp.Warning == "" {
+				t.Errorf("ReadFile replaced invalid bytes without a warning")
+			}
+		})
+	}
+}
+
+func TestConvertToCSharpClassNullable(t *testing.T) {
+	input := `[{"name": "a", "age": 1, "nick": null, "address": {"city": "x"}}, {"name": "b", "age": null}]`
+	tests := []struct {
+		name string
+		opts ConvertOptions
+		want []string
+		skip []string
+	}{
+		{"default", ConvertOptions{}, []string{"public string Name {", "public int Age {", "public object Nick {", "public Address Address {"}, []string{"#nullable enable", "?"}},
+		{"nullable", ConvertOptions{CSharpNullable: true}, []string{"#nullable enable\n", "public string Name {", "public int? Age {", "public object? Nick {", "public Address? Address {"}, nil},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.ConvertToCSharpClassOpts(input, "Person", tt.opts)
+			if !resp.Success {
+				t.Fatalf("ConvertToCSharpClassOpts failed: %s", resp.Error)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(resp.Data, want) {
+					t.Errorf("output lacks %q:\n%s", want, resp.Data)
+				}
+			}
+			for _, unwanted := range tt.skip {
+				if strings.Contains(resp.Data, unwanted) {
+					t.Errorf("output contains %q:\n%s", unwanted, resp.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestRepairMarkdownBlocks(t *testing.T) {
+	input := "Intro text.\n```json\n{a: 1}\n```\nMore prose.\n```\n[true,]\n```\nThe end."
+	app := NewApp()
+
+	resp := app.RepairMarkdownBlocks(input, false)
+	if !resp.Success || !resp.Repaired {
+		t.Fatalf("RepairMarkdownBlocks = %+v, want a repaired result", resp)
+	}
+	if got, want := compactJSON(t, resp.Data), `{"a":1}`; got != want {
+		t.Errorf("RepairMarkdownBlocks = %s, want %s", got, want)
+	}
+
+	resp = app.RepairMarkdownBlocks(input, true)
+	if !resp.Success {
+		t.Fatalf("RepairMarkdownBlocks failed: %s", resp.Error)
+	}
+	if got, want := compactJSON(t, resp.Data), `[{"a":1},[true]]`; got != want {
+		t.Errorf("RepairMarkdownBlocks all = %s, want %s", got, want)
+	}
+	if !resp.Repaired {
+		t.Errorf("RepairMarkdownBlocks all of two blocks is not marked repaired")
+	}
+
+	resp = app.RepairMarkdownBlocks("Intro.\n```json\n{\"a\": 1}\n```\nThe end.", true)
+	if !resp.Success || resp.Repaired {
+		t.Errorf("RepairMarkdownBlocks all of one valid block = %+v, want an unrepaired result", resp)
+	}
+}
+
+func TestFileEncodingRoundTrip(t *testing.T) {
+	content := `{"name": "张三", "city": "Zürich"}`
+	tests := []struct {
+		encoding string
+		content  string
+	}{
+		{"utf-8", content},
+		{"utf-16le", content},
+		{"utf-16be", content},
+		{"gbk", content},
+		{"latin1", `{"city": "Zürich", "note": "café"}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.json")
+			if resp := app.WriteFileDirect(tt.content, path, tt.encoding, false); !resp.Success {
+				t.Fatalf("WriteFileDirect failed: %s", resp.Error)
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.encoding != "utf-8" && string(raw) == tt.content {
+				t.Errorf("WriteFileDirect wrote UTF-8 for %s", tt.encoding)
+			}
+			resp := app.ReadFile(path, tt.encoding, false)
+			if !resp.Success || resp.Data != tt.content {
+				t.Errorf("ReadFile = %+v, want %q", resp, tt.content)
+			}
+		})
+	}
+}
+
+func TestFileEncodingErrors(t *testing.T) {
+	app := NewApp()
+	path := filepath.Join(t.TempDir(), "out.json")
+	if resp := app.WriteFileDirect(`{"name": "张三"}`, path, "latin1", false); resp.Success {
+		t.Errorf("WriteFileDirect of Chinese text as latin1 = %+v, want an error", resp)
+	}
+	if resp := app.WriteFileDirect(`{}`, path, "ebcdic", false); resp.Success {
+		t.Errorf("WriteFileDirect with an unknown encoding = %+v, want an error", resp)
+	}
+
+	// 0x81 starts a two-byte GBK character, which the quote cannot complete
+	if err := os.WriteFile(path, []byte{'"', 0x81, '"'}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if resp := app.ReadFile(path, "gbk", false); resp.Success {
+		t.Errorf("ReadFile of undecodable GBK = %+v, want an error", resp)
+	}
+}
+
+func TestConvertToJavaClassBuilder(t *testing.T) {
+	input := `{"zeta": 1, "user": {"b_name": "x", "a": true}, "items": [{"id": 1}]}`
+	resp := NewApp().ConvertToJavaClassOpts(input, "Root", ConvertOptions{JavaBuilder: true})
+	if !resp.Success {
+		t.Fatalf("ConvertToJavaClassOpts failed: %s", resp.Error)
+	}
+	for _, want := range []string{
+		"public final class Root {\n    private final List<Items> items;\n    private final User user;\n    private final Integer zeta;\n",
+		"    private Root(Builder builder) {\n        this.items = builder.items;\n        this.user = builder.user;\n        this.zeta = builder.zeta;\n    }\n",
+		"public final class User {\n    private final Boolean a;\n    private final String bName;\n",
+		"    public String getBName() {\n        return this.bName;\n    }\n",
+		"        public Builder bName(String bName) {\n            this.bName = bName;\n            return this;\n        }\n",
+		"        public User build() {\n            return new User(this);\n        }\n",
+		"public final class Items {\n    private final Integer id;\n",
+	} {
+		if !strings.Contains(resp.Data, want) {
+			t.Errorf("output lacks %q:\n%s", want, resp.Data)
+		}
+	}
+	if strings.Contains(resp.Data, "public void set") {
+		t.Errorf("builder output has setters:\n%s", resp.Data)
+	}
+
+	// The output is stable across runs even though the input is decoded into maps
+	for run := 0; run < 5; run++ {
+		if again := NewApp().ConvertToJavaClassOpts(input, "Root", ConvertOptions{JavaBuilder: true}); again.Data != resp.Data {
+			t.Fatalf("ConvertToJavaClassOpts output differs between runs:\n%s\n%s", resp.Data, again.Data)
+		}
+	}
+}
+
+func TestConvertToJavaClassEmptyKey(t *testing.T) {
+	for _, opts := range []ConvertOptions{{}, {JavaBuilder: true}} {
+		resp := NewApp().ConvertToJavaClassOpts(`{"": {"x": 1}, "_": 2}`, "Root", opts)
+		if !resp.Success || !strings.Contains(resp.Data, " Field field;") {
+			t.Errorf("ConvertToJavaClassOpts with an empty key = %+v", resp)
+		}
+	}
+	if resp := NewApp().ConvertToTypeScriptInterface(`{"": {"x": 1}}`, false, false, "Root"); !resp.Success {
+		t.Errorf("ConvertToTypeScriptInterface with an empty key failed: %s", resp.Error)
+	}
+}
+
+func TestRepairStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		repaired bool
+	}{
+		{"valid input unchanged", `{"a": 1, "b": [true, null]}`, `{"a":1,"b":[true,null]}`, false},
+		{"lone surrogate escape", `["\ud800"]`, `["\ud800"]`, false},
+		{"leading plus", `[+1]`, `[1]`, true},
+		{"bare fraction", `[.5]`, `[0.5]`, true},
+		{"incomplete exponent", `[1E+]`, `[1E+0]`, true},
+		{"raw control character", "[\"a\x01b\"]", `["a\u0001b"]`, true},
+		{"trailing garbage", `{"a":1}x`, `{"a":1}`, true},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.RepairStrict(tt.input)
+			if !resp.Success {
+				t.Fatalf("RepairStrict failed: %s", resp.Error)
+			}
+			if err := strictDecode(resp.Data); err != nil {
+				t.Errorf("RepairStrict output rejected by encoding/json: %v", err)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want || resp.Repaired != tt.repaired {
+				t.Errorf("RepairStrict(%q) = %s (repaired %v), want %s (repaired %v)", tt.input, got, resp.Repaired, tt.want, tt.repaired)
+			}
+		})
+	}
+}
+
+func TestRepairStrictInvalidUTF8(t *testing.T) {
+	// gjson.Valid accepts invalid UTF-8 inside strings, encoding/json would silently replace it
+	for _, input := range []string{"\"a\xffb\"", "{\"k\xfe\": 1}"} {
+		if !gjson.Valid(input) {
+			t.Fatalf("gjson.Valid(%q) = false, the test no longer covers a disagreement", input)
+		}
+		if resp := NewApp().ProcessJSON(input, "4", false, true); !resp.Success {
+			t.Errorf("ProcessJSON(%q) failed: %s", input, resp.Error)
+		}
+		if resp := NewApp().RepairStrict(input); resp.Success {
+			t.Errorf("RepairStrict(%q) = %+v, want an error", input, resp)
+		}
+	}
+}
+
+func TestConvertMergesArrayElements(t *testing.T) {
+	// Keys appear in different elements, score widens from int to float and owner is merged
+	input := `[{"id": 1, "score": 2, "owner": {"name": "a"}}, {"id": 2, "score": 2.5, "label": "x", "owner": {"age": 3}}, {"id": 3, "label": null, "owner": null}]`
+	app := NewApp()
+	tests := []struct {
+		name    string
+		convert func(ConvertOptions) JSONResponse
+		want    []string
+	}{
+		{"go", func(o ConvertOptions) JSONResponse { return app.ConvertToGoStructOpts(input, "Row", o) }, []string{
+			"    Id int `json:\"id\"`\n", "    Score float64 `json:\"score\"`\n", "    Label string `json:\"label\"`\n", "    Owner Owner `json:\"owner\"`\n",
+			"    Name string `json:\"name\"`\n", "    Age int `json:\"age\"`\n",
+		}},
+		{"typescript", func(o ConvertOptions) JSONResponse { return app.ConvertToTypeScriptInterfaceOpts(input, "Row", o) }, []string{
+			"    id: number;\n", "    score?: number;\n", "    label?: string | null;\n", "    owner: Owner | null;\n",
+			"    name: string;\n", "    age: number;\n",
+		}},
+		{"python", func(o ConvertOptions) JSONResponse { return app.ConvertToPythonClassOpts(input, "Row", o) }, []string{
+			"    id: int\n", "    score: float\n", "    label: str\n", "    owner: Owner\n", "    name: str\n", "    age: int\n",
+		}},
+		{"java", func(o ConvertOptions) JSONResponse { return app.ConvertToJavaClassOpts(input, "Row", o) }, []string{
+			"    private Integer id;\n", "    private Double score;\n", "    private String label;\n", "    private Owner owner;\n",
+			"    private Integer age;\n    private String name;\n",
+		}},
+		{"csharp", func(o ConvertOptions) JSONResponse { return app.ConvertToCSharpClassOpts(input, "Row", o) }, []string{
+			"    public int Id { get; set; }\n", "    public double Score { get; set; }\n", "    public string Label { get; set; }\n",
+			"    public Owner Owner { get; set; }\n", "    public string Name { get; set; }\n", "    public int Age { get; set; }\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.convert(ConvertOptions{})
+			if !resp.Success {
+				t.Fatalf("conversion failed: %s", resp.Error)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(resp.Data, want) {
+					t.Errorf("output lacks %q:\n%s", want, resp.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertToTypeScriptOptionalNullable(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"omitted and null in different fields",
+			`[{"id": 1, "name": "a", "email": null}, {"id": 2, "email": "x"}, {"id": 3, "name": "c", "email": "y"}]`,
+			[]string{"    id: number;\n", "    name?: string;\n", "    email: string | null;\n"}},
+		{"omitted and null in one field of a nested array",
+			`{"users": [{"id": 1, "tags": null}, {"id": 2, "tags": ["a"]}, {"id": 3}]}`,
+			[]string{"    users: Users[];\n", "    id: number;\n", "    tags?: string[] | null;\n"}},
+		{"always null", `[{"a": null}, {"a": null}]`, []string{"    a: any | null;\n"}},
+		{"single object", `{"a": null, "b": 1}`, []string{"    a: any | null;\n", "    b: number;\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToTypeScriptInterfaceOpts(tt.input, "Root", ConvertOptions{})
+			if !resp.Success {
+				t.Fatalf("ConvertToTypeScriptInterfaceOpts failed: %s", resp.Error)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(resp.Data, want) {
+					t.Errorf("output lacks %q:\n%s", want, resp.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeSamples(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   interface{}
+	}{
+		{"widen to float", []interface{}{1.0, 2.5, 3.0}, 2.5},
+		{"integers stay", []interface{}{1.0, 2.0}, 1.0},
+		{"first non-null", []interface{}{nil, "a", 1.0}, "a"},
+		{"all null", []interface{}{nil, nil}, nil},
+		{"arrays concatenated", []interface{}{[]interface{}{1.0}, nil, []interface{}{2.0, 3.0}}, []interface{}{1.0, 2.0, 3.0}},
+		{"objects merged", []interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 1.5, "b": map[string]interface{}{"c": true}}},
+			map[string]interface{}{"a": 1.5, "b": map[string]interface{}{"c": true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeSamples(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeSamples(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportArrayElements(t *testing.T) {
+	dir := t.TempDir()
+	// An existing file must not be overwritten by an element with the same name
+	existing := filepath.Join(dir, "ann.json")
+	if err := os.WriteFile(existing, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := `[{"name": "ann", "n": 1}, {"name": "Ann"}, {"name": "a/b:c"}, {"n": 4}, {"name": " CON "}, 7, {"name": "ann_2"}]`
+	resp := NewApp().ExportArrayElements(input, dir, "name")
+	if !resp.Success {
+		t.Fatalf("ExportArrayElements failed: %s", resp.Error)
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(resp.Data), &paths); err != nil {
+		t.Fatalf("ExportArrayElements returned %q: %v", resp.Data, err)
+	}
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{"ann_2.json", "Ann_3.json", "a_b_c.json", "3.json", "_CON.json", "5.json", "ann_2_2.json"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ExportArrayElements wrote %v, want %v", names, want)
+	}
+
+	if content, _ := os.ReadFile(existing); string(content) != "keep" {
+		t.Errorf("existing file was overwritten with %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "ann_2.json")); string(content) != "{\n    \"name\": \"ann\",\n    \"n\": 1\n}" {
+		t.Errorf("ann_2.json = %q", content)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(want)+1 {
+		t.Errorf("directory holds %d files, want %d", len(entries), len(want)+1)
+	}
+
+	// A second export never reuses a name, also when it only differs in case (Ann_3.json)
+	resp = NewApp().ExportArrayElements(`[{"name": "ann"}]`, dir, "name")
+	if !resp.Success || !strings.HasSuffix(resp.Data, `ann_4.json"]`) {
+		t.Errorf("second ExportArrayElements = %+v, want ann_4.json", resp)
+	}
+
+	if resp := NewApp().ExportArrayElements(`{"a": 1}`, dir, ""); resp.Success {
+		t.Errorf("ExportArrayElements of an object = %+v, want an error", resp)
+	}
+}
+
+func TestWriteNewFile(t *testing.T) {
+	// A file created after ExportArrayElements listed the directory is still not overwritten
+	path := filepath.Join(t.TempDir(), "a.json")
+	if err := writeNewFile(path, []byte("first")); err != nil {
+		t.Fatalf("writeNewFile failed: %v", err)
+	}
+	if err := writeNewFile(path, []byte("second")); !os.IsExist(err) {
+		t.Errorf("writeNewFile over an existing file = %v, want an IsExist error", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "first" {
+		t.Errorf("existing file was overwritten with %q", content)
+	}
+}
+
+func TestProcessJSONDuplicateKeyStrategy(t *testing.T) {
+	input := `{"a": 1, "b": {"x": 1, "x": 2}, "a": 2, " a ": 3}`
+	tests := []struct {
+		strategy string
+		trim     bool
+		ordered  string
+		sorted   string
+	}{
+		{"keep-first", false, `{"a":1,"b":{"x":1}," a ":3}`, `{" a ":3,"a":1,"b":{"x":1}}`},
+		{"keep-last", false, `{"a":2,"b":{"x":2}," a ":3}`, `{" a ":3,"a":2,"b":{"x":2}}`},
+		{"rename", false, `{"a":1,"b":{"x":1,"x_2":2},"a_2":2," a ":3}`, `{" a ":3,"a":1,"a_2":2,"b":{"x":1,"x_2":2}}`},
+		// Keys that only differ by surrounding whitespace are duplicates once trimmed
+		{"keep-first", true, `{"a":1,"b":{"x":1}}`, `{"a":1,"b":{"x":1}}`},
+		{"keep-last", true, `{"a":3,"b":{"x":2}}`, `{"a":3,"b":{"x":2}}`},
+		{"rename", true, `{"a":1,"b":{"x":1,"x_2":2},"a_2":2,"a_3":3}`, `{"a":1,"a_2":2,"a_3":3,"b":{"x":1,"x_2":2}}`},
+	}
+	for _, tt := range tests {
+		ordered := processCompact(t, input, FormatOptions{KeepOrder: true, TrimWhitespace: tt.trim, DuplicateKeyStrategy: tt.strategy})
+		if ordered != tt.ordered {
+			t.Errorf("%s (trim %v, keep order) = %s, want %s", tt.strategy, tt.trim, ordered, tt.ordered)
+		}
+		sorted := processCompact(t, input, FormatOptions{TrimWhitespace: tt.trim, DuplicateKeyStrategy: tt.strategy})
+		if sorted != tt.sorted {
+			t.Errorf("%s (trim %v, sorted) = %s, want %s", tt.strategy, tt.trim, sorted, tt.sorted)
+		}
+		// MinifyJSONOpts applies the strategy on both of its branches
+		if resp := NewApp().MinifyJSONOpts(input, FormatOptions{KeepOrder: true, TrimWhitespace: tt.trim, DuplicateKeyStrategy: tt.strategy}); resp.Data != tt.ordered {
+			t.Errorf("%s (trim %v, minify keep order) = %+v, want %s", tt.strategy, tt.trim, resp, tt.ordered)
+		}
+		if resp := NewApp().MinifyJSONOpts(input, FormatOptions{TrimWhitespace: tt.trim, DuplicateKeyStrategy: tt.strategy}); resp.Data != tt.sorted {
+			t.Errorf("%s (trim %v, minify sorted) = %+v, want %s", tt.strategy, tt.trim, resp, tt.sorted)
+		}
+	}
+
+	app := NewApp()
+	if resp := app.MinifyJSONOpts(`{a:1,a:2}`, FormatOptions{KeepOrder: true, DuplicateKeyStrategy: "keep-first"}); resp.Data != `{"a":1}` || !resp.Repaired {
+		t.Errorf("MinifyJSONOpts keep-first of repaired input = %+v, want a repaired {\"a\":1}", resp)
+	}
+	for _, strategy := range []string{"error", "bogus"} {
+		if resp := app.ProcessJSONOpts(input, FormatOptions{KeepOrder: true, DuplicateKeyStrategy: strategy}); resp.Success {
+			t.Errorf("strategy %q = %+v, want an error", strategy, resp)
+		}
+		for _, minifyInput := range []string{`{"a":1,"a":2}`, `{a:1,a:2}`} {
+			if resp := app.MinifyJSONOpts(minifyInput, FormatOptions{KeepOrder: true, DuplicateKeyStrategy: strategy}); resp.Success {
+				t.Errorf("minify %s with strategy %q = %+v, want an error", minifyInput, strategy, resp)
+			}
+		}
+	}
+	// FormatJSONOpts applies the strategy too, even to valid input it would otherwise only indent
+	if resp := app.FormatJSONOpts(`{"a": 1, "a": 2}`, FormatOptions{Indent: "2", KeepOrder: true, DuplicateKeyStrategy: "keep-last"}); resp.Data != "{\n  \"a\": 2\n}" {
+		t.Errorf("FormatJSONOpts = %+v, want only the last a", resp)
+	}
+}
+
+func TestProcessJSONExpansionWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ratio float64
+		warn  bool
+	}{
+		{"sparse array", `[` + strings.Repeat(",", 20) + `1]`, 3, true},
+		{"unclosed nesting", strings.Repeat("[", 30), 1.5, true},
+		{"missing quotes", `{a: b, c: d}`, 3, false},
+		{"missing closing brace", `{"name": "x", "tags": ["a", "b"]`, 1.5, false},
+		{"valid input", `[1,2,3]`, 0.1, false},
+		{"disabled", `[` + strings.Repeat(",", 20) + `1]`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ProcessJSONOpts(tt.input, FormatOptions{KeepOrder: true, ExpansionWarningRatio: tt.ratio})
+			if !resp.Success {
+				t.Fatalf("ProcessJSONOpts failed: %s", resp.Error)
+			}
+			if got := resp.Warning != ""; got != tt.warn {
+				t.Errorf("ProcessJSONOpts warning = %q, want warning %v", resp.Warning, tt.warn)
+			}
+		})
+	}
+}
+
+func TestGetPathByOffset(t *testing.T) {
+	input := "  {\"a\": [1,  2], \"b\": {   }, \"c\": {\"d\": [ ]}, \"é\": \"x\"}"
+	tests := []struct {
+		name   string
+		offset int
+		want   string
+	}{
+		{"leading whitespace", 0, "$"},
+		{"key", 4, "$.a"},
+		{"array element", 13, "$.a[1]"},
+		{"between array elements", 11, "$.a"},
+		{"comma after an element", 10, "$.a"},
+		{"between object members", 16, "$"},
+		{"inside an empty object", 24, "$.b"},
+		{"inside a nested empty array", 41, "$.c.d"},
+		{"whitespace in a nested object", 39, "$.c"},
+		{"offsets count runes", 52, "$.é"},
+		{"closing brace", 54, "$"},
+		{"out of range", 100, "$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewApp().GetPathByOffset(input, tt.offset); got != tt.want {
+				t.Errorf("GetPathByOffset(%d) = %s, want %s", tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	input := `{"store": {"book": [{"title": "A", "price": 8}, {"title": "B", "price": 12}, {"title": "C", "price": 5}]}, "friends": [{"name": "x"}, {"name": "y"}], "a.b": 1}`
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"first match", "store.book.#(price<10).title", `"A"`},
+		{"all matches", "store.book.#(price<10)#.title", `["A","C"]`},
+		{"field of every element", "friends.#.name", `["x","y"]`},
+		{"array length", "store.book.#", `3`},
+		{"index", "store.book.1", `{"title":"B","price":12}`},
+		{"escaped dot", `a\.b`, `1`},
+		{"no matches in an all-matches query", "store.book.#(price>100)#.title", `[]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().QueryJSON(input, tt.query)
+			if !resp.Success {
+				t.Fatalf("QueryJSON(%q) failed: %s", tt.query, resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("QueryJSON(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		query string
+		want  string
+	}{
+		{"", "查询路径为空"},
+		{"store..book", "空的路径段"},
+		{"store.book.#(price<10", "没有闭合"},
+		{`store.book.#(title=="A)`, "缺少结束引号"},
+		{")", "没有匹配的左括号"},
+		{"missing", "没有匹配的结果"},
+	}
+	for _, tt := range errorTests {
+		if resp := NewApp().QueryJSON(input, tt.query); resp.Success || !strings.Contains(resp.Error, tt.want) {
+			t.Errorf("QueryJSON(%q) = %+v, want an error containing %q", tt.query, resp, tt.want)
+		}
+	}
+
+	resp := NewApp().QueryJSON(`{a: [1, 2,]}`, "a")
+	if !resp.Success || !resp.Repaired || compactJSON(t, resp.Data) != `[1,2]` {
+		t.Errorf("QueryJSON on invalid input = %+v, want the repaired [1,2]", resp)
+	}
+}
+
+func TestProcessJSONLargeIntegers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		sorted  string
+		ordered string
+	}{
+		{"valid input", `{"id": 12345678901234567890, "b": [-98765432109876543210]}`,
+			`{"b":[-98765432109876543210],"id":12345678901234567890}`, `{"id":12345678901234567890,"b":[-98765432109876543210]}`},
+		{"repaired input", `{id: 12345678901234567890, b: [-98765432109876543210,]}`,
+			`{"b":[-98765432109876543210],"id":12345678901234567890}`, `{"id":12345678901234567890,"b":[-98765432109876543210]}`},
+		{"fractions and exponents", `{"f": 0.10000000000000000001, "e": 1E400}`,
+			`{"e":1E400,"f":0.10000000000000000001}`, `{"f":0.10000000000000000001,"e":1E400}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, opts := range []FormatOptions{{Indent: "2"}, {Indent: "2", TrimWhitespace: true}} {
+				if got := processCompact(t, tt.input, opts); got != tt.sorted {
+					t.Errorf("ProcessJSONOpts(%+v) = %s, want %s", opts, got, tt.sorted)
+				}
+			}
+			if got := processCompact(t, tt.input, FormatOptions{Indent: "2", KeepOrder: true}); got != tt.ordered {
+				t.Errorf("ProcessJSONOpts with KeepOrder = %s, want %s", got, tt.ordered)
+			}
+		})
+	}
+}
+
+func TestConvertToSQLInserts(t *testing.T) {
+	input := `[{"id": 12345678901234567890, "name": "O'Brien", "active": true, "note": null, "meta": {"k": "v'"}, "tags": ["a"]}, {"id": 2, "name": "b", "active": false}, 3]`
+	tests := []struct {
+		databaseType string
+		want         string
+	}{
+		{"mysql", "-- mysql INSERT statements\n" +
+			`INSERT INTO users (active, id, meta, name, note, tags) VALUES (1, 12345678901234567890, '{"k":"v''"}', 'O''Brien', NULL, '["a"]');` + "\n" +
+			`INSERT INTO users (active, id, name) VALUES (0, 2, 'b');`},
+		{"postgresql", `INSERT INTO users (active, id, meta, name, note, tags) VALUES (TRUE, 12345678901234567890, '{"k":"v''"}', 'O''Brien', NULL, '["a"]');` + "\n" +
+			`INSERT INTO users (active, id, name) VALUES (FALSE, 2, 'b');`},
+		{"sqlserver", `INSERT INTO users (active, id, meta, name, note, tags) VALUES (1, 12345678901234567890, N'{"k":"v''"}', N'O''Brien', NULL, N'["a"]');` + "\n" +
+			`INSERT INTO users (active, id, name) VALUES (0, 2, N'b');`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.databaseType, func(t *testing.T) {
+			resp := NewApp().ConvertToSQL(input, false, true, tt.databaseType, "users", true)
+			if !resp.Success {
+				t.Fatalf("ConvertToSQL failed: %s", resp.Error)
+			}
+			if !strings.Contains(resp.Data, tt.want) {
+				t.Errorf("ConvertToSQL output lacks %q:\n%s", tt.want, resp.Data)
+			}
+			if !strings.Contains(resp.Data, "CREATE TABLE users (") {
+				t.Errorf("ConvertToSQL output lacks the table definition:\n%s", resp.Data)
+			}
+		})
+	}
+
+	if resp := NewApp().ConvertToSQL(`{"a": 1}`, false, true, "mysql", "t", false); !resp.Success || strings.Contains(resp.Data, "INSERT") {
+		t.Errorf("ConvertToSQL without inserts = %+v", resp)
+	}
+	if resp := NewApp().ConvertToSQL(`{"a": "x"}`, false, true, "mysql", "t", true); !strings.HasSuffix(resp.Data, "INSERT INTO t (a) VALUES ('x');\n") {
+		t.Errorf("ConvertToSQL of a root object = %q", resp.Data)
+	}
+
+	want := "-- mysql CREATE TABLE statement\n" +
+		"CREATE TABLE t (\n" +
+		"    active TINYINT(1),\n" +
+		"    id BIGINT,\n" +
+		"    name VARCHAR(255),\n" +
+		"    score DECIMAL(20,10)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n\n" +
+		"-- mysql INSERT statements\n" +
+		"INSERT INTO t (active, id, name, score) VALUES (1, 7, 'x', 1.5);\n"
+	for i := 0; i < 10; i++ {
+		if resp := NewApp().ConvertToSQL(`{"score": 1.5, "name": "x", "id": 7, "active": true}`, false, true, "mysql", "t", true); resp.Data != want {
+			t.Fatalf("ConvertToSQL = %q, want %q", resp.Data, want)
+		}
+	}
+}
+
+func TestConvertCombinedOutput(t *testing.T) {
+	input := `{"zeta": 1, "user": {"name": "x", "address": {"city": "c"}}, "items": [{"id": 1}]}`
+	app := NewApp()
+	opts := ConvertOptions{CombinedOutput: true, CodeNamespace: " com.example "}
+	tests := []struct {
+		name        string
+		convert     func(ConvertOptions) JSONResponse
+		header      string
+		importLine  string
+		definitions []string
+	}{
+		{"java", func(o ConvertOptions) JSONResponse { return app.ConvertToJavaClassOpts(input, "Root", o) },
+			"package com.example;\n\nimport java.util.*;\n", "import ",
+			[]string{"public class Root {", "\nclass Items {", "\nclass User {", "\nclass Address {"}},
+		{"go", func(o ConvertOptions) JSONResponse { return app.ConvertToGoStructOpts(input, "Root", o) },
+			"package com.example\n", "package ",
+			[]string{"type Root struct", "type Items struct", "type User struct", "type Address struct"}},
+		{"csharp", func(o ConvertOptions) JSONResponse { return app.ConvertToCSharpClassOpts(input, "Root", o) },
+			"using System.Collections.Generic;\n\nnamespace com.example\n{\n", "using ",
+			[]string{"    public class Root\n", "    public class Items\n", "    public class User\n", "    public class Address\n"}},
+		{"typescript", func(o ConvertOptions) JSONResponse { return app.ConvertToTypeScriptInterfaceOpts(input, "Root", o) },
+			"export namespace com.example {\n", "export namespace",
+			[]string{"    export interface Root {", "    export interface Items {", "    export interface User {", "    export interface Address {"}},
+		{"python", func(o ConvertOptions) JSONResponse { return app.ConvertToPythonClassOpts(input, "Root", o) },
+			"from __future__ import annotations\n\nfrom typing import Any, Optional\n", "from typing",
+			[]string{"class Root:", "class Items:", "class User:", "class Address:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.convert(opts)
+			if !resp.Success {
+				t.Fatalf("convert failed: %s", resp.Error)
+			}
+			if !strings.HasPrefix(resp.Data, tt.header) {
+				t.Errorf("output does not start with %q:\n%s", tt.header, resp.Data)
+			}
+			if n := strings.Count(resp.Data, tt.importLine); n != 1 {
+				t.Errorf("output has %d %q lines, want 1:\n%s", n, tt.importLine, resp.Data)
+			}
+			// Root first, then its dependencies breadth first in field order
+			last := -1
+			for _, definition := range tt.definitions {
+				idx := strings.Index(resp.Data, definition)
+				if idx <= last {
+					t.Errorf("%q is missing or out of order:\n%s", definition, resp.Data)
+				}
+				last = idx
+			}
+			// Fields are sorted by key, so repeated runs give the same output
+			for range 20 {
+				if again := tt.convert(opts); again.Data != resp.Data {
+					t.Fatalf("output changed between runs:\n%s\n---\n%s", resp.Data, again.Data)
+				}
+			}
+		})
+	}
+
+	t.Run("sql", func(t *testing.T) {
+		resp := app.ConvertToSQL(input, false, true, "mysql", "root", false)
+		if !resp.Success {
+			t.Fatalf("ConvertToSQL failed: %s", resp.Error)
+		}
+		// Nested tables follow the sorted columns, each nested object before the next column
+		last := -1
+		for _, table := range []string{"CREATE TABLE root (", "CREATE TABLE items (", "CREATE TABLE user (", "CREATE TABLE address ("} {
+			idx := strings.Index(resp.Data, table)
+			if idx <= last {
+				t.Errorf("%q is missing or out of order:\n%s", table, resp.Data)
+			}
+			last = idx
+		}
+		for range 20 {
+			if again := app.ConvertToSQL(input, false, true, "mysql", "root", false); again.Data != resp.Data {
+				t.Fatalf("output changed between runs:\n%s\n---\n%s", resp.Data, again.Data)
+			}
+		}
+	})
+
+	// Without CombinedOutput there is no namespace, and Java still imports once
+	resp := app.ConvertToJavaClassOpts(input, "Root", ConvertOptions{CodeNamespace: "com.example"})
+	if strings.Contains(resp.Data, "package ") || strings.Count(resp.Data, "import java.util.*;") != 1 {
+		t.Errorf("separate Java output:\n%s", resp.Data)
+	}
+}
+
+func TestConvertToJavaClassGetters(t *testing.T) {
+	input := `{"name": "x", "age": 3, "tags": ["a"], "user": {"id": 1}}`
+	resp := NewApp().ConvertToJavaClass(input, false, false, "Root")
+	if !resp.Success {
+		t.Fatalf("ConvertToJavaClass failed: %s", resp.Error)
+	}
+	// Getters return the type of their field, like the builder variant
+	for _, want := range []string{
+		"    public Integer getAge() {\n",
+		"    public String getName() {\n",
+		"    public List<String> getTags() {\n",
+		"    public User getUser() {\n",
+		"    public Integer getId() {\n",
+	} {
+		if !strings.Contains(resp.Data, want) {
+			t.Errorf("output lacks %q:\n%s", want, resp.Data)
+		}
+	}
+}
+
+func TestConvertToSQLColumnTypesFromAllRows(t *testing.T) {
+	input := `[{"id": 1, "age": null, "score": 1}, {"id": 2, "age": 30, "score": 2.5, "name": "b"}, {"id": 3, "age": 40, "score": 3, "flag": true, "mixed": 1}, {"id": 4, "mixed": "x"}]`
+	tests := []struct {
+		databaseType string
+		want         map[string]string
+	}{
+		{"mysql", map[string]string{"id": "BIGINT", "age": "BIGINT", "score": "DECIMAL(20,10)", "name": "VARCHAR(255)", "flag": "TINYINT(1)", "mixed": "VARCHAR(255)"}},
+		{"postgresql", map[string]string{"id": "INT8", "age": "INT8", "score": "NUMERIC(20,10)", "name": "TEXT", "flag": "BOOLEAN", "mixed": "TEXT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.databaseType, func(t *testing.T) {
+			resp := NewApp().ConvertToSQL(input, false, true, tt.databaseType, "t", false)
+			if !resp.Success {
+				t.Fatalf("ConvertToSQL failed: %s", resp.Error)
+			}
+			// Column order is not specified, so compare the column definitions as a set
+			got := make(map[string]string)
+			for _, line := range strings.Split(resp.Data, "\n") {
+				if strings.HasPrefix(line, "    ") {
+					column, typ, _ := strings.Cut(strings.TrimSuffix(strings.TrimSpace(line), ","), " ")
+					got[column] = typ
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertToSQL columns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessJSONOptsPreserveSpecialWhitespace(t *testing.T) {
+	input := "{\"s\": \"\u00A0a\u3000\", \"u\": hello\u3000,\u00A0\"v\": world\u00A0}"
+	tests := []struct {
+		name string
+		keep bool
+		want string
+	}{
+		{"trimmed by default", false, "{\"s\":\"\u00A0a\u3000\",\"u\":\"hello\",\"v\":\"world\"}"},
+		{"kept", true, "{\"s\":\"\u00A0a\u3000\",\"u\":\"hello\u3000\",\"v\":\"world\u00A0\"}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := processCompact(t, input, FormatOptions{KeepOrder: true, PreserveSpecialWhitespace: tt.keep}); got != tt.want {
+				t.Errorf("ProcessJSONOpts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessJSONOpts(t *testing.T) {
+	input := `{b: ' x ', a: [1,2,]`
+	tests := []struct {
+		name string
+		// opts is the object the frontend passes, decoded through the JSON tags
+		opts string
+		want string
+	}{
+		{"indent and order", `{"indent": "2", "keepOrder": true}`, "{\n  \"b\": \" x \",\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"tab, sorted by remarshaling", `{"indent": "tab"}`, "{\n\t\"a\": [\n\t\t1,\n\t\t2\n\t],\n\t\"b\": \" x \"\n}"},
+		{"trimmed with the default indent", `{"trimWhitespace": true, "keepOrder": true}`, "{\n    \"b\": \"x\",\n    \"a\": [\n        1,\n        2\n    ]\n}"},
+		{"sorted keys", `{"indent": "4", "sortKeys": true}`, "{\n    \"a\": [\n        1,\n        2\n    ],\n    \"b\": \" x \"\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts FormatOptions
+			if err := json.Unmarshal([]byte(tt.opts), &opts); err != nil {
+				t.Fatal(err)
+			}
+			resp := NewApp().ProcessJSONOpts(input, opts)
+			if !resp.Success || !resp.Repaired {
+				t.Fatalf("ProcessJSONOpts = %+v, want a repaired success", resp)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ProcessJSONOpts = %q, want %q", resp.Data, tt.want)
+			}
+			if !opts.SortKeys {
+				if legacy := NewApp().ProcessJSON(input, opts.Indent, opts.TrimWhitespace, opts.KeepOrder); legacy != resp {
+					t.Errorf("ProcessJSON = %+v, want the same as ProcessJSONOpts", legacy)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveIndent(t *testing.T) {
+	tests := []struct {
+		indent string
+		want   string
+	}{
+		{"tab", "\t"},
+		{"2", "  "},
+		{"3", "   "},
+		{"8", "        "},
+		{"16", strings.Repeat(" ", 16)},
+		{"17", strings.Repeat(" ", 16)},
+		{"1000000", strings.Repeat(" ", 16)},
+		{"", "    "},
+		{"0", "    "},
+		{"-2", "    "},
+		{"wide", "    "},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.indent, func(t *testing.T) {
+			if got := resolveIndent(tt.indent); got != tt.want {
+				t.Errorf("resolveIndent(%q) = %q, want %q", tt.indent, got, tt.want)
+			}
+			want := "{\n" + tt.want + "\"a\": [\n" + tt.want + tt.want + "1\n" + tt.want + "]\n}"
+			if resp := app.ProcessJSON(`{a: [1]}`, tt.indent, false, true); resp.Data != want {
+				t.Errorf("ProcessJSON = %q, want %q", resp.Data, want)
+			}
+			if resp := app.FormatJSON(`{"a": [1]}`, tt.indent, false, true); resp.Data != want {
+				t.Errorf("FormatJSON = %q, want %q", resp.Data, want)
+			}
+			if resp := app.FormatJSONWithBraceStyle(`{"a": [1]}`, tt.indent, false, true, "kr"); resp.Data != want {
+				t.Errorf("FormatJSONWithBraceStyle = %q, want %q", resp.Data, want)
+			}
+		})
+	}
+}
+
+func TestProcessJSONSortKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		trim  bool
+		want  string
+	}{
+		{"every level", `{"b": {"z": 1, "y": {"d": 2, "c": 3}}, "a": [{"q": 1, "p": 2}, 3]}`, false,
+			`{"a":[{"p":2,"q":1},3],"b":{"y":{"c":3,"d":2},"z":1}}`},
+		{"numbers kept as written", `{"n": 12345678901234567890, "f": 1.50, "e": 1E+3, "m": -0.0}`, false,
+			`{"e":1E+3,"f":1.50,"m":-0.0,"n":12345678901234567890}`},
+		{"repaired input", `{b: 1, a: {d: 12345678901234567890, c: 1.10}}`, false,
+			`{"a":{"c":1.10,"d":12345678901234567890},"b":1}`},
+		{"duplicate keys kept in order", `{"k": 1, "j": 2, "k": 3}`, false, `{"j":2,"k":1,"k":3}`},
+		{"byte order", "{\"B\": 1, \"a\": 2, \"\xc3\xa9\": 3, \"10\": 4, \"9\": 5}", false,
+			"{\"10\":4,\"9\":5,\"B\":1,\"a\":2,\"\xc3\xa9\":3}"},
+		{"untrimmed keys", `{" b ": 1, "a": 2}`, false, `{" b ":1,"a":2}`},
+		{"keys trimmed before sorting", `{" b ": 1, "a": 2}`, true, `{"a":2,"b":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := processCompact(t, tt.input, FormatOptions{SortKeys: true, TrimWhitespace: tt.trim}); got != tt.want {
+				t.Errorf("ProcessJSONOpts = %s, want %s", got, tt.want)
+			}
+			// Minifying sorts the same way, whether or not key order is kept otherwise
+			for _, keepOrder := range []bool{false, true} {
+				resp := NewApp().MinifyJSONOpts(tt.input, FormatOptions{SortKeys: true, KeepOrder: keepOrder, TrimWhitespace: tt.trim})
+				if resp.Data != tt.want {
+					t.Errorf("MinifyJSONOpts (keep order %v) = %+v, want %s", keepOrder, resp, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessJSONKeepsNumberFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"sorted keys", `{"b": 1.0, "a": 1e3, "c": 0.10}`, `{"a":1e3,"b":1.0,"c":0.10}`},
+		{"array", `[1.0, 1E+3, 0.10, -0.0, 12345678901234567890]`, `[1.0,1E+3,0.10,-0.0,12345678901234567890]`},
+		{"repaired input", `{b: 1.0, a: [1e3, .5]}`, `{"a":[1e3,0.5],"b":1.0}`},
+		{"truncated input", `{"x": 0.10`, `{"x":0.10}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ProcessJSON(tt.input, "4", false, false)
+			if !resp.Success {
+				t.Fatalf("ProcessJSON failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("ProcessJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
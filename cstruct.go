@@ -0,0 +1,187 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cKeywords are reserved words that cannot be used as C member names
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true, "continue": true,
+	"default": true, "do": true, "double": true, "else": true, "enum": true, "extern": true,
+	"float": true, "for": true, "goto": true, "if": true, "inline": true, "int": true,
+	"long": true, "register": true, "restrict": true, "return": true, "short": true,
+	"signed": true, "sizeof": true, "static": true, "struct": true, "switch": true,
+	"typedef": true, "union": true, "unsigned": true, "void": true, "volatile": true,
+	"while": true, "bool": true,
+}
+
+// ConvertToCStruct converts JSON to C struct definitions. Integers become long long, other
+// numbers double, strings char * and booleans int. Nested objects get their own struct, defined
+// before the structs using them. C arrays need a size, so a JSON array becomes a pointer plus a
+// size_t <name>_count member; arrays of arrays go through an item struct holding the inner
+// pointer and count. Members are sorted by key.
+func (a *App) ConvertToCStruct(input string, structName string) JSONResponse {
+	return a.ConvertToCStructOpts(input, structName, ConvertOptions{})
+}
+
+// ConvertToCStructOpts is ConvertToCStruct with its settings in a ConvertOptions
+func (a *App) ConvertToCStructOpts(input string, structName string, opts ConvertOptions) JSONResponse {
+	var obj interface{}
+	if err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields); err != nil {
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
+		if !resp.Success {
+			return resp
+		}
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
+		obj = a.trimStrings(obj)
+	}
+
+	if structName == "" {
+		structName = "Root"
+	}
+
+	gen := &cStructGenerator{opts: &opts, names: make(map[string]bool)}
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		gen.define(v, structName, "$")
+	case []interface{}:
+		elemType := gen.elementType(v, structName+"Item", "$")
+		name := gen.reserve(structName)
+		gen.structs = append(gen.structs, "struct "+name+" {\n"+cArrayMember(elemType, "items")+"};\n")
+	default:
+		gen.define(map[string]interface{}{"value": obj}, structName, "$")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("#include <stddef.h>\n\n")
+	builder.WriteString(strings.Join(gen.structs, "\n"))
+	return JSONResponse{Success: true, Data: builder.String()}
+}
+
+// cStructGenerator collects struct definitions in dependency order
+type cStructGenerator struct {
+	opts    *ConvertOptions
+	names   map[string]bool
+	structs []string
+}
+
+// reserve returns a unique struct name derived from name
+func (gen *cStructGenerator) reserve(name string) string {
+	name = cIdentifier(toPascalCase(name))
+	unique := name
+	for n := 2; gen.names[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	gen.names[unique] = true
+	return unique
+}
+
+// define emits a struct for obj after the structs it depends on and returns its name
+func (gen *cStructGenerator) define(obj map[string]interface{}, name string, path string) string {
+	name = gen.reserve(name)
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var body strings.Builder
+	members := make(map[string]bool)
+	for _, key := range keys {
+		member := cIdentifier(toSnakeCase(key))
+		if cKeywords[member] {
+			member += "_"
+		}
+		unique := member
+		for n := 2; members[unique] || members[unique+"_count"]; n++ {
+			unique = member + "_" + strconv.Itoa(n)
+		}
+		members[unique] = true
+
+		memberPath := childJSONPath(path, key)
+		body.WriteString(gen.opts.pathComment("//", memberPath))
+		if array, ok := obj[key].([]interface{}); ok {
+			elemType := gen.elementType(array, toPascalCase(key)+"Item", memberPath)
+			members[unique+"_count"] = true
+			body.WriteString(cArrayMember(elemType, unique))
+			continue
+		}
+		body.WriteString("    " + cDeclaration(gen.memberType(obj[key], toPascalCase(key), memberPath), unique) + ";\n")
+	}
+
+	gen.structs = append(gen.structs, "struct "+name+" {\n"+body.String()+"};\n")
+	return name
+}
+
+// memberType returns the C type of a non-array value, defining nested structs as needed
+func (gen *cStructGenerator) memberType(value interface{}, name string, path string) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "struct " + gen.define(v, name, path)
+	case float64:
+		if v == float64(int64(v)) {
+			return "long long"
+		}
+		return "double"
+	case bool:
+		return "int"
+	case string:
+		return "char *"
+	default:
+		return "void *"
+	}
+}
+
+// elementType returns the C type of the elements of array. Arrays of arrays get an item
+// struct holding the inner pointer and count.
+func (gen *cStructGenerator) elementType(array []interface{}, name string, path string) string {
+	if len(array) == 0 {
+		return "void"
+	}
+	if merged, _, ok := mergeObjectSamples(array); ok {
+		return "struct " + gen.define(merged, name, path+"[*]")
+	}
+	sample := mergeSamples(array)
+	if _, ok := sample.([]interface{}); ok {
+		return "struct " + gen.define(map[string]interface{}{"items": sample}, name, path+"[*]")
+	}
+	return gen.memberType(sample, name, path+"[*]")
+}
+
+// cDeclaration declares name with type typ, attaching pointer stars to the name
+func cDeclaration(typ string, name string) string {
+	if strings.HasSuffix(typ, "*") {
+		return typ + name
+	}
+	return typ + " " + name
+}
+
+// cArrayMember declares the pointer and count members for an array of elemType
+func cArrayMember(elemType string, name string) string {
+	pointer := elemType + " *"
+	if strings.HasSuffix(elemType, "*") {
+		pointer = elemType + "*"
+	}
+	return "    " + pointer + name + ";\n    size_t " + name + "_count;\n"
+}
+
+// cIdentifier turns name into a valid C identifier
+func cIdentifier(name string) string {
+	var builder strings.Builder
+	for _, r := range name {
+		if r == '_' || r < 128 && (r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('_')
+		}
+	}
+	identifier := builder.String()
+	if identifier == "" || identifier[0] >= '0' && identifier[0] <= '9' {
+		identifier = "_" + identifier
+	}
+	return identifier
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestConvertToCStruct(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"nested structs before use and arrays with counts",
+			`{"id": 1, "price": 9.5, "name": "x", "active": true, "note": null, "owner": {"name": "a", "address": {"city": "c"}}, "tags": ["a", "b"], "items": [{"sku": "s", "qty": 2}, {"sku": "t", "extra": 1.5}], "matrix": [[1, 2], [3]], "empty": [], "int": 3}`,
+			"#include <stddef.h>\n\n" +
+				"struct ItemsItem {\n    double extra;\n    long long qty;\n    char *sku;\n};\n\n" +
+				"struct MatrixItem {\n    long long *items;\n    size_t items_count;\n};\n\n" +
+				"struct Address {\n    char *city;\n};\n\n" +
+				"struct Owner {\n    struct Address address;\n    char *name;\n};\n\n" +
+				"struct Order {\n    int active;\n    void *empty;\n    size_t empty_count;\n    long long id;\n    long long int_;\n" +
+				"    struct ItemsItem *items;\n    size_t items_count;\n    struct MatrixItem *matrix;\n    size_t matrix_count;\n" +
+				"    char *name;\n    void *note;\n    struct Owner owner;\n    double price;\n    char **tags;\n    size_t tags_count;\n};\n"},
+		{"deduplicated struct names and identifiers",
+			`{"user": {"id": 1}, "users": [{"user": {"name": "n"}}], "1st-key": 2}`,
+			"#include <stddef.h>\n\n" +
+				"struct User {\n    long long id;\n};\n\n" +
+				"struct User2 {\n    char *name;\n};\n\n" +
+				"struct UsersItem {\n    struct User2 user;\n};\n\n" +
+				"struct Order {\n    long long _1st_key;\n    struct User user;\n    struct UsersItem *users;\n    size_t users_count;\n};\n"},
+		{"count member collision", `{"a": [1], "a_count": 2}`,
+			"#include <stddef.h>\n\nstruct Order {\n    long long *a;\n    size_t a_count;\n    long long a_count_2;\n};\n"},
+		{"root array", `[{"a": 1}, {"a": 2}]`,
+			"#include <stddef.h>\n\nstruct OrderItem {\n    long long a;\n};\n\nstruct Order {\n    struct OrderItem *items;\n    size_t items_count;\n};\n"},
+		{"root scalar", `"text"`,
+			"#include <stddef.h>\n\nstruct Order {\n    char *value;\n};\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToCStruct(tt.input, "Order")
+			if !resp.Success {
+				t.Fatalf("ConvertToCStruct failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToCStruct =\n%s\nwant\n%s", resp.Data, tt.want)
+			}
+		})
+	}
+}
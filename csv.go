@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// ConvertToCSV converts an array of objects (or a single object) to CSV with a header row built
+// from the union of all keys and one row per object. Nested objects are flattened to dotted
+// columns such as address.city and arrays are indexed (tags.0, tags.1). Columns follow their
+// first appearance when keepOrder is true and are sorted otherwise. delimiter defaults to a
+// comma; pass "\t" for TSV. Fields are quoted as described in RFC 4180.
+func (a *App) ConvertToCSV(input string, delimiter string, keepOrder bool) JSONResponse {
+	comma, err := csvDelimiter(delimiter)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	resp := a.ProcessJSON(input, "4", false, keepOrder)
+	if !resp.Success {
+		return resp
+	}
+	root := gjson.Parse(resp.Data)
+
+	var records []gjson.Result
+	switch {
+	case root.IsObject():
+		records = []gjson.Result{root}
+	case root.IsArray():
+		idx := 0
+		var bad string
+		root.ForEach(func(_, value gjson.Result) bool {
+			if !value.IsObject() {
+				bad = "$[" + strconv.Itoa(idx) + "]"
+				return false
+			}
+			records = append(records, value)
+			idx++
+			return true
+		})
+		if bad != "" {
+			return JSONResponse{Success: false, Error: "CSV 导出需要对象数组，元素不是对象: " + bad}
+		}
+	default:
+		return JSONResponse{Success: false, Error: "CSV 导出需要对象或对象数组"}
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, len(records))
+	for idx, record := range records {
+		rows[idx] = make(map[string]string)
+		if !hasChildren(record) {
+			continue
+		}
+		flattenCSVRecord(record, "", rows[idx], func(column string) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		})
+	}
+	if !keepOrder {
+		sort.Strings(columns)
+	}
+
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	writer.Comma = comma
+	writer.Write(columns)
+	for _, row := range rows {
+		fields := make([]string, len(columns))
+		for idx, column := range columns {
+			fields[idx] = row[column]
+		}
+		writer.Write(fields)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return JSONResponse{Success: false, Error: "CSV 导出失败: " + err.Error()}
+	}
+
+	return JSONResponse{Success: true, Data: builder.String(), Repaired: resp.Repaired}
+}
+
+// csvDelimiter returns the field delimiter for a user supplied delimiter string
+func csvDelimiter(delimiter string) (rune, error) {
+	switch delimiter {
+	case "":
+		return ',', nil
+	case "\\t", "tab":
+		return '\t', nil
+	}
+	comma, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || comma == '"' || comma == '\r' || comma == '\n' || comma == utf8.RuneError {
+		return 0, errors.New("无效的分隔符: " + delimiter)
+	}
+	return comma, nil
+}
+
+// ToLongCSV converts any document to CSV in long (tidy) format: one path,value row per leaf in
+// document order, with a third type column when includeType is set. Paths use the dotted
+// column names of ConvertToCSV (items.0.name), so irregular, deeply nested documents that do
+// not fit a table can still be inspected in a spreadsheet. Empty objects and arrays are leaves.
+func (a *App) ToLongCSV(input string, includeType bool) JSONResponse {
+	resp := a.ProcessJSON(input, "4", false, true)
+	if !resp.Success {
+		return resp
+	}
+	if resp.Data == "" {
+		return JSONResponse{Success: false, Error: "输入内容为空"}
+	}
+
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	header := []string{"path", "value"}
+	if includeType {
+		header = append(header, "type")
+	}
+	writer.Write(header)
+	walkCSVLeaves(gjson.Parse(resp.Data), "", func(path string, leaf gjson.Result) {
+		if path == "" {
+			// The document itself is a scalar or empty container
+			path = "$"
+		}
+		record := []string{path, csvCell(leaf)}
+		if includeType {
+			record = append(record, jsonTypeName(leaf))
+		}
+		writer.Write(record)
+	})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return JSONResponse{Success: false, Error: "CSV 导出失败: " + err.Error()}
+	}
+
+	return JSONResponse{Success: true, Data: builder.String(), Repaired: resp.Repaired}
+}
+
+// flattenCSVRecord stores the leaves of res in row under dotted column names and reports every
+// column to addColumn in document order
+func flattenCSVRecord(res gjson.Result, prefix string, row map[string]string, addColumn func(string)) {
+	walkCSVLeaves(res, prefix, func(column string, leaf gjson.Result) {
+		addColumn(column)
+		row[column] = csvCell(leaf)
+	})
+}
+
+// walkCSVLeaves calls visit with the dotted name and value of every leaf of res in document
+// order. Array elements are named by index; empty objects and arrays count as leaves.
+func walkCSVLeaves(res gjson.Result, prefix string, visit func(string, gjson.Result)) {
+	if hasChildren(res) {
+		idx := 0
+		res.ForEach(func(key, value gjson.Result) bool {
+			name := key.String()
+			if res.IsArray() {
+				name = strconv.Itoa(idx)
+			}
+			idx++
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			walkCSVLeaves(value, name, visit)
+			return true
+		})
+		return
+	}
+	visit(prefix, res)
+}
+
+// csvCell returns the cell text of a leaf. Empty objects and arrays are kept as {} and [];
+// null becomes an empty cell.
+func csvCell(leaf gjson.Result) string {
+	switch leaf.Type {
+	case gjson.String:
+		return leaf.String()
+	case gjson.Null:
+		return ""
+	default:
+		return compactRaw(leaf)
+	}
+}
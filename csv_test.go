@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertToCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		delimiter string
+		keepOrder bool
+		want      string
+	}{
+		{"RFC 4180 quoting", `[{"name": "a,b", "q": "say \"hi\"", "n": "x\ny"}]`, "", true,
+			"name,q,n\n\"a,b\",\"say \"\"hi\"\"\",\"x\ny\"\n"},
+		{"nested objects and arrays in document order", `[{"b": 1, "a": {"c": true, "d": null}}, {"tags": ["x", "y"], "b": 2}]`, "", true,
+			"b,a.c,a.d,tags.0,tags.1\n1,true,,,\n2,,,x,y\n"},
+		{"sorted columns", `[{"b": 1, "a": {"c": true, "d": null}}, {"tags": ["x", "y"], "b": 2}]`, "", false,
+			"a.c,a.d,b,tags.0,tags.1\ntrue,,1,,\n,,2,x,y\n"},
+		{"single object", `{"a": 1.50}`, "", true, "a\n1.50\n"},
+		{"empty containers", `[{"a": 1}, {"b": [], "c": {}}]`, "", true, "a,b,c\n1,,\n,[],{}\n"},
+		{"tab delimiter", `[{"a": "x\ty", "b": 1}]`, "\\t", true, "a\tb\n\"x\ty\"\t1\n"},
+		{"custom delimiter", `[{"a": "x;y", "b": "p,q"}]`, ";", true, "a;b\n\"x;y\";p,q\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToCSV(tt.input, tt.delimiter, tt.keepOrder)
+			if !resp.Success {
+				t.Fatalf("ConvertToCSV failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToCSV = %q, want %q", resp.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertToCSVRepairsAndRejects(t *testing.T) {
+	app := NewApp()
+	resp := app.ConvertToCSV(`[{a: 1,}, {a: 2}`, "", true)
+	if !resp.Success || !resp.Repaired || resp.Data != "a\n1\n2\n" {
+		t.Errorf("ConvertToCSV of invalid input = %+v, want repaired rows", resp)
+	}
+
+	errorCases := []struct {
+		input     string
+		delimiter string
+		want      string
+	}{
+		{`[{"a": 1}, 2]`, "", "$[1]"},
+		{`"s"`, "", "对象或对象数组"},
+		{`[{"a": 1}]`, "ab", "无效的分隔符"},
+		{`[{"a": 1}]`, `"`, "无效的分隔符"},
+	}
+	for _, tt := range errorCases {
+		if resp := app.ConvertToCSV(tt.input, tt.delimiter, true); resp.Success || !strings.Contains(resp.Error, tt.want) {
+			t.Errorf("ConvertToCSV(%q, %q) = %+v, want an error mentioning %s", tt.input, tt.delimiter, resp, tt.want)
+		}
+	}
+}
+
+func TestToLongCSV(t *testing.T) {
+	nested := `{"a": {"b": {"c": [1, {"d": "x,y"}], "e": null}}, "f": true, "g": "say \"hi\"", "h": [], "i": {}, "j": 1.50}`
+	tests := []struct {
+		name        string
+		input       string
+		includeType bool
+		want        string
+	}{
+		{"deeply nested document", nested, false,
+			"path,value\na.b.c.0,1\na.b.c.1.d,\"x,y\"\na.b.e,\nf,true\ng,\"say \"\"hi\"\"\"\nh,[]\ni,{}\nj,1.50\n"},
+		{"type column", nested, true,
+			"path,value,type\na.b.c.0,1,number\na.b.c.1.d,\"x,y\",string\na.b.e,,null\nf,true,boolean\ng,\"say \"\"hi\"\"\",string\nh,[],array\ni,{},object\nj,1.50,number\n"},
+		{"root array", `[[1], {"a": 2}]`, false, "path,value\n0.0,1\n1.a,2\n"},
+		{"root scalar", `"x"`, true, "path,value,type\n$,x,string\n"},
+		{"empty root array", `[]`, false, "path,value\n$,[]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ToLongCSV(tt.input, tt.includeType)
+			if !resp.Success {
+				t.Fatalf("ToLongCSV failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ToLongCSV = %q, want %q", resp.Data, tt.want)
+			}
+		})
+	}
+
+	if resp := NewApp().ToLongCSV(`{a: 1`, false); !resp.Success || !resp.Repaired || resp.Data != "path,value\na,1\n" {
+		t.Errorf("ToLongCSV of invalid input = %+v, want the repaired document", resp)
+	}
+	if resp := NewApp().ToLongCSV("  ", false); resp.Success {
+		t.Errorf("ToLongCSV of blank input = %+v, want an error", resp)
+	}
+}
@@ -0,0 +1,572 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// DiffHunk describes one aligned change between an original and a repaired text.
+// Offsets and lengths are counted in runes to match Monaco.
+type DiffHunk struct {
+	Type           string `json:"type"` // "insert", "delete" or "replace"
+	OriginalOffset int    `json:"originalOffset"`
+	OriginalLength int    `json:"originalLength"`
+	RepairedOffset int    `json:"repairedOffset"`
+	RepairedLength int    `json:"repairedLength"`
+	OriginalText   string `json:"originalText"`
+	RepairedText   string `json:"repairedText"`
+}
+
+// RepairDiff is the payload returned by RepairDiffView
+type RepairDiff struct {
+	Original string     `json:"original"`
+	Repaired string     `json:"repaired"`
+	Hunks    []DiffHunk `json:"hunks"`
+}
+
+// RepairDiffView repairs the input and returns the original text, the repaired text and
+// the aligned hunks between them, ready for a two-pane diff view.
+func (a *App) RepairDiffView(input string) JSONResponse {
+	repaired := input
+	if !gjson.Valid(input) {
+		var err error
+		repaired, err = JSONRepair(input, false)
+		if err != nil {
+			return JSONResponse{Success: false, Error: "无法解析 JSON: " + err.Error()}
+		}
+	}
+
+	result := RepairDiff{
+		Original: input,
+		Repaired: repaired,
+		Hunks:    diffHunks([]rune(input), []rune(repaired)),
+	}
+	data, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	return JSONResponse{Success: true, Data: string(data), Repaired: repaired != input}
+}
+
+// DiffEntry is one structural difference between two documents. Type is "added", "removed",
+// "changed" or "typeChanged"; values are raw JSON and omitted when absent on that side.
+type DiffEntry struct {
+	Path     string          `json:"path"`
+	Type     string          `json:"type"`
+	OldValue json.RawMessage `json:"oldValue,omitempty"`
+	NewValue json.RawMessage `json:"newValue,omitempty"`
+
+	// tokens is Path split into object keys and array indices, for the patch renderers
+	tokens []diffToken
+}
+
+// diffToken is one step of a DiffEntry path: an object key, or an array index when index is set
+type diffToken struct {
+	name  string
+	index bool
+}
+
+// DiffJSON compares two documents structurally and returns the added, removed and changed
+// paths as a JSON array of DiffEntry, e.g. $.items[2].price. Invalid inputs are repaired first.
+// Key order never counts as a change; keepOrder lists object members in document order
+// instead of sorted by key. Arrays are compared index by index, so elements beyond the shorter
+// array are reported as added or removed. Numbers are compared by value (1.0 equals 1) and
+// strings after unescaping.
+func (a *App) DiffJSON(left string, right string, keepOrder bool) JSONResponse {
+	return a.DiffJSONWithFormat(left, right, keepOrder, "entries")
+}
+
+// DiffJSONWithFormat computes the same differences as DiffJSON and renders them as format:
+//   - "entries": the DiffEntry array returned by DiffJSON
+//   - "json-patch": an RFC 6902 JSON Patch turning left into right
+//   - "merge-patch": an RFC 7386 JSON Merge Patch. Merge patches cannot address array elements
+//     or set a member to null, so a change inside an array replaces the whole array and a member
+//     that becomes null is written as null, which removes it when applied.
+//   - "text": unified-style lines, "- path: old" and "+ path: new"
+func (a *App) DiffJSONWithFormat(left string, right string, keepOrder bool, format string) JSONResponse {
+	validLeft, err := a.validJSON(left)
+	if err != nil {
+		return JSONResponse{Success: false, Error: "左侧 JSON: " + err.Error()}
+	}
+	validRight, err := a.validJSON(right)
+	if err != nil {
+		return JSONResponse{Success: false, Error: "右侧 JSON: " + err.Error()}
+	}
+
+	entries := []DiffEntry{}
+	after := gjson.Parse(validRight)
+	diffValues(gjson.Parse(validLeft), after, "$", nil, keepOrder, &entries)
+
+	var resp JSONResponse
+	switch format {
+	case "", "entries":
+		data, err := json.MarshalIndent(entries, "", "    ")
+		if err != nil {
+			return JSONResponse{Success: false, Error: err.Error()}
+		}
+		resp = JSONResponse{Success: true, Data: string(data)}
+	case "json-patch":
+		resp = indentedResponse(jsonPatch(entries))
+	case "merge-patch":
+		resp = indentedResponse(mergePatch(entries, after))
+	case "text":
+		resp = JSONResponse{Success: true, Data: diffText(entries)}
+	default:
+		return JSONResponse{Success: false, Error: "不支持的差异格式: " + format}
+	}
+	resp.Repaired = validLeft != left || validRight != right
+	return resp
+}
+
+// diffValues appends the differences between before and after at path to entries. tokens is
+// path split into keys and indices.
+func diffValues(before gjson.Result, after gjson.Result, path string, tokens []diffToken, keepOrder bool, entries *[]DiffEntry) {
+	entry := func(path string, tokens []diffToken, typ string, oldValue, newValue *gjson.Result) DiffEntry {
+		e := DiffEntry{Path: path, Type: typ, tokens: tokens}
+		if oldValue != nil {
+			e.OldValue = json.RawMessage(compactRaw(*oldValue))
+		}
+		if newValue != nil {
+			e.NewValue = json.RawMessage(compactRaw(*newValue))
+		}
+		return e
+	}
+	child := func(name string, index bool) []diffToken {
+		return append(append([]diffToken(nil), tokens...), diffToken{name: name, index: index})
+	}
+
+	beforeType, afterType := jsonTypeName(before), jsonTypeName(after)
+	switch {
+	case beforeType != afterType:
+		*entries = append(*entries, entry(path, tokens, "typeChanged", &before, &after))
+	case before.IsObject():
+		oldMembers, oldKeys := objectMembers(before)
+		newMembers, newKeys := objectMembers(after)
+		keys := oldKeys
+		for _, key := range newKeys {
+			if _, ok := oldMembers[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		if !keepOrder {
+			sort.Strings(keys)
+		}
+		for _, key := range keys {
+			oldValue, inOld := oldMembers[key]
+			newValue, inNew := newMembers[key]
+			childPath := childJSONPath(path, key)
+			switch {
+			case !inNew:
+				*entries = append(*entries, entry(childPath, child(key, false), "removed", &oldValue, nil))
+			case !inOld:
+				*entries = append(*entries, entry(childPath, child(key, false), "added", nil, &newValue))
+			default:
+				diffValues(oldValue, newValue, childPath, child(key, false), keepOrder, entries)
+			}
+		}
+	case before.IsArray():
+		oldElements, newElements := before.Array(), after.Array()
+		for idx := 0; idx < len(oldElements) || idx < len(newElements); idx++ {
+			childPath := path + "[" + strconv.Itoa(idx) + "]"
+			childTokens := child(strconv.Itoa(idx), true)
+			switch {
+			case idx >= len(newElements):
+				*entries = append(*entries, entry(childPath, childTokens, "removed", &oldElements[idx], nil))
+			case idx >= len(oldElements):
+				*entries = append(*entries, entry(childPath, childTokens, "added", nil, &newElements[idx]))
+			default:
+				diffValues(oldElements[idx], newElements[idx], childPath, childTokens, keepOrder, entries)
+			}
+		}
+	case !scalarsEqual(before, after):
+		*entries = append(*entries, entry(path, tokens, "changed", &before, &after))
+	}
+}
+
+// jsonPointer returns tokens as an RFC 6901 JSON Pointer
+func jsonPointer(tokens []diffToken) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteString("/")
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(token.name, "~", "~0"), "/", "~1"))
+	}
+	return builder.String()
+}
+
+// jsonPatch renders entries as a compact RFC 6902 JSON Patch. Elements removed from the end of
+// an array are removed last index first, so each operation still finds its target.
+func jsonPatch(entries []DiffEntry) string {
+	var ops []string
+	for idx := 0; idx < len(entries); idx++ {
+		e := entries[idx]
+		pointer := canonicalString(jsonPointer(e.tokens))
+		switch e.Type {
+		case "added":
+			ops = append(ops, `{"op":"add","path":`+pointer+`,"value":`+string(e.NewValue)+`}`)
+		case "removed":
+			run := idx
+			for run+1 < len(entries) && isArrayTailRemoval(e, entries[run+1]) {
+				run++
+			}
+			for k := run; k >= idx; k-- {
+				ops = append(ops, `{"op":"remove","path":`+canonicalString(jsonPointer(entries[k].tokens))+`}`)
+			}
+			idx = run
+		default:
+			ops = append(ops, `{"op":"replace","path":`+pointer+`,"value":`+string(e.NewValue)+`}`)
+		}
+	}
+	return "[" + strings.Join(ops, ",") + "]"
+}
+
+// isArrayTailRemoval reports whether next removes another element of the same array as first.
+func isArrayTailRemoval(first DiffEntry, next DiffEntry) bool {
+	n := len(first.tokens)
+	if next.Type != "removed" || n == 0 || len(next.tokens) != n || !first.tokens[n-1].index {
+		return false
+	}
+	return jsonPointer(first.tokens[:n-1]) == jsonPointer(next.tokens[:n-1])
+}
+
+// mergePatchNode is an object of a merge patch under construction, with keys in insertion order.
+// value is set for a leaf.
+type mergePatchNode struct {
+	keys     []string
+	children map[string]*mergePatchNode
+	value    string
+}
+
+// mergePatch renders entries as a compact RFC 7386 JSON Merge Patch. Arrays are replaced as a
+// whole, so a changed array is written with its full value from after.
+func mergePatch(entries []DiffEntry, after gjson.Result) string {
+	if len(entries) == 0 {
+		return "{}"
+	}
+	root := &mergePatchNode{}
+	for _, e := range entries {
+		// Merge patches only descend into objects: stop at the first array and replace it
+		tokens := e.tokens
+		value := string(e.NewValue)
+		if e.Type == "removed" {
+			value = "null"
+		}
+		for depth, token := range e.tokens {
+			if token.index {
+				tokens = e.tokens[:depth]
+				value = compactRaw(lookupDiffTokens(after, tokens))
+				break
+			}
+		}
+		if len(tokens) == 0 {
+			// The root itself is replaced
+			return value
+		}
+		node := root
+		for depth, token := range tokens {
+			if node.value != "" {
+				break
+			}
+			if node.children == nil {
+				node.children = make(map[string]*mergePatchNode)
+			}
+			next, ok := node.children[token.name]
+			if !ok {
+				next = &mergePatchNode{}
+				node.children[token.name] = next
+				node.keys = append(node.keys, token.name)
+			}
+			if depth == len(tokens)-1 {
+				next.value = value
+			}
+			node = next
+		}
+	}
+	return root.render()
+}
+
+// render writes the merge patch node as compact JSON
+func (node *mergePatchNode) render() string {
+	if node.value != "" {
+		return node.value
+	}
+	members := make([]string, len(node.keys))
+	for idx, key := range node.keys {
+		members[idx] = canonicalString(key) + ":" + node.children[key].render()
+	}
+	return "{" + strings.Join(members, ",") + "}"
+}
+
+// lookupDiffTokens returns the value of doc at tokens
+func lookupDiffTokens(doc gjson.Result, tokens []diffToken) gjson.Result {
+	for _, token := range tokens {
+		if token.index {
+			idx, _ := strconv.Atoi(token.name)
+			doc = doc.Array()[idx]
+			continue
+		}
+		members, _ := objectMembers(doc)
+		doc = members[token.name]
+	}
+	return doc
+}
+
+// diffText renders entries as unified-style text: removed values on "-" lines, added values on
+// "+" lines and changed values as a "-" line followed by a "+" line
+func diffText(entries []DiffEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("--- left\n+++ right\n")
+	for _, e := range entries {
+		if e.OldValue != nil {
+			builder.WriteString("- " + e.Path + ": " + string(e.OldValue) + "\n")
+		}
+		if e.NewValue != nil {
+			builder.WriteString("+ " + e.Path + ": " + string(e.NewValue) + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// objectMembers returns the members of obj by key together with the keys in document order.
+// A repeated key keeps its last value, like JSON.parse.
+func objectMembers(obj gjson.Result) (map[string]gjson.Result, []string) {
+	members := make(map[string]gjson.Result)
+	var keys []string
+	obj.ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		if _, ok := members[name]; !ok {
+			keys = append(keys, name)
+		}
+		members[name] = value
+		return true
+	})
+	return members, keys
+}
+
+// scalarsEqual compares two scalars of the same type: numbers exactly by value, strings after
+// unescaping
+func scalarsEqual(before gjson.Result, after gjson.Result) bool {
+	switch before.Type {
+	case gjson.Number:
+		oldNumber, okOld := new(big.Rat).SetString(before.Raw)
+		newNumber, okNew := new(big.Rat).SetString(after.Raw)
+		if okOld && okNew {
+			return oldNumber.Cmp(newNumber) == 0
+		}
+		return before.Raw == after.Raw
+	case gjson.String:
+		return before.String() == after.String()
+	default:
+		return before.Type == after.Type
+	}
+}
+
+// diffOp is a single rune-level edit: '=' keep, '-' delete from a, '+' insert from b
+type diffOp byte
+
+// maxDiffEdits bounds the edit distance diffSequence searches for. The trace it keeps to
+// recover the edit script grows with the square of the distance, so a larger diff falls back
+// to a coarser one instead of exhausting memory.
+const maxDiffEdits = 1000
+
+// diffRunes computes the edit script between a and b: the shortest one rune by rune when it
+// needs at most maxDiffEdits edits, otherwise a line-level one, and as a last resort a single
+// replacement of everything between the common prefix and suffix
+func diffRunes(a, b []rune) []diffOp {
+	if ops, ok := diffSequence(a, b, maxDiffEdits); ok {
+		return ops
+	}
+	linesA, linesB := splitLines(a), splitLines(b)
+	if lineOps, ok := diffSequence(linesA, linesB, maxDiffEdits); ok {
+		var ops []diffOp
+		ai, bi := 0, 0
+		for _, op := range lineOps {
+			var line string
+			switch op {
+			case '=':
+				line = linesA[ai]
+				ai++
+				bi++
+			case '-':
+				line = linesA[ai]
+				ai++
+			default:
+				line = linesB[bi]
+				bi++
+			}
+			for range utf8.RuneCountInString(line) {
+				ops = append(ops, op)
+			}
+		}
+		return ops
+	}
+	return replaceMiddle(a, b)
+}
+
+// splitLines splits text into lines, each keeping its trailing newline
+func splitLines(text []rune) []string {
+	var lines []string
+	start := 0
+	for i, r := range text {
+		if r == '\n' {
+			lines = append(lines, string(text[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(text) {
+		lines = append(lines, string(text[start:]))
+	}
+	return lines
+}
+
+// replaceMiddle returns an edit script that keeps the common prefix and suffix of a and b and
+// replaces everything in between
+func replaceMiddle(a, b []rune) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for range prefix {
+		ops = append(ops, '=')
+	}
+	for range len(a) - prefix - suffix {
+		ops = append(ops, '-')
+	}
+	for range len(b) - prefix - suffix {
+		ops = append(ops, '+')
+	}
+	for range suffix {
+		ops = append(ops, '=')
+	}
+	return ops
+}
+
+// diffSequence computes the shortest edit script between a and b using Myers' algorithm. It
+// gives up and returns false once the edit distance exceeds maxEdits.
+func diffSequence[E comparable](a, b []E, maxEdits int) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	done := false
+	for d := 0; d <= maxD && !done; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		// Only the diagonals -d..d are reachable, so snapshot that window
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edit script
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, '=')
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, '+')
+			y--
+		} else {
+			ops = append(ops, '-')
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, '=')
+		x--
+		y--
+	}
+
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops, true
+}
+
+// diffHunks groups consecutive rune edits between a and b into hunks
+func diffHunks(a, b []rune) []DiffHunk {
+	hunks := []DiffHunk{}
+	ops := diffRunes(a, b)
+	ai, bi := 0, 0
+	for idx := 0; idx < len(ops); {
+		if ops[idx] == '=' {
+			ai++
+			bi++
+			idx++
+			continue
+		}
+		hunk := DiffHunk{OriginalOffset: ai, RepairedOffset: bi}
+		for idx < len(ops) && ops[idx] != '=' {
+			if ops[idx] == '-' {
+				ai++
+			} else {
+				bi++
+			}
+			idx++
+		}
+		hunk.OriginalLength = ai - hunk.OriginalOffset
+		hunk.RepairedLength = bi - hunk.RepairedOffset
+		hunk.OriginalText = string(a[hunk.OriginalOffset:ai])
+		hunk.RepairedText = string(b[hunk.RepairedOffset:bi])
+		switch {
+		case hunk.OriginalLength == 0:
+			hunk.Type = "insert"
+		case hunk.RepairedLength == 0:
+			hunk.Type = "delete"
+		default:
+			hunk.Type = "replace"
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
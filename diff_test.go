@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepairDiffView(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []DiffHunk
+	}{
+		{"inserted comma", `{"a": 1 "b": 2}`, []DiffHunk{
+			{Type: "insert", OriginalOffset: 7, RepairedOffset: 7, RepairedLength: 1, RepairedText: ","},
+		}},
+		{"quoted key", `{a: 1}`, []DiffHunk{
+			{Type: "insert", OriginalOffset: 1, RepairedOffset: 1, RepairedLength: 1, RepairedText: `"`},
+			{Type: "insert", OriginalOffset: 2, RepairedOffset: 3, RepairedLength: 1, RepairedText: `"`},
+		}},
+		{"single quotes", `{'a': 1}`, []DiffHunk{
+			{Type: "replace", OriginalOffset: 1, OriginalLength: 1, RepairedOffset: 1, RepairedLength: 1, OriginalText: "'", RepairedText: `"`},
+			{Type: "replace", OriginalOffset: 3, OriginalLength: 1, RepairedOffset: 3, RepairedLength: 1, OriginalText: "'", RepairedText: `"`},
+		}},
+		{"removed trailing comma", `[1, 2,]`, []DiffHunk{
+			{Type: "delete", OriginalOffset: 5, OriginalLength: 1, RepairedOffset: 5, OriginalText: ","},
+		}},
+		{"offsets in runes", `{"é": "ü"`, []DiffHunk{
+			{Type: "insert", OriginalOffset: 9, RepairedOffset: 9, RepairedLength: 1, RepairedText: "}"},
+		}},
+		{"valid input", `{"a": 1}`, []DiffHunk{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().RepairDiffView(tt.input)
+			if !resp.Success {
+				t.Fatalf("RepairDiffView(%s) failed: %s", tt.input, resp.Error)
+			}
+			var got RepairDiff
+			if err := json.Unmarshal([]byte(resp.Data), &got); err != nil {
+				t.Fatalf("RepairDiffView returned invalid JSON: %v", err)
+			}
+			if got.Original != tt.input {
+				t.Errorf("Original = %q, want %q", got.Original, tt.input)
+			}
+			if !reflect.DeepEqual(got.Hunks, tt.want) {
+				t.Errorf("RepairDiffView(%s) hunks = %+v, want %+v (repaired %q)", tt.input, got.Hunks, tt.want, got.Repaired)
+			}
+			if resp.Repaired != (len(tt.want) > 0) {
+				t.Errorf("Repaired = %v", resp.Repaired)
+			}
+		})
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		left      string
+		right     string
+		keepOrder bool
+		want      string
+	}{
+		{"reordered keys", `{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, false, `[]`},
+		{"added, removed, changed and type changes", `{"a": 1, "b": "x", "c": true}`, `{"a": "1", "b": "y", "d": null}`, false,
+			`[{"path":"$.a","type":"typeChanged","oldValue":1,"newValue":"1"},{"path":"$.b","type":"changed","oldValue":"x","newValue":"y"},{"path":"$.c","type":"removed","oldValue":true},{"path":"$.d","type":"added","newValue":null}]`},
+		{"shorter array", `{"items": [1, 2, 3]}`, `{"items": [1, 5]}`, false,
+			`[{"path":"$.items[1]","type":"changed","oldValue":2,"newValue":5},{"path":"$.items[2]","type":"removed","oldValue":3}]`},
+		{"longer array", `{"items": [1]}`, `{"items": [1, {"k": 2}]}`, false,
+			`[{"path":"$.items[1]","type":"added","newValue":{"k":2}}]`},
+		{"numbers by value", `{"n": 1.0, "s": "A"}`, `{"n": 1, "s": "A"}`, false, `[]`},
+		{"sorted paths", `{"z": 1, "y": 1}`, `{"y": 2, "z": 2}`, false,
+			`[{"path":"$.y","type":"changed","oldValue":1,"newValue":2},{"path":"$.z","type":"changed","oldValue":1,"newValue":2}]`},
+		{"document order", `{"z": 1, "y": 1}`, `{"y": 2, "z": 2}`, true,
+			`[{"path":"$.z","type":"changed","oldValue":1,"newValue":2},{"path":"$.y","type":"changed","oldValue":1,"newValue":2}]`},
+		{"root type change", `[1]`, `{"a": 1}`, false, `[{"path":"$","type":"typeChanged","oldValue":[1],"newValue":{"a":1}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().DiffJSON(tt.left, tt.right, tt.keepOrder)
+			if !resp.Success {
+				t.Fatalf("DiffJSON failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("DiffJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	resp := NewApp().DiffJSON(`{a: 1, b: [1,]}`, `{"a": 2, "b": [1]}`, false)
+	if !resp.Success || !resp.Repaired || compactJSON(t, resp.Data) != `[{"path":"$.a","type":"changed","oldValue":1,"newValue":2}]` {
+		t.Errorf("DiffJSON of repaired input = %+v", resp)
+	}
+}
+
+func TestDiffJSONWithFormat(t *testing.T) {
+	left := `{"a": 1, "b": {"c": "x", "d": [1, 2]}, "e/f": true, "g~h": 0}`
+	right := `{"a": 2, "b": {"c": "x", "d": [1, 3]}, "g~h": null, "n": {"k": 1}}`
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"entries", `[{"path":"$.a","type":"changed","oldValue":1,"newValue":2},{"path":"$.b.d[1]","type":"changed","oldValue":2,"newValue":3},{"path":"$[\"e/f\"]","type":"removed","oldValue":true},{"path":"$[\"g~h\"]","type":"typeChanged","oldValue":0,"newValue":null},{"path":"$.n","type":"added","newValue":{"k":1}}]`},
+		{"json-patch", `[{"op":"replace","path":"/a","value":2},{"op":"replace","path":"/b/d/1","value":3},{"op":"remove","path":"/e~1f"},{"op":"replace","path":"/g~0h","value":null},{"op":"add","path":"/n","value":{"k":1}}]`},
+		{"merge-patch", `{"a":2,"b":{"d":[1,3]},"e/f":null,"g~h":null,"n":{"k":1}}`},
+		{"text", "--- left\n+++ right\n- $.a: 1\n+ $.a: 2\n- $.b.d[1]: 2\n+ $.b.d[1]: 3\n- $[\"e/f\"]: true\n- $[\"g~h\"]: 0\n+ $[\"g~h\"]: null\n+ $.n: {\"k\":1}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			resp := NewApp().DiffJSONWithFormat(left, right, true, tt.format)
+			if !resp.Success {
+				t.Fatalf("DiffJSONWithFormat failed: %s", resp.Error)
+			}
+			got := strings.TrimSpace(resp.Data)
+			if tt.format != "text" {
+				got = compactJSON(t, resp.Data)
+			}
+			if got != tt.want {
+				t.Errorf("DiffJSONWithFormat = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	app := NewApp()
+	if resp := app.DiffJSONWithFormat(`{"a": 1}`, `{"a": 1}`, false, "yaml"); resp.Success {
+		t.Errorf("DiffJSONWithFormat with an unknown format = %+v, want an error", resp)
+	}
+	if resp := app.DiffJSONWithFormat(`[1]`, `{"a": 1}`, false, "merge-patch"); !resp.Success || compactJSON(t, resp.Data) != `{"a":1}` {
+		t.Errorf("merge patch replacing the root = %+v", resp)
+	}
+	if resp := app.DiffJSONWithFormat(`{a: 1}`, `{"a": 1}`, false, "text"); !resp.Success || resp.Data != "" || !resp.Repaired {
+		t.Errorf("text diff of a repaired, equal document = %+v", resp)
+	}
+}
+
+func TestRepairDiffViewLargeInput(t *testing.T) {
+	// Quoting every element takes far more edits than maxDiffEdits, so the diff falls back to
+	// coarser hunks instead of keeping a trace quadratic in the edit distance
+	tests := []struct {
+		name     string
+		original string
+		repaired string
+	}{
+		{"single line", "[" + strings.Repeat("'x',", 20000) + "]", "[" + strings.Repeat(`"x",`, 19999) + `"x"]`},
+		{"many lines", "[\n" + strings.Repeat("'x',\n", 20000) + "]", "[\n" + strings.Repeat(`"x",`+"\n", 19999) + `"x"` + "\n]"},
+		{"few edits", "[" + strings.Repeat(`"x",`, 20000) + "]", "[" + strings.Repeat(`"x",`, 19999) + `"x"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := []rune(tt.original)
+			hunks := diffHunks(original, []rune(tt.repaired))
+			if len(hunks) == 0 {
+				t.Fatalf("no hunks for %d runes", len(original))
+			}
+			// Applying the hunks to the original must give the repaired text
+			var rebuilt strings.Builder
+			at := 0
+			for _, hunk := range hunks {
+				rebuilt.WriteString(string(original[at:hunk.OriginalOffset]))
+				rebuilt.WriteString(hunk.RepairedText)
+				at = hunk.OriginalOffset + hunk.OriginalLength
+			}
+			rebuilt.WriteString(string(original[at:]))
+			if rebuilt.String() != tt.repaired {
+				t.Errorf("hunks do not turn the original into the repaired text")
+			}
+		})
+	}
+
+	// The same goes through RepairDiffView
+	input := "[" + strings.Repeat("'x',", 2000) + "]"
+	resp := NewApp().RepairDiffView(input)
+	var got RepairDiff
+	if !resp.Success || json.Unmarshal([]byte(resp.Data), &got) != nil || len(got.Hunks) == 0 {
+		t.Fatalf("RepairDiffView of a long array gave no hunks: %s", resp.Error)
+	}
+}
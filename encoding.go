@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Byte order marks used to detect UTF-16 and UTF-32 encoded files
+var (
+	bomUTF32LE = []byte{0xff, 0xfe, 0x00, 0x00}
+	bomUTF32BE = []byte{0x00, 0x00, 0xfe, 0xff}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// transcodeBOM converts UTF-16 or UTF-32 content that starts with a byte order mark to UTF-8.
+// Content without such a BOM is returned unchanged. The second result names the detected encoding.
+func transcodeBOM(content []byte) (string, string) {
+	switch {
+	// UTF-32 LE must be checked before UTF-16 LE since their BOMs share a prefix
+	case bytes.HasPrefix(content, bomUTF32LE):
+		return decodeUTF32(content[4:], binary.LittleEndian), "utf-32le"
+	case bytes.HasPrefix(content, bomUTF32BE):
+		return decodeUTF32(content[4:], binary.BigEndian), "utf-32be"
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return decodeUTF16(content[2:], binary.LittleEndian), "utf-16le"
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return decodeUTF16(content[2:], binary.BigEndian), "utf-16be"
+	}
+	return string(content), "utf-8"
+}
+
+// decodeUTF16 decodes UTF-16 code units, combining surrogate pairs. A trailing odd byte is dropped.
+func decodeUTF16(content []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(content)/2)
+	for idx := range units {
+		units[idx] = order.Uint16(content[idx*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeUTF32 decodes UTF-32 code points; invalid code points become U+FFFD
+func decodeUTF32(content []byte, order binary.ByteOrder) string {
+	runes := make([]rune, len(content)/4)
+	for idx := range runes {
+		runes[idx] = rune(order.Uint32(content[idx*4:]))
+	}
+	return string(runes)
+}
+
+// fileEncodings maps the encoding names accepted by ReadFile/SaveFile to their codecs.
+// UTF-8 is handled separately since it needs no transcoding.
+var fileEncodings = map[string]encoding.Encoding{
+	"utf-16le": unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"utf-16be": unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"latin1":   charmap.ISO8859_1,
+	"gbk":      simplifiedchinese.GBK,
+}
+
+// lookupEncoding normalizes an encoding name. An empty name means UTF-8, which yields a nil codec.
+func lookupEncoding(name string) (string, encoding.Encoding, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "", "utf-8", "utf8":
+		return "utf-8", nil, nil
+	case "iso-8859-1":
+		name = "latin1"
+	}
+	enc, ok := fileEncodings[name]
+	if !ok {
+		return "", nil, errors.New("不支持的编码: " + name)
+	}
+	return name, enc, nil
+}
+
+// decodeWithEncoding converts file content in the given encoding to UTF-8.
+// Bytes the codec cannot map decode to U+FFFD, which is reported as an error.
+func decodeWithEncoding(content []byte, enc encoding.Encoding, name string) (string, error) {
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", errors.New("无法按 " + name + " 编码解码文件: " + err.Error())
+	}
+	if bytes.ContainsRune(decoded, utf8.RuneError) {
+		return "", errors.New("文件内容不是有效的 " + name + " 编码")
+	}
+	return string(decoded), nil
+}
+
+// encodeWithEncoding converts UTF-8 text to the given encoding for writing to disk
+func encodeWithEncoding(text string, enc encoding.Encoding, name string) ([]byte, error) {
+	if enc == nil {
+		return []byte(text), nil
+	}
+	encoded, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, errors.New("内容包含无法用 " + name + " 编码表示的字符")
+	}
+	return encoded, nil
+}
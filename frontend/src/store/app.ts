@@ -9,7 +9,7 @@ export interface Tab {
   isDirty: boolean
   isPinned: boolean
   formatOptions: {
-    indent: '2' | '4' | 'tab'
+    indent: '2' | '3' | '4' | '8' | 'tab'
     quotes: 'double' | 'single'
     trimWhitespace: boolean
     keepOrder: boolean
@@ -2,34 +2,138 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function Aggregate(arg1:string,arg2:string,arg3:Array<main.Aggregation>):Promise<main.JSONResponse>;
+
+export function AnonymizeConsistent(arg1:string,arg2:Array<string>,arg3:string):Promise<main.JSONResponse>;
+
+export function ApplyDefaults(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function CheckArrayHomogeneity(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function CollapseSingleKeyWrappers(arg1:string,arg2:Array<string>):Promise<main.JSONResponse>;
+
+export function ComplexityReport(arg1:string):Promise<main.JSONResponse>;
+
+export function ConvertToCSV(arg1:string,arg2:string,arg3:boolean):Promise<main.JSONResponse>;
+
 export function ConvertToCSharpClass(arg1:string,arg2:boolean,arg3:boolean,arg4:string):Promise<main.JSONResponse>;
 
+export function ConvertToCSharpClassOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function ConvertToCStruct(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function ConvertToCStructOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function ConvertToGoLiteral(arg1:string):Promise<main.JSONResponse>;
+
 export function ConvertToGoStruct(arg1:string,arg2:boolean,arg3:boolean,arg4:string):Promise<main.JSONResponse>;
 
+export function ConvertToGoStructOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
 export function ConvertToJavaClass(arg1:string,arg2:boolean,arg3:boolean,arg4:string):Promise<main.JSONResponse>;
 
+export function ConvertToJavaClassOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function ConvertToMermaid(arg1:string):Promise<main.JSONResponse>;
+
 export function ConvertToPythonClass(arg1:string,arg2:boolean,arg3:boolean,arg4:string):Promise<main.JSONResponse>;
 
-export function ConvertToSQL(arg1:string,arg2:boolean,arg3:boolean,arg4:string,arg5:string):Promise<main.JSONResponse>;
+export function ConvertToPythonClassOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function ConvertToPythonLiteral(arg1:string):Promise<main.JSONResponse>;
+
+export function ConvertToSQL(arg1:string,arg2:boolean,arg3:boolean,arg4:string,arg5:string,arg6:boolean):Promise<main.JSONResponse>;
+
+export function ConvertToSQLOpts(arg1:string,arg2:string,arg3:string,arg4:boolean,arg5:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function ConvertToTOML(arg1:string,arg2:boolean,arg3:boolean):Promise<main.JSONResponse>;
+
+export function ConvertToTOMLOpts(arg1:string,arg2:main.ConvertOptions):Promise<main.JSONResponse>;
 
 export function ConvertToTypeScriptInterface(arg1:string,arg2:boolean,arg3:boolean,arg4:string):Promise<main.JSONResponse>;
 
+export function ConvertToTypeScriptInterfaceOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
 export function ConvertToYAML(arg1:string,arg2:boolean,arg3:boolean):Promise<main.JSONResponse>;
 
+export function ConvertToYAMLOpts(arg1:string,arg2:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function ConvertToZodSchema(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function ConvertToZodSchemaOpts(arg1:string,arg2:string,arg3:main.ConvertOptions):Promise<main.JSONResponse>;
+
+export function DetectIndent(arg1:string):Promise<main.JSONResponse>;
+
+export function DiffJSON(arg1:string,arg2:string,arg3:boolean):Promise<main.JSONResponse>;
+
+export function DiffJSONWithFormat(arg1:string,arg2:string,arg3:boolean,arg4:string):Promise<main.JSONResponse>;
+
+export function EscapeNonASCII(arg1:string):Promise<main.JSONResponse>;
+
+export function ExpandDottedKeys(arg1:string,arg2:string,arg3:boolean):Promise<main.JSONResponse>;
+
+export function ExportArrayElements(arg1:string,arg2:string,arg3:string):Promise<main.JSONResponse>;
+
+export function FieldPresenceReport(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function FlattenDottedKeys(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
 export function FormatJSON(arg1:string,arg2:string,arg3:boolean,arg4:boolean):Promise<main.JSONResponse>;
 
+export function FormatJSONOpts(arg1:string,arg2:main.FormatOptions):Promise<main.JSONResponse>;
+
+export function FormatJSONWithBraceStyle(arg1:string,arg2:string,arg3:boolean,arg4:boolean,arg5:string):Promise<main.JSONResponse>;
+
+export function FromSortedFlatLines(arg1:string):Promise<main.JSONResponse>;
+
+export function FullyCanonicalize(arg1:string):Promise<main.JSONResponse>;
+
+export function GenerateGraphQLQuery(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
 export function GetPathByOffset(arg1:string,arg2:number):Promise<string>;
 
 export function GetPathOffset(arg1:string,arg2:string):Promise<main.PathInfo>;
 
+export function InferSchemaWithExamples(arg1:string):Promise<main.JSONResponse>;
+
+export function IntegerizeWhereLossless(arg1:string):Promise<main.JSONResponse>;
+
+export function KeyTypeSummary(arg1:string):Promise<main.JSONResponse>;
+
 export function MinifyJSON(arg1:string,arg2:boolean,arg3:boolean):Promise<main.JSONResponse>;
 
+export function MinifyJSONOpts(arg1:string,arg2:main.FormatOptions):Promise<main.JSONResponse>;
+
+export function NormalizeToArrays(arg1:string,arg2:Array<string>,arg3:boolean):Promise<main.JSONResponse>;
+
 export function ProcessJSON(arg1:string,arg2:string,arg3:boolean,arg4:boolean):Promise<main.JSONResponse>;
 
-export function ReadFile(arg1:string):Promise<main.JSONResponse>;
+export function ProcessJSONOpts(arg1:string,arg2:main.FormatOptions):Promise<main.JSONResponse>;
+
+export function QueryJSON(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function ReadFile(arg1:string,arg2:string,arg3:boolean):Promise<main.JSONResponse>;
 
 export function RegisterAsDefaultEditor():Promise<main.JSONResponse>;
 
-export function SaveFile(arg1:string,arg2:string):Promise<main.JSONResponse>;
+export function RepairDiffView(arg1:string):Promise<main.JSONResponse>;
+
+export function RepairJSONSeq(arg1:string,arg2:boolean):Promise<main.JSONResponse>;
+
+export function RepairMarkdownBlocks(arg1:string,arg2:boolean):Promise<main.JSONResponse>;
+
+export function RepairStrict(arg1:string):Promise<main.JSONResponse>;
+
+export function RestoreKeys(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function SaveFile(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<main.JSONResponse>;
+
+export function ToLabeledEntries(arg1:string,arg2:string):Promise<main.JSONResponse>;
+
+export function ToLongCSV(arg1:string,arg2:boolean):Promise<main.JSONResponse>;
+
+export function ToSortedFlatLines(arg1:string):Promise<main.JSONResponse>;
+
+export function TransformKeys(arg1:string,arg2:string,arg3:boolean):Promise<main.JSONResponse>;
 
-export function WriteFileDirect(arg1:string,arg2:string):Promise<main.JSONResponse>;
+export function WriteFileDirect(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<main.JSONResponse>;
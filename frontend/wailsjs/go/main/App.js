@@ -2,38 +2,182 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function Aggregate(arg1, arg2, arg3) {
+  return window['go']['main']['App']['Aggregate'](arg1, arg2, arg3);
+}
+
+export function AnonymizeConsistent(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AnonymizeConsistent'](arg1, arg2, arg3);
+}
+
+export function ApplyDefaults(arg1, arg2) {
+  return window['go']['main']['App']['ApplyDefaults'](arg1, arg2);
+}
+
+export function CheckArrayHomogeneity(arg1, arg2) {
+  return window['go']['main']['App']['CheckArrayHomogeneity'](arg1, arg2);
+}
+
+export function CollapseSingleKeyWrappers(arg1, arg2) {
+  return window['go']['main']['App']['CollapseSingleKeyWrappers'](arg1, arg2);
+}
+
+export function ComplexityReport(arg1) {
+  return window['go']['main']['App']['ComplexityReport'](arg1);
+}
+
+export function ConvertToCSV(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToCSV'](arg1, arg2, arg3);
+}
+
 export function ConvertToCSharpClass(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConvertToCSharpClass'](arg1, arg2, arg3, arg4);
 }
 
+export function ConvertToCSharpClassOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToCSharpClassOpts'](arg1, arg2, arg3);
+}
+
+export function ConvertToCStruct(arg1, arg2) {
+  return window['go']['main']['App']['ConvertToCStruct'](arg1, arg2);
+}
+
+export function ConvertToCStructOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToCStructOpts'](arg1, arg2, arg3);
+}
+
+export function ConvertToGoLiteral(arg1) {
+  return window['go']['main']['App']['ConvertToGoLiteral'](arg1);
+}
+
 export function ConvertToGoStruct(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConvertToGoStruct'](arg1, arg2, arg3, arg4);
 }
 
+export function ConvertToGoStructOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToGoStructOpts'](arg1, arg2, arg3);
+}
+
 export function ConvertToJavaClass(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConvertToJavaClass'](arg1, arg2, arg3, arg4);
 }
 
+export function ConvertToJavaClassOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToJavaClassOpts'](arg1, arg2, arg3);
+}
+
+export function ConvertToMermaid(arg1) {
+  return window['go']['main']['App']['ConvertToMermaid'](arg1);
+}
+
 export function ConvertToPythonClass(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConvertToPythonClass'](arg1, arg2, arg3, arg4);
 }
 
-export function ConvertToSQL(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['ConvertToSQL'](arg1, arg2, arg3, arg4, arg5);
+export function ConvertToPythonClassOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToPythonClassOpts'](arg1, arg2, arg3);
+}
+
+export function ConvertToPythonLiteral(arg1) {
+  return window['go']['main']['App']['ConvertToPythonLiteral'](arg1);
+}
+
+export function ConvertToSQL(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['ConvertToSQL'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function ConvertToSQLOpts(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['ConvertToSQLOpts'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function ConvertToTOML(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToTOML'](arg1, arg2, arg3);
+}
+
+export function ConvertToTOMLOpts(arg1, arg2) {
+  return window['go']['main']['App']['ConvertToTOMLOpts'](arg1, arg2);
 }
 
 export function ConvertToTypeScriptInterface(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConvertToTypeScriptInterface'](arg1, arg2, arg3, arg4);
 }
 
+export function ConvertToTypeScriptInterfaceOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToTypeScriptInterfaceOpts'](arg1, arg2, arg3);
+}
+
 export function ConvertToYAML(arg1, arg2, arg3) {
   return window['go']['main']['App']['ConvertToYAML'](arg1, arg2, arg3);
 }
 
+export function ConvertToYAMLOpts(arg1, arg2) {
+  return window['go']['main']['App']['ConvertToYAMLOpts'](arg1, arg2);
+}
+
+export function ConvertToZodSchema(arg1, arg2) {
+  return window['go']['main']['App']['ConvertToZodSchema'](arg1, arg2);
+}
+
+export function ConvertToZodSchemaOpts(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ConvertToZodSchemaOpts'](arg1, arg2, arg3);
+}
+
+export function DetectIndent(arg1) {
+  return window['go']['main']['App']['DetectIndent'](arg1);
+}
+
+export function DiffJSON(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffJSON'](arg1, arg2, arg3);
+}
+
+export function DiffJSONWithFormat(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['DiffJSONWithFormat'](arg1, arg2, arg3, arg4);
+}
+
+export function EscapeNonASCII(arg1) {
+  return window['go']['main']['App']['EscapeNonASCII'](arg1);
+}
+
+export function ExpandDottedKeys(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExpandDottedKeys'](arg1, arg2, arg3);
+}
+
+export function ExportArrayElements(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportArrayElements'](arg1, arg2, arg3);
+}
+
+export function FieldPresenceReport(arg1, arg2) {
+  return window['go']['main']['App']['FieldPresenceReport'](arg1, arg2);
+}
+
+export function FlattenDottedKeys(arg1, arg2) {
+  return window['go']['main']['App']['FlattenDottedKeys'](arg1, arg2);
+}
+
 export function FormatJSON(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['FormatJSON'](arg1, arg2, arg3, arg4);
 }
 
+export function FormatJSONOpts(arg1, arg2) {
+  return window['go']['main']['App']['FormatJSONOpts'](arg1, arg2);
+}
+
+export function FormatJSONWithBraceStyle(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['FormatJSONWithBraceStyle'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function FromSortedFlatLines(arg1) {
+  return window['go']['main']['App']['FromSortedFlatLines'](arg1);
+}
+
+export function FullyCanonicalize(arg1) {
+  return window['go']['main']['App']['FullyCanonicalize'](arg1);
+}
+
+export function GenerateGraphQLQuery(arg1, arg2) {
+  return window['go']['main']['App']['GenerateGraphQLQuery'](arg1, arg2);
+}
+
 export function GetPathByOffset(arg1, arg2) {
   return window['go']['main']['App']['GetPathByOffset'](arg1, arg2);
 }
@@ -42,26 +186,90 @@ export function GetPathOffset(arg1, arg2) {
   return window['go']['main']['App']['GetPathOffset'](arg1, arg2);
 }
 
+export function InferSchemaWithExamples(arg1) {
+  return window['go']['main']['App']['InferSchemaWithExamples'](arg1);
+}
+
+export function IntegerizeWhereLossless(arg1) {
+  return window['go']['main']['App']['IntegerizeWhereLossless'](arg1);
+}
+
+export function KeyTypeSummary(arg1) {
+  return window['go']['main']['App']['KeyTypeSummary'](arg1);
+}
+
 export function MinifyJSON(arg1, arg2, arg3) {
   return window['go']['main']['App']['MinifyJSON'](arg1, arg2, arg3);
 }
 
+export function MinifyJSONOpts(arg1, arg2) {
+  return window['go']['main']['App']['MinifyJSONOpts'](arg1, arg2);
+}
+
+export function NormalizeToArrays(arg1, arg2, arg3) {
+  return window['go']['main']['App']['NormalizeToArrays'](arg1, arg2, arg3);
+}
+
 export function ProcessJSON(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ProcessJSON'](arg1, arg2, arg3, arg4);
 }
 
-export function ReadFile(arg1) {
-  return window['go']['main']['App']['ReadFile'](arg1);
+export function ProcessJSONOpts(arg1, arg2) {
+  return window['go']['main']['App']['ProcessJSONOpts'](arg1, arg2);
+}
+
+export function QueryJSON(arg1, arg2) {
+  return window['go']['main']['App']['QueryJSON'](arg1, arg2);
+}
+
+export function ReadFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ReadFile'](arg1, arg2, arg3);
 }
 
 export function RegisterAsDefaultEditor() {
   return window['go']['main']['App']['RegisterAsDefaultEditor']();
 }
 
-export function SaveFile(arg1, arg2) {
-  return window['go']['main']['App']['SaveFile'](arg1, arg2);
+export function RepairDiffView(arg1) {
+  return window['go']['main']['App']['RepairDiffView'](arg1);
+}
+
+export function RepairJSONSeq(arg1, arg2) {
+  return window['go']['main']['App']['RepairJSONSeq'](arg1, arg2);
+}
+
+export function RepairMarkdownBlocks(arg1, arg2) {
+  return window['go']['main']['App']['RepairMarkdownBlocks'](arg1, arg2);
+}
+
+export function RepairStrict(arg1) {
+  return window['go']['main']['App']['RepairStrict'](arg1);
+}
+
+export function RestoreKeys(arg1, arg2) {
+  return window['go']['main']['App']['RestoreKeys'](arg1, arg2);
+}
+
+export function SaveFile(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SaveFile'](arg1, arg2, arg3, arg4);
+}
+
+export function ToLabeledEntries(arg1, arg2) {
+  return window['go']['main']['App']['ToLabeledEntries'](arg1, arg2);
+}
+
+export function ToLongCSV(arg1, arg2) {
+  return window['go']['main']['App']['ToLongCSV'](arg1, arg2);
+}
+
+export function ToSortedFlatLines(arg1) {
+  return window['go']['main']['App']['ToSortedFlatLines'](arg1);
+}
+
+export function TransformKeys(arg1, arg2, arg3) {
+  return window['go']['main']['App']['TransformKeys'](arg1, arg2, arg3);
 }
 
-export function WriteFileDirect(arg1, arg2) {
-  return window['go']['main']['App']['WriteFileDirect'](arg1, arg2);
+export function WriteFileDirect(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['WriteFileDirect'](arg1, arg2, arg3, arg4);
 }
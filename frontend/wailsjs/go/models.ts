@@ -1,10 +1,97 @@
 export namespace main {
 	
+	export class Aggregation {
+	    field: string;
+	    operation: string;
+	    output: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Aggregation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.operation = source["operation"];
+	        this.output = source["output"];
+	    }
+	}
+	export class ConvertOptions {
+	    trimWhitespace: boolean;
+	    keepOrder: boolean;
+	    nullPolicy?: string;
+	    tomlDatetimes?: boolean;
+	    preciseFields?: string[];
+	    goPointers?: boolean;
+	    goOmitEmpty?: boolean;
+	    pathComments?: boolean;
+	    javaBuilder?: boolean;
+	    csharpNullable?: boolean;
+	    goTagTemplate?: string;
+	    javaAnnotationTemplate?: string;
+	    csharpAttributeTemplate?: string;
+	    combinedOutput?: boolean;
+	    codeNamespace?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.trimWhitespace = source["trimWhitespace"];
+	        this.keepOrder = source["keepOrder"];
+	        this.nullPolicy = source["nullPolicy"];
+	        this.tomlDatetimes = source["tomlDatetimes"];
+	        this.preciseFields = source["preciseFields"];
+	        this.goPointers = source["goPointers"];
+	        this.goOmitEmpty = source["goOmitEmpty"];
+	        this.pathComments = source["pathComments"];
+	        this.javaBuilder = source["javaBuilder"];
+	        this.csharpNullable = source["csharpNullable"];
+	        this.goTagTemplate = source["goTagTemplate"];
+	        this.javaAnnotationTemplate = source["javaAnnotationTemplate"];
+	        this.csharpAttributeTemplate = source["csharpAttributeTemplate"];
+	        this.combinedOutput = source["combinedOutput"];
+	        this.codeNamespace = source["codeNamespace"];
+	    }
+	}
+	export class FormatOptions {
+	    indent: string;
+	    trimWhitespace: boolean;
+	    keepOrder: boolean;
+	    duplicateKeyStrategy?: string;
+	    sortKeys?: boolean;
+	    truncatedBase64?: string;
+	    hashComments?: boolean;
+	    preserveSpecialWhitespace?: boolean;
+	    trailingNewline?: boolean;
+	    expansionWarningRatio?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FormatOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.indent = source["indent"];
+	        this.trimWhitespace = source["trimWhitespace"];
+	        this.keepOrder = source["keepOrder"];
+	        this.duplicateKeyStrategy = source["duplicateKeyStrategy"];
+	        this.sortKeys = source["sortKeys"];
+	        this.truncatedBase64 = source["truncatedBase64"];
+	        this.hashComments = source["hashComments"];
+	        this.preserveSpecialWhitespace = source["preserveSpecialWhitespace"];
+	        this.trailingNewline = source["trailingNewline"];
+	        this.expansionWarningRatio = source["expansionWarningRatio"];
+	    }
+	}
 	export class JSONResponse {
 	    success: boolean;
 	    data: string;
 	    error: string;
 	    repaired: boolean;
+	    warning: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new JSONResponse(source);
@@ -16,6 +103,7 @@ export namespace main {
 	        this.data = source["data"];
 	        this.error = source["error"];
 	        this.repaired = source["repaired"];
+	        this.warning = source["warning"];
 	    }
 	}
 	export class PathInfo {
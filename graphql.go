@@ -0,0 +1,134 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// graphQLNameRe matches valid GraphQL names
+var graphQLNameRe = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// GenerateGraphQLQuery reconstructs a GraphQL query selecting exactly the fields present in a
+// sample response. Scalars become bare field selections and objects nested selection sets;
+// the fields of all elements of an array of objects are selected once. A response wrapped in
+// the standard {"data": ...} envelope is unwrapped. Keys that are not valid GraphQL names are
+// left out with a comment. An empty operationName produces an anonymous query.
+func (a *App) GenerateGraphQLQuery(input string, operationName string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	operationName = strings.TrimSpace(operationName)
+	if operationName != "" && !graphQLNameRe.MatchString(operationName) {
+		return JSONResponse{Success: false, Error: "无效的操作名称: " + operationName}
+	}
+
+	root := gjson.Parse(validInput)
+	if data := root.Get("data"); root.IsObject() && data.IsObject() && isGraphQLEnvelope(root) {
+		root = data
+	}
+	if !root.IsObject() {
+		return JSONResponse{Success: false, Error: "GraphQL 响应的根必须是对象"}
+	}
+
+	selection := &graphQLSelection{}
+	selection.observe(root)
+
+	var builder strings.Builder
+	builder.WriteString("query")
+	if operationName != "" {
+		builder.WriteString(" " + operationName)
+	}
+	builder.WriteString(" {\n")
+	selection.write(&builder, "  ")
+	builder.WriteString("}\n")
+	return JSONResponse{Success: true, Data: builder.String()}
+}
+
+// isGraphQLEnvelope reports whether res only holds the top-level keys of a GraphQL response
+func isGraphQLEnvelope(res gjson.Result) bool {
+	envelope := true
+	res.ForEach(func(key, _ gjson.Result) bool {
+		switch key.String() {
+		case "data", "errors", "extensions":
+		default:
+			envelope = false
+		}
+		return envelope
+	})
+	return envelope
+}
+
+// graphQLSelection is a selection set, with fields in the order they are first seen. A nil
+// child selects a scalar.
+type graphQLSelection struct {
+	fields   []string
+	children map[string]*graphQLSelection
+	invalid  []string
+}
+
+// observe adds the fields of the object value to the selection set
+func (sel *graphQLSelection) observe(value gjson.Result) {
+	if sel.children == nil {
+		sel.children = make(map[string]*graphQLSelection)
+	}
+	value.ForEach(func(key, member gjson.Result) bool {
+		name := key.String()
+		if !graphQLNameRe.MatchString(name) {
+			for _, seen := range sel.invalid {
+				if seen == name {
+					return true
+				}
+			}
+			sel.invalid = append(sel.invalid, name)
+			return true
+		}
+		if _, seen := sel.children[name]; !seen {
+			sel.fields = append(sel.fields, name)
+			sel.children[name] = nil
+		}
+		sel.observeMember(name, member)
+		return true
+	})
+}
+
+// observeMember records the selection of field name for one of its sample values, looking
+// through arrays (and arrays of arrays) at their elements
+func (sel *graphQLSelection) observeMember(name string, member gjson.Result) {
+	switch {
+	case member.IsObject():
+		if sel.children[name] == nil {
+			sel.children[name] = &graphQLSelection{}
+		}
+		sel.children[name].observe(member)
+	case member.IsArray():
+		member.ForEach(func(_, elem gjson.Result) bool {
+			sel.observeMember(name, elem)
+			return true
+		})
+	}
+}
+
+// write writes the fields of the selection set, one per line
+func (sel *graphQLSelection) write(builder *strings.Builder, indent string) {
+	for _, name := range sel.invalid {
+		builder.WriteString(indent + "# " + canonicalString(name) + " is not a valid GraphQL field name\n")
+	}
+	if len(sel.fields) == 0 {
+		// A selection set cannot be empty
+		builder.WriteString(indent + "__typename\n")
+		return
+	}
+	for _, name := range sel.fields {
+		child := sel.children[name]
+		if child == nil {
+			builder.WriteString(indent + name + "\n")
+			continue
+		}
+		builder.WriteString(indent + name + " {\n")
+		child.write(builder, indent+"  ")
+		builder.WriteString(indent + "}\n")
+	}
+}
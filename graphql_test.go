@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestGenerateGraphQLQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		operationName string
+		want          string
+	}{
+		{"nested selection sets from an envelope",
+			`{"data": {"user": {"id": 1, "name": "a", "posts": [{"title": "t"}, {"title": "u", "tags": ["x"], "author": {"id": 2}}], "empty": {}}}}`, "GetUser",
+			"query GetUser {\n  user {\n    id\n    name\n    posts {\n      title\n      tags\n      author {\n        id\n      }\n    }\n    empty {\n      __typename\n    }\n  }\n}\n"},
+		{"anonymous query with invalid names and nested arrays",
+			`{"viewer": {"login": "x"}, "my-key": 1, "grid": [[{"a": 1}], [{"b": 2}]]}`, "",
+			"query {\n  # \"my-key\" is not a valid GraphQL field name\n  viewer {\n    login\n  }\n  grid {\n    a\n    b\n  }\n}\n"},
+		{"data next to other keys is a field", `{"data": {"x": 1}, "other": 2}`, " Q ",
+			"query Q {\n  data {\n    x\n  }\n  other\n}\n"},
+		{"repaired input", `{user: {id: 1`, "", "query {\n  user {\n    id\n  }\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().GenerateGraphQLQuery(tt.input, tt.operationName)
+			if !resp.Success {
+				t.Fatalf("GenerateGraphQLQuery failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("GenerateGraphQLQuery =\n%s\nwant\n%s", resp.Data, tt.want)
+			}
+		})
+	}
+
+	for _, input := range [][2]string{{`[1]`, "Q"}, {`{"a": 1}`, "bad name"}} {
+		if resp := NewApp().GenerateGraphQLQuery(input[0], input[1]); resp.Success {
+			t.Errorf("GenerateGraphQLQuery(%s, %q) = %+v, want an error", input[0], input[1], resp)
+		}
+	}
+}
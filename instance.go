@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxInstanceMessage limits the size of a message from another instance
+const maxInstanceMessage = 64 << 20
+
+// instanceMessage is a request sent by a second instance over the single-instance socket.
+// kind is "path" for a file to open or "content" for JSON text piped to that instance.
+type instanceMessage struct {
+	kind    string
+	payload string
+}
+
+// encodeInstanceMessage frames payload as a message: a "<kind> <length>\n" header followed by
+// length bytes of payload
+func encodeInstanceMessage(kind string, payload string) []byte {
+	return []byte(kind + " " + strconv.Itoa(len(payload)) + "\n" + payload)
+}
+
+// readInstanceMessage reads one message until the sender closes the connection
+func readInstanceMessage(r io.Reader) (instanceMessage, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxInstanceMessage+64))
+	if err != nil {
+		return instanceMessage{}, err
+	}
+	return parseInstanceMessage(data)
+}
+
+// parseInstanceMessage decodes a message framed by encodeInstanceMessage. Data without a
+// valid header is a bare file path, as sent by older versions.
+func parseInstanceMessage(data []byte) (instanceMessage, error) {
+	if len(data) == 0 {
+		return instanceMessage{}, errors.New("empty message")
+	}
+	header, payload, found := bytes.Cut(data, []byte("\n"))
+	kind, size, _ := strings.Cut(string(header), " ")
+	length, err := strconv.Atoi(size)
+	if !found || (kind != "path" && kind != "content") || err != nil || length < 0 {
+		return instanceMessage{kind: "path", payload: string(data)}, nil
+	}
+	if length > maxInstanceMessage {
+		return instanceMessage{}, errors.New("message too large")
+	}
+	if len(payload) != length {
+		return instanceMessage{}, errors.New("message length mismatch: expected " + strconv.Itoa(length) + " bytes, got " + strconv.Itoa(len(payload)))
+	}
+	return instanceMessage{kind: kind, payload: string(payload)}, nil
+}
+
+// pipedInputTimeout bounds how long a second instance waits for its piped input to end before
+// giving up, so a pipe that is never closed does not leave it running
+const pipedInputTimeout = 10 * time.Second
+
+// readPipedInput returns standard input when it is a pipe or a redirected file, as in
+// cat file.json | json-fixer. Input that is empty or only whitespace is ignored. A positive
+// timeout gives up when the input has not ended by then; otherwise it waits for the end.
+func readPipedInput(timeout time.Duration) (string, bool) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return "", false
+	}
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, maxInstanceMessage))
+		done <- result{data, err}
+	}()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+	select {
+	case r := <-done:
+		if r.err != nil || len(bytes.TrimSpace(r.data)) == 0 {
+			return "", false
+		}
+		return string(r.data), true
+	case <-expired:
+		return "", false
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInstanceMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		payload string
+	}{
+		{"path", "path", `C:\Users\me\data file.json`},
+		{"content", "content", "{\"a\": 1,\n \"b\": [2, 3]}\n"},
+		{"content with a header-like first line", "content", "path 3\nabc"},
+		{"empty content", "content", ""},
+		{"non-ASCII content", "content", "{\"name\": \"\xe4\xb8\xad\"}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// net.Pipe stands in for the socket: the sender closes its end when done
+			client, server := net.Pipe()
+			go func() {
+				client.Write(encodeInstanceMessage(tt.kind, tt.payload))
+				client.Close()
+			}()
+			msg, err := readInstanceMessage(server)
+			if err != nil {
+				t.Fatalf("readInstanceMessage failed: %v", err)
+			}
+			if want := (instanceMessage{kind: tt.kind, payload: tt.payload}); msg != want {
+				t.Errorf("readInstanceMessage = %+v, want %+v", msg, want)
+			}
+		})
+	}
+}
+
+func TestParseInstanceMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    instanceMessage
+		wantErr string
+	}{
+		{"bare path from an older version", "/tmp/data.json", instanceMessage{kind: "path", payload: "/tmp/data.json"}, ""},
+		{"unknown kind is a bare path", "file 3\nabc", instanceMessage{kind: "path", payload: "file 3\nabc"}, ""},
+		{"bad length is a bare path", "content x\nabc", instanceMessage{kind: "path", payload: "content x\nabc"}, ""},
+		{"short payload", "content 5\nabc", instanceMessage{}, "message length mismatch: expected 5 bytes, got 3"},
+		{"long payload", "path 1\nabc", instanceMessage{}, "message length mismatch: expected 1 bytes, got 3"},
+		{"too large", "content " + strconv.Itoa(maxInstanceMessage+1) + "\n", instanceMessage{}, "message too large"},
+		{"empty", "", instanceMessage{}, "empty message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := parseInstanceMessage([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseInstanceMessage = %+v, %v, want error %q", msg, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || msg != tt.want {
+				t.Errorf("parseInstanceMessage = %+v, %v, want %+v", msg, err, tt.want)
+			}
+		})
+	}
+
+	// A payload beyond the read limit is cut off and then fails the length check
+	oversized := encodeInstanceMessage("content", strings.Repeat("x", maxInstanceMessage+100))
+	if _, err := readInstanceMessage(bytes.NewReader(oversized)); err == nil {
+		t.Error("readInstanceMessage accepted a payload over the limit")
+	}
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ================================
@@ -29,6 +36,7 @@ const (
 	codeReturn                  = 0xd  // "\r"
 	codeBackspace               = 0x08 // "\b"
 	codeFormFeed                = 0x0c // "\f"
+	codeRecordSeparator         = 0x1e // RS, separates records in application/json-seq
 	codeDoubleQuote             = 0x22 // "
 	codePlus                    = 0x2b // "+"
 	codeMinus                   = 0x2d // "-"
@@ -39,7 +47,9 @@ const (
 	codeDot                     = 0x2e // "." (dot, period)
 	codeColon                   = 0x3a // ":"
 	codeEqual                   = 0x3d // "="
+	codeGreaterThan             = 0x3e // ">"
 	codeSemicolon               = 0x3b // ";"
+	codeHash                    = 0x23 // "#"
 	codeUppercaseA              = 0x41 // "A"
 	codeLowercaseA              = 0x61 // "a"
 	codeUppercaseE              = 0x45 // "E"
@@ -95,8 +105,13 @@ var (
 	ErrInvalidCharacter    = errors.New("invalid character")
 	ErrUnexpectedCharacter = errors.New("unexpected character")
 	ErrInvalidUnicode      = errors.New("invalid unicode character")
+	ErrInvalidUTF8         = errors.New("invalid utf-8 byte sequence")
+	ErrMaxDepthExceeded    = errors.New("maximum nesting depth exceeded")
 )
 
+// DefaultMaxDepth is the nesting depth allowed when RepairOptions.MaxDepth is 0
+const DefaultMaxDepth = 10000
+
 // URL-related regular expressions and functions
 var regexURLStart = regexp.MustCompile(`^(https?|ftp|mailto|file|data|irc)://`)
 var regexURLChar = regexp.MustCompile(`^[A-Za-z0-9\-._~:/?#@!$&'()*+;=]$`)
@@ -112,8 +127,19 @@ var (
 	regexStartOfValue    = regexp.MustCompile(`^[{[\w-]$`)
 	trailingWhitespaceRe = regexp.MustCompile(`(?:\\[ntrfb]| )+$`)
 	stringTrimRe         = regexp.MustCompile(`^(?:\\[ntrfb]| )+|(?:\\[ntrfb]| )+$`)
+	unquotedDateRe       = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?`)
+	leadingZeroRe        = regexp.MustCompile(`^0\d`)
 )
 
+// DefaultUnitTable contains the unit suffixes recognized by RepairOptions.UnitSuffixMode.
+// Durations convert to seconds, byte sizes to bytes and percentages to fractions.
+var DefaultUnitTable = map[string]float64{
+	"ns": 1e-9, "us": 1e-6, "ms": 1e-3, "s": 1, "m": 60, "h": 3600, "d": 86400,
+	"B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40,
+	"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40,
+	"%": 0.01,
+}
+
 // windowsPathPatterns contains common Windows directory patterns for path detection.
 var windowsPathPatterns = []string{
 	"program files", "system32", "windows\\", "programdata",
@@ -167,74 +193,1218 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// textChar is the element type of the text being parsed. ASCII-only input is parsed as bytes,
+// which saves converting it to runes; anything else is parsed as runes. Positions are indexes
+// into the text either way, which agree for ASCII.
+type textChar interface {
+	byte | rune
+}
+
+// RepairOptions controls optional repair behaviour.
+type RepairOptions struct {
+	TrimWhitespace bool
+	// FromSourceLiteral unescapes one level of source code string escaping
+	// (JSON copied out of a Java/Go string literal) before repairing.
+	FromSourceLiteral bool
+	// StripJSONP unwraps a JSONP callback such as cb({...}); that wraps the whole document.
+	// Function calls appearing as values are left untouched.
+	StripJSONP bool
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 byte sequences with U+FFFD. When false,
+	// input containing invalid UTF-8 is rejected with an error wrapping ErrInvalidUTF8.
+	ReplaceInvalidUTF8 bool
+	// ExtractFencedBlock repairs only the first ```json (or bare ```) fenced block when the
+	// input is a markdown document with prose around the fence.
+	ExtractFencedBlock bool
+	// KeyControlChars controls literal control characters (tabs, newlines, ...) inside quoted
+	// object keys: "" escapes them like in values, "strip" removes them and "error" rejects
+	// the input, since such keys usually indicate corrupted data.
+	KeyControlChars string
+	// UnitSuffixMode controls unquoted numbers with a unit suffix such as 30s, 10MB or 50%.
+	// "" keeps them as strings, "split" emits {"value":30,"unit":"s"} and "canonical"
+	// converts them to a plain number in the base unit using UnitTable.
+	UnitSuffixMode string
+	// UnitTable maps recognized unit suffixes to their multiplier for the base unit.
+	// DefaultUnitTable is used when it is nil.
+	UnitTable map[string]float64
+	// ProseQuotes treats a double quote followed by more words on the same line as an
+	// unescaped quote inside prose, e.g. "He said "hello" to me", instead of the end of
+	// the string, as long as the line later ends the string before a delimiter or the
+	// words run to the end of the value, as in {"a": "x" y}.
+	ProseQuotes bool
+	// LenientSeparators also accepts "->" and ":=" between an object key and its value,
+	// as left behind by PHP or Pascal/Go code, and normalizes them to ":".
+	LenientSeparators bool
+	// TruncatedBase64 controls a base64 string value cut off by the end of the input, as
+	// left by copying from a terminal: "" keeps it as is, "pad" trims and pads it to a
+	// decodable length and "marker" replaces it with "<truncated base64>".
+	TruncatedBase64 string
+	// HashComments also skips shell/YAML style # line comments. A # only starts a comment at
+	// the start of the input or after whitespace, so URL fragments such as http://x#top and
+	// values like a#b are kept; # inside quoted strings is never a comment.
+	HashComments bool
+	// DuplicateKeys resolves object keys that occur more than once, see ResolveDuplicateKeys.
+	// The default "" keeps every occurrence.
+	DuplicateKeys string
+	// PythonLiterals reads Python repr() output: tuples (1, 2) and sets {1, 2} become arrays,
+	// and set(), frozenset(...), tuple(...) and list(...) calls are unwrapped.
+	PythonLiterals bool
+	// DoubledQuotes collapses strings wrapped in two pairs of double quotes, such as ""x"" left
+	// by a templating bug, into "x" in key and value positions. Empty strings "" followed by a
+	// delimiter are left alone.
+	DoubledQuotes bool
+	// FoldTrailingPairs moves the closing brace of a root object after key:value pairs that
+	// follow it, as in {"a":1} "b":2 where the brace was typed too early. Trailing values
+	// that are not key:value pairs are still ignored.
+	FoldTrailingPairs bool
+	// AllowEmpty returns "" without an error for input that is empty or holds nothing but
+	// whitespace and comments, e.g. while the user is still typing. By default such input
+	// fails with ErrUnexpectedEnd.
+	AllowEmpty bool
+	// InvisibleMarks skips invisible format characters left between tokens by rich-text copy,
+	// such as the U+200E/U+200F direction marks and bidi embeddings, as whitespace. Zero-width
+	// spaces and joiners and a stray U+FEFF are always skipped. Inside strings they are kept.
+	InvisibleMarks bool
+	// PreserveSpecialWhitespace keeps non-breaking, ideographic and other Unicode spaces at the
+	// end of unquoted values, which are otherwise trimmed like ASCII spaces. Quoted strings
+	// always keep them; between tokens they are still normalized to a plain space.
+	PreserveSpecialWhitespace bool
+	// NormalizeStringNewlines converts CRLF and lone CR line breaks inside strings to LF,
+	// whether they appear raw or escaped as \r. Structural line breaks are left alone.
+	NormalizeStringNewlines bool
+	// EscapeKeyDots escapes dots and backslashes in object keys with a backslash, e.g.
+	// "user.name" becomes "user\\.name", for dotted keys that are meant literally, as in
+	// Elasticsearch or Prometheus labels. ExpandDottedKeys then keeps such keys whole.
+	EscapeKeyDots bool
+	// KeysOnly quotes unquoted object keys but writes unquoted values such as hello or
+	// undefined verbatim, for pipelines that handle values later. The output is then not
+	// strict JSON, so KeysOnly cannot be combined with DuplicateKeys.
+	KeysOnly bool
+	// MaxDepth limits how deeply values may be nested. Deeper input is rejected with an error
+	// wrapping ErrMaxDepthExceeded instead of exhausting the stack; 0 means DefaultMaxDepth.
+	MaxDepth int
+
+	// report collects the repairs made when set by JSONRepairWithReport
+	report *repairReport
+	// depth is the number of values currently being parsed
+	depth int
+	// callDepth is the number of function calls whose arguments are currently being parsed
+	callDepth int
+}
+
+// RepairAction describes a single change made while repairing. Kind is a short identifier
+// such as "inserted-comma", "closed-brace", "quoted-key" or "removed-comment", and Position
+// is the rune offset in the text passed to JSONRepairWithReport where the change applies,
+// counting a leading byte order mark.
+type RepairAction struct {
+	Kind        string `json:"kind"`
+	Position    int    `json:"position"`
+	Description string `json:"description"`
+}
+
+// repairReport collects RepairActions. The parser looks ahead by parsing the same input more
+// than once, so actions are deduplicated by kind and position.
+type repairReport struct {
+	actions []RepairAction
+	seen    map[string]bool
+}
+
+// record adds a repair action to the report, if one is being collected
+func (opts *RepairOptions) record(kind string, position int, description string) {
+	if opts == nil || opts.report == nil {
+		return
+	}
+	key := kind + "@" + strconv.Itoa(position)
+	if opts.report.seen[key] {
+		return
+	}
+	opts.report.seen[key] = true
+	opts.report.actions = append(opts.report.actions, RepairAction{Kind: kind, Position: position, Description: description})
+}
+
+// skipsMark reports whether code is an invisible mark to skip as whitespace: a zero-width
+// character, or any invisible mark with InvisibleMarks
+func (opts *RepairOptions) skipsMark(code rune) bool {
+	return isZeroWidth(code) || opts != nil && opts.InvisibleMarks && isInvisibleMark(code)
+}
+
+// reportMark returns the number of recorded actions, for discarding them with reportRollback
+// when a speculative parse is abandoned
+func (opts *RepairOptions) reportMark() int {
+	if opts == nil || opts.report == nil {
+		return 0
+	}
+	return len(opts.report.actions)
+}
+
+// reportRollback discards the actions recorded after mark
+func (opts *RepairOptions) reportRollback(mark int) {
+	if opts == nil || opts.report == nil {
+		return
+	}
+	for _, action := range opts.report.actions[mark:] {
+		delete(opts.report.seen, action.Kind+"@"+strconv.Itoa(action.Position))
+	}
+	opts.report.actions = opts.report.actions[:mark]
+}
+
 // ================================
 // PUBLIC API
 // ================================
 
 // JSONRepair attempts to repair the given JSON string and returns the repaired version.
 func JSONRepair(text string, trimWhitespace bool) (string, error) {
-	if len(text) == 0 {
+	return JSONRepairWithOptions(text, RepairOptions{TrimWhitespace: trimWhitespace})
+}
+
+// RepairRaw repairs text without formatting it, for callers that format the result
+// themselves. Text that is already valid JSON is returned unchanged, spacing included, and
+// repaired is false; otherwise the repaired text keeps the original spacing where the repairs
+// allow and repaired is true.
+func RepairRaw(text string, trimWhitespace bool) (result string, repaired bool, err error) {
+	if json.Valid([]byte(text)) {
+		return text, false, nil
+	}
+	result, err = JSONRepair(text, trimWhitespace)
+	if err != nil {
+		return "", false, err
+	}
+	return result, true, nil
+}
+
+// JSONRepairWithReport repairs text like JSONRepair and also returns the list of changes that
+// were made, in the order they were applied. The list is empty when text was already valid.
+func JSONRepairWithReport(text string, trimWhitespace bool) (string, []RepairAction, error) {
+	report := &repairReport{seen: make(map[string]bool)}
+	repaired, err := JSONRepairWithOptions(text, RepairOptions{TrimWhitespace: trimWhitespace, report: report})
+	if err != nil {
+		return "", nil, err
+	}
+	if report.actions == nil {
+		report.actions = []RepairAction{}
+	}
+	// The parser counts positions after the byte order mark it drops
+	if strings.HasPrefix(text, "\uFEFF") {
+		for idx := range report.actions {
+			report.actions[idx].Position++
+		}
+	}
+	return repaired, report.actions, nil
+}
+
+// JSONRepairWithOptions attempts to repair the given JSON string using the given options.
+func JSONRepairWithOptions(text string, opts RepairOptions) (string, error) {
+	if opts.KeysOnly && opts.DuplicateKeys != "" {
+		// Duplicate keys are resolved by decoding the output, which KeysOnly leaves invalid
+		return "", errors.New("duplicate key strategies need strict JSON and cannot be combined with KeysOnly")
+	}
+	text, err := prepareRepairInput(text, opts)
+	if err != nil {
+		return "", err
+	}
+	if len(text) == 0 && !opts.AllowEmpty {
 		return "", newUnexpectedEndError(0)
 	}
+	// Most documents are ASCII, which is parsed as bytes instead of being converted to runes
+	if isASCII(text) {
+		return repairText([]byte(text), opts)
+	}
+	return repairText([]rune(text), opts)
+}
 
-	runes := []rune(text)
+// repairText is JSONRepairWithOptions for text that has been prepared and converted
+func repairText[T textChar](text []T, opts RepairOptions) (string, error) {
+	if opts.AllowEmpty && isBlankInput(text, &opts) {
+		return "", nil
+	}
 	i := 0
 	var output strings.Builder
 
-	parseMarkdownCodeBlock(&runes, &i, []string{"```", "[```", "{```"}, &output, trimWhitespace)
+	parseMarkdownCodeBlock(&text, &i, []string{"```", "[```", "{```"}, &output, &opts)
 
-	success, err := parseValue(&runes, &i, &output, trimWhitespace)
+	success, err := parseValue(&text, &i, &output, &opts)
 	if err != nil {
 		return "", err
 	}
 	if !success {
-		return "", newUnexpectedEndError(len(runes))
+		return "", newUnexpectedEndError(len(text))
 	}
+	if opts.FoldTrailingPairs {
+		if err := foldTrailingPairs(&text, &i, &output, &opts); err != nil {
+			return "", err
+		}
+	}
+
+	parseMarkdownCodeBlock(&text, &i, []string{"```", "```]", "```}"}, &output, &opts)
+
+	if opts.DuplicateKeys != "" {
+		return ResolveDuplicateKeys(output.String(), opts.DuplicateKeys, opts.TrimWhitespace)
+	}
+	return output.String(), nil
+}
+
+// prepareRepairInput checks the UTF-8 encoding of text, drops a byte order mark and applies
+// the whole-document rewrites selected in opts
+func prepareRepairInput(text string, opts RepairOptions) (string, error) {
+	if !utf8.ValidString(text) {
+		if !opts.ReplaceInvalidUTF8 {
+			return "", newInvalidUTF8Error(InvalidUTF8Offsets(text))
+		}
+		text, _ = ReplaceInvalidUTF8(text)
+	}
+	// A UTF-8 byte order mark left by Windows editors is not part of the document
+	text = strings.TrimPrefix(text, "\uFEFF")
+	return preprocessRepairInput(text, opts)
+}
+
+// isBlankInput reports whether text contains nothing but whitespace and comments
+func isBlankInput[T textChar](text []T, opts *RepairOptions) bool {
+	i := 0
+	parseWhitespaceAndSkipComments(&text, &i, &strings.Builder{}, true, opts)
+	return i >= len(text)
+}
+
+// isASCII reports whether text contains only ASCII characters
+func isASCII(text string) bool {
+	for idx := 0; idx < len(text); idx++ {
+		if text[idx] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateStrict checks that text is valid JSON as defined by RFC 8259 without repairing
+// anything. The returned *Error points at the first deviation, using the same rune positions
+// and sentinel errors as the repair functions, so errors.Is(err, ErrColonExpected) works.
+func ValidateStrict(text string) error {
+	if !utf8.ValidString(text) {
+		return newInvalidUTF8Error(InvalidUTF8Offsets(text))
+	}
+	v := &strictValidator{text: []rune(text)}
+	v.skipWhitespace()
+	if err := v.value(); err != nil {
+		return err
+	}
+	v.skipWhitespace()
+	if v.i < len(v.text) {
+		return v.unexpected()
+	}
+	return nil
+}
+
+// strictValidator walks a document following the RFC 8259 grammar
+type strictValidator struct {
+	text []rune
+	i    int
+}
+
+// skipWhitespace skips the four whitespace characters JSON allows
+func (v *strictValidator) skipWhitespace() {
+	for v.i < len(v.text) {
+		switch v.text[v.i] {
+		case ' ', '\t', '\n', '\r':
+			v.i++
+		default:
+			return
+		}
+	}
+}
+
+// unexpected returns the error for the character at the current position
+func (v *strictValidator) unexpected() *Error {
+	if v.i >= len(v.text) {
+		return newUnexpectedEndError(v.i)
+	}
+	return newUnexpectedCharacterError(fmt.Sprintf("Unexpected character %q", v.text[v.i]), v.i)
+}
+
+func (v *strictValidator) value() error {
+	if v.i >= len(v.text) {
+		return newUnexpectedEndError(v.i)
+	}
+	switch char := v.text[v.i]; {
+	case char == codeOpeningBrace:
+		return v.object()
+	case char == codeOpeningBracket:
+		return v.array()
+	case char == codeDoubleQuote:
+		return v.string()
+	case char == '-' || isDigit(char):
+		return v.number()
+	}
+	for _, literal := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(string(v.text[v.i:min(v.i+len(literal), len(v.text))]), literal) {
+			v.i += len(literal)
+			return nil
+		}
+	}
+	return v.unexpected()
+}
+
+func (v *strictValidator) object() error {
+	v.i++
+	v.skipWhitespace()
+	if v.i < len(v.text) && v.text[v.i] == codeClosingBrace {
+		v.i++
+		return nil
+	}
+	for {
+		if v.i >= len(v.text) {
+			return newUnexpectedEndError(v.i)
+		}
+		if v.text[v.i] != codeDoubleQuote {
+			return newObjectKeyExpectedError(v.i)
+		}
+		if err := v.string(); err != nil {
+			return err
+		}
+		v.skipWhitespace()
+		if v.i >= len(v.text) {
+			return newUnexpectedEndError(v.i)
+		}
+		if v.text[v.i] != codeColon {
+			return newColonExpectedError(v.i)
+		}
+		v.i++
+		v.skipWhitespace()
+		if err := v.value(); err != nil {
+			return err
+		}
+		v.skipWhitespace()
+		if v.i < len(v.text) && v.text[v.i] == codeClosingBrace {
+			v.i++
+			return nil
+		}
+		if v.i >= len(v.text) || v.text[v.i] != codeComma {
+			return v.unexpected()
+		}
+		v.i++
+		v.skipWhitespace()
+	}
+}
+
+func (v *strictValidator) array() error {
+	v.i++
+	v.skipWhitespace()
+	if v.i < len(v.text) && v.text[v.i] == codeClosingBracket {
+		v.i++
+		return nil
+	}
+	for {
+		if err := v.value(); err != nil {
+			return err
+		}
+		v.skipWhitespace()
+		if v.i < len(v.text) && v.text[v.i] == codeClosingBracket {
+			v.i++
+			return nil
+		}
+		if v.i >= len(v.text) || v.text[v.i] != codeComma {
+			return v.unexpected()
+		}
+		v.i++
+		v.skipWhitespace()
+	}
+}
+
+func (v *strictValidator) string() error {
+	v.i++
+	for v.i < len(v.text) {
+		char := v.text[v.i]
+		switch {
+		case char == codeDoubleQuote:
+			v.i++
+			return nil
+		case char < 0x20:
+			return newInvalidCharacterError("Control character in string", v.i)
+		case char == codeBackslash:
+			v.i++
+			if v.i >= len(v.text) {
+				return newUnexpectedEndError(v.i)
+			}
+			escape := v.text[v.i]
+			if escape == 'u' {
+				for k := 1; k <= 4; k++ {
+					if v.i+k >= len(v.text) {
+						return newUnexpectedEndError(len(v.text))
+					}
+					if !isHex(v.text[v.i+k]) {
+						return newInvalidUnicodeError("Invalid unicode escape", v.i-1)
+					}
+				}
+				v.i += 4
+			} else if _, ok := escapeCharacters[escape]; !ok {
+				return newInvalidCharacterError(fmt.Sprintf("Invalid escape character %q", escape), v.i)
+			}
+		}
+		v.i++
+	}
+	return newUnexpectedEndError(v.i)
+}
+
+func (v *strictValidator) number() error {
+	if v.text[v.i] == '-' {
+		v.i++
+	}
+	if v.i < len(v.text) && v.text[v.i] == '0' {
+		v.i++
+	} else if err := v.digits(); err != nil {
+		return err
+	}
+	if v.i < len(v.text) && v.text[v.i] == codeDot {
+		v.i++
+		if err := v.digits(); err != nil {
+			return err
+		}
+	}
+	if v.i < len(v.text) && (v.text[v.i] == 'e' || v.text[v.i] == 'E') {
+		v.i++
+		if v.i < len(v.text) && (v.text[v.i] == '+' || v.text[v.i] == '-') {
+			v.i++
+		}
+		if err := v.digits(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// digits consumes one or more decimal digits
+func (v *strictValidator) digits() error {
+	start := v.i
+	for v.i < len(v.text) && isDigit(v.text[v.i]) {
+		v.i++
+	}
+	if v.i == start {
+		if v.i >= len(v.text) {
+			return newUnexpectedEndError(v.i)
+		}
+		return newInvalidCharacterError(fmt.Sprintf("Invalid number, digit expected but got %q", v.text[v.i]), v.i)
+	}
+	return nil
+}
+
+// keyTrimCutset is the whitespace removed from keys and strings when trimming is enabled
+const keyTrimCutset = " \n\t\r\f\b"
+
+// ResolveDuplicateKeys applies strategy to object keys that occur more than once in the valid
+// JSON text: "keep-first" drops later members, "keep-last" keeps the value of the last member
+// at the position of the first one (like JSON.parse), "rename" appends _2, _3, ... to later
+// keys and "error" rejects the document. Text without duplicates is returned unchanged;
+// otherwise it is rewritten compactly.
+//
+// When trimKeys is set, keys are compared after trimming surrounding whitespace, because
+// trimming makes {"a": 1, " a ": 2} collapse into two "a" members; renamed keys are then
+// based on the trimmed key.
+func ResolveDuplicateKeys(text string, strategy string, trimKeys bool) (string, error) {
+	switch strategy {
+	case "", "keep-first", "keep-last", "rename", "error":
+	default:
+		return "", fmt.Errorf("unknown duplicate key strategy %q", strategy)
+	}
+	if strategy == "" {
+		return text, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	resolver := &duplicateKeyResolver{decoder: decoder, strategy: strategy, trimKeys: trimKeys}
+	var output strings.Builder
+	if err := resolver.value(&output, "$"); err != nil {
+		return "", err
+	}
+	if !resolver.found {
+		return text, nil
+	}
+	return output.String(), nil
+}
+
+// duplicateKeyResolver rewrites a token stream while resolving duplicate keys
+type duplicateKeyResolver struct {
+	decoder  *json.Decoder
+	strategy string
+	trimKeys bool
+	found    bool
+}
+
+// value copies the next value of the stream to output
+func (r *duplicateKeyResolver) value(output *strings.Builder, path string) error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			output.WriteByte('[')
+			for idx := 0; r.decoder.More(); idx++ {
+				if idx > 0 {
+					output.WriteByte(',')
+				}
+				if err := r.value(output, path+"["+strconv.Itoa(idx)+"]"); err != nil {
+					return err
+				}
+			}
+			output.WriteByte(']')
+		} else {
+			if err := r.object(output, path); err != nil {
+				return err
+			}
+		}
+		_, err = r.decoder.Token()
+		return err
+	case string:
+		output.WriteString(encodeJSONString(t))
+	case json.Number:
+		output.WriteString(t.String())
+	case bool:
+		output.WriteString(strconv.FormatBool(t))
+	default:
+		output.WriteString("null")
+	}
+	return nil
+}
+
+// object copies the members of an object whose opening brace has been read
+func (r *duplicateKeyResolver) object(output *strings.Builder, path string) error {
+	type member struct {
+		key   string
+		value string
+	}
+	var members []member
+	index := make(map[string]int)
+	for r.decoder.More() {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		var value strings.Builder
+		if err := r.value(&value, path+"."+key); err != nil {
+			return err
+		}
+
+		name := key
+		if r.trimKeys {
+			name = strings.Trim(key, keyTrimCutset)
+		}
+		if idx, duplicate := index[name]; duplicate {
+			r.found = true
+			switch r.strategy {
+			case "keep-first":
+				continue
+			case "keep-last":
+				members[idx].value = value.String()
+				continue
+			case "error":
+				return fmt.Errorf("duplicate key %q at %s", key, path)
+			}
+			// rename
+			for n := 2; ; n++ {
+				candidate := name + "_" + strconv.Itoa(n)
+				if _, taken := index[candidate]; !taken {
+					key, name = candidate, candidate
+					break
+				}
+			}
+		}
+		index[name] = len(members)
+		members = append(members, member{key: key, value: value.String()})
+	}
+
+	output.WriteByte('{')
+	for idx, m := range members {
+		if idx > 0 {
+			output.WriteByte(',')
+		}
+		output.WriteString(encodeJSONString(m.key))
+		output.WriteByte(':')
+		output.WriteString(m.value)
+	}
+	output.WriteByte('}')
+	return nil
+}
+
+// encodeJSONString encodes s as a JSON string without HTML escaping
+func encodeJSONString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// JSONRepairFirst repairs only the first complete top-level value of text and returns it
+// together with the unparsed remainder, so a stream of concatenated values can be consumed
+// one value at a time. Surrounding whitespace is trimmed from the repaired value and skipped
+// at the start of the remainder. When text needed preprocessing (see RepairOptions), the
+// remainder is taken from the preprocessed text.
+func JSONRepairFirst(text string, opts RepairOptions) (string, string, error) {
+	text, err := prepareRepairInput(text, opts)
+	if err != nil {
+		return "", "", err
+	}
+	if len(text) == 0 {
+		return "", "", newUnexpectedEndError(0)
+	}
+
+	runes := []rune(text)
+	i := 0
+	var output strings.Builder
+	success, err := parseValue(&runes, &i, &output, &opts)
+	if err != nil {
+		return "", "", err
+	}
+	if !success {
+		return "", "", newUnexpectedEndError(len(runes))
+	}
+	return strings.TrimSpace(output.String()), string(runes[i:]), nil
+}
 
-	parseMarkdownCodeBlock(&runes, &i, []string{"```", "```]", "```}"}, &output, trimWhitespace)
+// RepairNDJSON repairs newline-delimited JSON (JSON lines) held in memory: every line is
+// repaired as one record and text after a record on the same line is dropped. Blank and
+// comment-only lines are skipped. The records are returned one per line, or as the elements
+// of a single JSON array when wrapInArray is set.
+func RepairNDJSON(text string, trimWhitespace bool, wrapInArray bool) (string, error) {
+	opts := RepairOptions{TrimWhitespace: trimWhitespace}
+	text, err := prepareRepairInput(text, opts)
+	if err != nil {
+		return "", err
+	}
 
+	runes := []rune(text)
+	i := 0
+	var output strings.Builder
+	separator := "\n"
+	if wrapInArray {
+		separator = ","
+		output.WriteString("[")
+	}
+	if err := parseNewlineDelimitedJSON(&runes, &i, &output, separator, &opts); err != nil {
+		return "", err
+	}
+	if wrapInArray {
+		output.WriteString("]")
+	} else if output.Len() == 0 {
+		return "", newUnexpectedEndError(0)
+	}
 	return output.String(), nil
 }
 
+// RepairConcatenated repairs values written back to back without any separator, such as
+// {"a":1}{"b":2} in a log dump, and returns them as the elements of one JSON array.
+// Whitespace and comments between the values are skipped and every value is repaired on its
+// own, so a malformed value does not affect the ones after it.
+func RepairConcatenated(text string) (string, error) {
+	return RepairConcatenatedWithMode(text, "wrapArray")
+}
+
+// RepairConcatenatedWithMode repairs concatenated values like RepairConcatenated and combines
+// them according to mode:
+//   - "wrapArray" (or ""): the values become the elements of one JSON array
+//   - "mergeObjects": the objects are deep-merged into one, later values win on conflicts
+//   - "mergeToArrays": the objects are deep-merged into one and a key holding different
+//     values in several objects gets an array of them, in input order
+//
+// The merge modes require every value to be an object.
+func RepairConcatenatedWithMode(text string, mode string) (string, error) {
+	switch mode {
+	case "", "wrapArray", "mergeObjects", "mergeToArrays":
+	default:
+		return "", fmt.Errorf("unknown concatenated value mode %q", mode)
+	}
+	values, err := repairConcatenatedValues(text)
+	if err != nil {
+		return "", err
+	}
+	if mode == "" || mode == "wrapArray" {
+		return "[" + strings.Join(values, ",") + "]", nil
+	}
+
+	merged := &concatNode{}
+	for idx, value := range values {
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		node, err := decodeConcatNode(decoder)
+		if err != nil {
+			return "", err
+		}
+		if node.children == nil {
+			return "", fmt.Errorf("value %d is not an object, %s needs every concatenated value to be an object", idx+1, mode)
+		}
+		merged.merge(node, mode == "mergeToArrays")
+	}
+	return merged.render(), nil
+}
+
+// repairConcatenatedValues repairs the values of text written back to back, see
+// RepairConcatenated, and returns each of them as JSON text
+func repairConcatenatedValues(text string) ([]string, error) {
+	opts := RepairOptions{}
+	if !utf8.ValidString(text) {
+		return nil, newInvalidUTF8Error(InvalidUTF8Offsets(text))
+	}
+	text, err := preprocessRepairInput(text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(text)
+	i := 0
+	var values []string
+	for {
+		parseWhitespaceAndSkipComments(&runes, &i, &strings.Builder{}, true, &opts)
+		if i >= len(runes) {
+			break
+		}
+		start := i
+		var value strings.Builder
+		success, err := parseValue(&runes, &i, &value, &opts)
+		if err != nil {
+			return nil, err
+		}
+		if !success || i == start {
+			return nil, newUnexpectedCharacterError(fmt.Sprintf("Unexpected character %q", runes[i]), i)
+		}
+		values = append(values, strings.TrimSpace(value.String()))
+	}
+	if len(values) == 0 {
+		return nil, newUnexpectedEndError(len(runes))
+	}
+	return values, nil
+}
+
+// concatNode is a value being merged by RepairConcatenatedWithMode: an object with its keys in
+// order of first appearance, or the compact JSON of one or more other values, which render as
+// an array when there are several
+type concatNode struct {
+	keys     []string
+	children map[string]*concatNode
+	values   []string
+}
+
+// decodeConcatNode reads the next value of the stream
+func decodeConcatNode(decoder *json.Decoder) (*concatNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	node := &concatNode{}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			node.children = make(map[string]*concatNode)
+			for decoder.More() {
+				token, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				child, err := decodeConcatNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.add(token.(string), child, false)
+			}
+		} else {
+			var elements []string
+			for decoder.More() {
+				child, err := decodeConcatNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, child.render())
+			}
+			node.values = []string{"[" + strings.Join(elements, ",") + "]"}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.values = []string{encodeJSONString(t)}
+	case json.Number:
+		node.values = []string{t.String()}
+	case bool:
+		node.values = []string{strconv.FormatBool(t)}
+	default:
+		node.values = []string{"null"}
+	}
+	return node, nil
+}
+
+// add merges child into the member key of the object node
+func (n *concatNode) add(key string, child *concatNode, collect bool) {
+	if existing, ok := n.children[key]; ok {
+		existing.merge(child, collect)
+		return
+	}
+	n.keys = append(n.keys, key)
+	n.children[key] = child
+}
+
+// merge merges src into n. Objects are merged key by key; otherwise src replaces n, or with
+// collect its values are appended to those of n unless already present.
+func (n *concatNode) merge(src *concatNode, collect bool) {
+	empty := n.children == nil && len(n.values) == 0
+	switch {
+	case src.children != nil && (n.children != nil || empty):
+		if n.children == nil {
+			n.children = make(map[string]*concatNode)
+		}
+		for _, key := range src.keys {
+			n.add(key, src.children[key], collect)
+		}
+	case !collect || empty:
+		*n = *src
+	default:
+		values := n.values
+		if n.children != nil {
+			values = []string{n.render()}
+		}
+		srcValues := src.values
+		if src.children != nil {
+			srcValues = []string{src.render()}
+		}
+	next:
+		for _, value := range srcValues {
+			for _, seen := range values {
+				if seen == value {
+					continue next
+				}
+			}
+			values = append(values, value)
+		}
+		*n = concatNode{values: values}
+	}
+}
+
+// render returns n as compact JSON
+func (n *concatNode) render() string {
+	if n.children != nil {
+		members := make([]string, len(n.keys))
+		for idx, key := range n.keys {
+			members[idx] = encodeJSONString(key) + ":" + n.children[key].render()
+		}
+		return "{" + strings.Join(members, ",") + "}"
+	}
+	if len(n.values) == 1 {
+		return n.values[0]
+	}
+	return "[" + strings.Join(n.values, ",") + "]"
+}
+
+// RepairPythonLiteral converts a Python literal such as repr() output of a dict to JSON. On top
+// of the usual repairs (single quotes, None/True/False), tuples and sets become arrays.
+func RepairPythonLiteral(text string) (string, error) {
+	return JSONRepairWithOptions(text, RepairOptions{PythonLiterals: true})
+}
+
+// JSONRepairArrayTo repairs text like JSONRepairWithOptions and writes the result to w.
+// When the document is a top-level array, each element is written to w as soon as it has
+// been repaired instead of building the whole output in memory, so peak memory is the
+// input (held as runes) plus the largest single element. Other documents, and any document
+// when DuplicateKeys or FoldTrailingPairs is set (both rewrite the output as a whole), are
+// repaired in one piece. The written output is identical to JSONRepairWithOptions; on error,
+// the elements repaired so far may already have been written.
+func JSONRepairArrayTo(w io.Writer, text string, opts RepairOptions) error {
+	writeWhole := func() error {
+		repaired, err := JSONRepairWithOptions(text, opts)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, repaired)
+		return err
+	}
+	if opts.DuplicateKeys != "" || opts.FoldTrailingPairs {
+		return writeWhole()
+	}
+
+	prepared, err := prepareRepairInput(text, opts)
+	if err != nil {
+		return err
+	}
+	runes := []rune(prepared)
+	if opts.AllowEmpty && isBlankInput(runes, &opts) {
+		return nil
+	}
+	i := 0
+	var output strings.Builder
+
+	parseMarkdownCodeBlock(&runes, &i, []string{"```", "[```", "{```"}, &output, &opts)
+	parseWhitespaceAndSkipComments(&runes, &i, &output, true, &opts)
+	if i >= len(runes) || runes[i] != codeOpeningBracket {
+		return writeWhole()
+	}
+
+	// Keep trailing whitespace buffered: parseArray inserts commas and brackets before it
+	flush := func(output *strings.Builder) error {
+		s := output.String()
+		end := len(s)
+		for end > 0 && isWhitespace(rune(s[end-1])) {
+			end--
+		}
+		if _, err := io.WriteString(w, s[:end]); err != nil {
+			return err
+		}
+		output.Reset()
+		output.WriteString(s[end:])
+		return nil
+	}
+	if _, err := parseArrayWithFlush(&runes, &i, &output, &opts, flush); err != nil {
+		return err
+	}
+	parseWhitespaceAndSkipComments(&runes, &i, &output, true, &opts)
+	parseMarkdownCodeBlock(&runes, &i, []string{"```", "```]", "```}"}, &output, &opts)
+
+	_, err = io.WriteString(w, output.String())
+	return err
+}
+
+// JSONRepairFencedBlocks repairs every ```json (or bare ```) fenced block of a markdown
+// document and returns the results in document order. Blocks tagged with another language
+// are ignored. If the document has no fences, it is repaired as a whole.
+func JSONRepairFencedBlocks(text string, opts RepairOptions) ([]string, error) {
+	blocks := extractFencedBlocks(text)
+	if len(blocks) == 0 {
+		blocks = []string{text}
+	}
+	opts.ExtractFencedBlock = false
+	repaired := make([]string, 0, len(blocks))
+	for idx, block := range blocks {
+		result, err := JSONRepairWithOptions(block, opts)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", idx, err)
+		}
+		repaired = append(repaired, result)
+	}
+	return repaired, nil
+}
+
+// InvalidUTF8Offsets returns the byte offset of every invalid UTF-8 sequence in s
+func InvalidUTF8Offsets(s string) []int {
+	var offsets []int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size <= 1 {
+			offsets = append(offsets, i)
+			size = 1
+		}
+		i += size
+	}
+	return offsets
+}
+
+// formatOffsets lists at most limit offsets separated by commas, followed by "(+N)" for the rest
+func formatOffsets(offsets []int, limit int) string {
+	shown := offsets
+	if len(shown) > limit {
+		shown = shown[:limit]
+	}
+	parts := make([]string, len(shown))
+	for idx, offset := range shown {
+		parts[idx] = strconv.Itoa(offset)
+	}
+	result := strings.Join(parts, ", ")
+	if len(offsets) > len(shown) {
+		result += fmt.Sprintf(" (+%d)", len(offsets)-len(shown))
+	}
+	return result
+}
+
+// ReplaceInvalidUTF8 replaces each invalid UTF-8 byte with U+FFFD and returns the
+// result together with the number of replacements
+func ReplaceInvalidUTF8(s string) (string, int) {
+	var sb strings.Builder
+	replaced := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size <= 1 {
+			sb.WriteRune(utf8.RuneError)
+			replaced++
+			i++
+			continue
+		}
+		sb.WriteString(s[i : i+size])
+		i += size
+	}
+	return sb.String(), replaced
+}
+
+// JSONRepairUnmarshal repairs the given JSON string and unmarshals the result into v.
+// Repair failures are returned wrapping *Error, unmarshal failures wrap the encoding/json error.
+func JSONRepairUnmarshal(text string, v interface{}, opts RepairOptions) error {
+	repaired, err := JSONRepairWithOptions(text, opts)
+	if err != nil {
+		return fmt.Errorf("repair json: %w", err)
+	}
+	if err := json.Unmarshal([]byte(repaired), v); err != nil {
+		return fmt.Errorf("unmarshal repaired json: %w", err)
+	}
+	return nil
+}
+
+// JSONRepairSequence splits a JSON text sequence (RFC 7464, records prefixed by 0x1E)
+// into records and repairs each one. Empty records are skipped.
+func JSONRepairSequence(text string, opts RepairOptions) ([]string, error) {
+	var records []string
+	for idx, record := range strings.Split(text, string(rune(codeRecordSeparator))) {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		repaired, err := JSONRepairWithOptions(strings.TrimSpace(record), opts)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", idx, err)
+		}
+		records = append(records, repaired)
+	}
+	return records, nil
+}
+
+// RepairStream repairs newline-delimited JSON read from r and writes one repaired record per
+// line to w as soon as each record is complete, so memory use is bounded by the largest record
+// rather than the whole input. Lines are collected into a window until the brackets opened by
+// the record are balanced; the window grows as long as needed when a record spans several
+// lines. A line starting with { or [ in the first column while a record is still open is
+// taken as the start of the next record, so one truncated record cannot swallow the rest of
+// the stream. Blank lines are skipped.
+func RepairStream(r io.Reader, w io.Writer, opts RepairOptions) error {
+	reader := bufio.NewReader(r)
+	var window strings.Builder
+	depth := 0
+	inString := false
+	escaped := false
+	startLine, lineNumber := 0, 0
+	// blank tracks whether the window holds only whitespace, so lines are not rescanned
+	blank := true
+
+	flush := func() error {
+		wasBlank := blank
+		record := window.String()
+		window.Reset()
+		depth, inString, escaped, blank = 0, false, false, true
+		if wasBlank {
+			return nil
+		}
+		record = strings.TrimSpace(record)
+		repaired, err := JSONRepairWithOptions(record, opts)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", startLine, err)
+		}
+		// Records that spanned several lines are compacted to keep one record per line
+		var compact bytes.Buffer
+		if json.Compact(&compact, []byte(repaired)) == nil {
+			repaired = compact.String()
+		}
+		_, err = io.WriteString(w, repaired+"\n")
+		return err
+	}
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if line != "" {
+			lineNumber++
+			if window.Len() > 0 && depth > 0 && (strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[")) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if blank {
+				startLine = lineNumber
+				blank = strings.TrimSpace(line) == ""
+			}
+			window.WriteString(line)
+			for _, char := range line {
+				switch {
+				case escaped:
+					escaped = false
+				case inString && char == codeBackslash:
+					escaped = true
+				case char == codeDoubleQuote:
+					inString = !inString
+				case inString:
+				case char == codeOpeningBrace || char == codeOpeningBracket:
+					depth++
+				case char == codeClosingBrace || char == codeClosingBracket:
+					depth--
+				}
+			}
+			// A newline inside a string means the quote was never closed on this line
+			inString = false
+			if depth <= 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return flush()
+		}
+	}
+}
+
 // ================================
 // PARSING FUNCTIONS
 // ================================
 
-func parseValue(text *[]rune, i *int, output *strings.Builder, trimWhitespace bool) (bool, error) {
-	parseWhitespaceAndSkipComments(text, i, output, true)
+func parseValue[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) (bool, error) {
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if opts.depth >= maxDepth {
+		return false, newMaxDepthExceededError(maxDepth, *i)
+	}
+	opts.depth++
+	defer func() { opts.depth-- }()
+
+	parseWhitespaceAndSkipComments(text, i, output, true, opts)
+
+	// Dates must be checked before objects, otherwise the colons of a timestamp
+	// would be taken for a braceless object
+	if parseUnquotedDate(text, i, output) {
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
+		return true, nil
+	}
+
+	if opts.PythonLiterals {
+		if processed, err := parsePythonCollection(text, i, output, opts); err != nil || processed {
+			parseWhitespaceAndSkipComments(text, i, output, true, opts)
+			return processed, err
+		}
+	}
 
 	iBeforeObj := *i
 	oBeforeObj := output.Len()
-	if processedObj, err := parseObject(text, i, output, trimWhitespace); err != nil {
+	reportBeforeObj := opts.reportMark()
+	if processedObj, err := parseObject(text, i, output, opts); err != nil {
 		return false, err
 	} else if processedObj {
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		return true, nil
 	}
 	*i = iBeforeObj
-	outputStr := output.String()
-	if len(outputStr) > oBeforeObj {
-		output.Reset()
-		output.WriteString(outputStr[:oBeforeObj])
-	}
+	opts.reportRollback(reportBeforeObj)
+	truncateOutput(output, oBeforeObj)
 
 	iBeforeMongo := *i
 	oBeforeMongo := output.Len()
-	if *i < len(*text) && isFunctionNameCharStart((*text)[*i]) {
+	if *i < len(*text) && isFunctionNameCharStart(rune((*text)[*i])) {
 		j := *i
-		for j < len(*text) && isFunctionNameChar((*text)[j]) {
+		for j < len(*text) && isFunctionNameChar(rune((*text)[j])) {
 			j++
 		}
-		name := string((*text)[*i:j])
+		name := textString((*text)[*i:j])
 		if name == "ObjectId" || name == "NumberLong" || name == "NumberInt" || name == "ISODate" || name == "BinData" {
 			k := j
-			for k < len(*text) && isWhitespace((*text)[k]) {
+			for k < len(*text) && isWhitespace(rune((*text)[k])) {
 				k++
 			}
 			if k >= len(*text) || (*text)[k] != codeOpenParenthesis {
 				*i = k
 				var innerValue strings.Builder
-				if parseUnquotedString(text, i, &innerValue, trimWhitespace) {
+				if parseUnquotedString(text, i, &innerValue, opts) {
 					fmt.Fprintf(output, `"%s"`, name)
 					val := innerValue.String()
 					if strings.HasPrefix(val, "\"") && strings.HasSuffix(val, "\"") {
@@ -250,38 +1420,38 @@ func parseValue(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 		}
 	}
 	*i = iBeforeMongo
-	output.Reset()
-	output.WriteString(outputStr[:oBeforeMongo])
+	truncateOutput(output, oBeforeMongo)
 
-	processed, err := parseArray(text, i, output, trimWhitespace)
+	processed, err := parseArray(text, i, output, opts)
 	if err != nil {
 		return false, err
 	}
 	if !processed {
-		stringProcessed, err := parseString(text, i, output, false, -1, trimWhitespace)
+		stringProcessed, err := parseString(text, i, output, false, -1, opts)
 		if err != nil {
 			return false, err
 		}
 		processed = stringProcessed
 		if !processed {
-			processed = parseNumber(text, i, output) ||
+			processed = parseNumberWithUnit(text, i, output, opts) ||
+				parseNumber(text, i, output, opts) ||
 				parseKeywords(text, i, output) ||
-				parseUnquotedString(text, i, output, trimWhitespace) ||
-				parseRegex(text, i, output)
+				parseRegex(text, i, output) ||
+				parseUnquotedString(text, i, output, opts)
 		}
 	}
-	parseWhitespaceAndSkipComments(text, i, output, true)
+	parseWhitespaceAndSkipComments(text, i, output, true, opts)
 
 	return processed, nil
 }
 
-func parseWhitespaceAndSkipComments(text *[]rune, i *int, output *strings.Builder, skipNewline bool) bool {
+func parseWhitespaceAndSkipComments[T textChar](text *[]T, i *int, output *strings.Builder, skipNewline bool, opts *RepairOptions) bool {
 	start := *i
-	parseWhitespace(text, i, output, skipNewline)
+	parseWhitespace(text, i, output, skipNewline, opts)
 	for {
-		changed := parseComment(text, i)
+		changed := parseComment(text, i, opts)
 		if changed {
-			changed = parseWhitespace(text, i, output, skipNewline)
+			changed = parseWhitespace(text, i, output, skipNewline, opts)
 		}
 		if !changed {
 			break
@@ -290,16 +1460,18 @@ func parseWhitespaceAndSkipComments(text *[]rune, i *int, output *strings.Builde
 	return *i > start
 }
 
-func parseWhitespace(text *[]rune, i *int, output *strings.Builder, skipNewline bool) bool {
+func parseWhitespace[T textChar](text *[]T, i *int, output *strings.Builder, skipNewline bool, opts *RepairOptions) bool {
 	start := *i
 	whitespace := strings.Builder{}
 	isW := isWhitespace
 	if !skipNewline {
 		isW = isWhitespaceExceptNewline
 	}
-	for *i < len(*text) && (isW((*text)[*i]) || isSpecialWhitespace((*text)[*i])) {
-		if !isSpecialWhitespace((*text)[*i]) {
-			whitespace.WriteRune((*text)[*i])
+	for *i < len(*text) && (isW(rune((*text)[*i])) || isSpecialWhitespace(rune((*text)[*i])) || opts.skipsMark(rune((*text)[*i]))) {
+		if opts.skipsMark(rune((*text)[*i])) {
+			opts.record("removed-invisible-mark", *i, fmt.Sprintf("removed invisible character U+%04X", (*text)[*i]))
+		} else if !isSpecialWhitespace(rune((*text)[*i])) {
+			whitespace.WriteRune(rune((*text)[*i]))
 		} else {
 			whitespace.WriteRune(' ') // repair special whitespace
 		}
@@ -312,9 +1484,10 @@ func parseWhitespace(text *[]rune, i *int, output *strings.Builder, skipNewline
 	return *i > start
 }
 
-func parseComment(text *[]rune, i *int) bool {
+func parseComment[T textChar](text *[]T, i *int, opts *RepairOptions) bool {
 	if *i+1 < len(*text) {
 		if (*text)[*i] == codeSlash && (*text)[*i+1] == codeAsterisk {
+			opts.record("removed-comment", *i, "removed block comment")
 			for *i < len(*text) && !atEndOfBlockComment(text, i) {
 				*i++
 			}
@@ -325,30 +1498,47 @@ func parseComment(text *[]rune, i *int) bool {
 		} else if (*text)[*i] == codeSlash && (*text)[*i+1] == codeSlash {
 			if *i > 0 && (*text)[*i-1] == codeColon {
 				j := *i - 2
-				for j >= 0 && (isLetter((*text)[j])) {
+				for j >= 0 && (isLetter(rune((*text)[j]))) {
 					j--
 				}
-				protocol := string((*text)[j+1 : *i-1])
+				protocol := textString((*text)[j+1 : *i-1])
 				if protocol == "http" || protocol == "https" || protocol == "ftp" {
 					return false
 				}
 			}
+			opts.record("removed-comment", *i, "removed line comment")
 			for *i < len(*text) && (*text)[*i] != codeNewline && (*text)[*i] != codeReturn {
 				*i++
 			}
 			return true
 		}
 	}
+	if isHashComment(text, *i, opts) {
+		opts.record("removed-comment", *i, "removed # comment")
+		for *i < len(*text) && (*text)[*i] != codeNewline && (*text)[*i] != codeReturn {
+			*i++
+		}
+		return true
+	}
 	return false
 }
 
-func lookAheadForColon(text *[]rune, i int) bool {
+// isHashComment reports whether a # line comment starts at i when RepairOptions.HashComments
+// is set
+func isHashComment[T textChar](text *[]T, i int, opts *RepairOptions) bool {
+	if opts == nil || !opts.HashComments || i >= len(*text) || (*text)[i] != codeHash {
+		return false
+	}
+	return i == 0 || isWhitespace(rune((*text)[i-1])) || isSpecialWhitespace(rune((*text)[i-1]))
+}
+
+func lookAheadForColon[T textChar](text *[]T, i int, opts *RepairOptions) bool {
 	j := i
 	if j < len(*text) && ((*text)[j] == codeNewline || (*text)[j] == codeReturn) {
 		j++
 	}
 	for j < len(*text) {
-		if isWhitespace((*text)[j]) || isSpecialWhitespace((*text)[j]) {
+		if isWhitespace(rune((*text)[j])) || isSpecialWhitespace(rune((*text)[j])) {
 			j++
 			continue
 		}
@@ -371,26 +1561,32 @@ func lookAheadForColon(text *[]rune, i int) bool {
 				continue
 			}
 		}
+		if isHashComment(text, j, opts) {
+			for j < len(*text) && (*text)[j] != codeNewline && (*text)[j] != codeReturn {
+				j++
+			}
+			continue
+		}
 		break
 	}
 	hasKey := false
-	for j < len(*text) && !isDelimiter((*text)[j]) && !isQuote((*text)[j]) {
-		if (*text)[j] == codeColon || (*text)[j] == codeEqual {
+	for j < len(*text) && !isDelimiter(rune((*text)[j])) && !isQuote(rune((*text)[j])) {
+		if isKeyValueSeparator(text, j) {
 			return hasKey
 		}
-		if !isWhitespace((*text)[j]) {
+		if !isWhitespace(rune((*text)[j])) {
 			hasKey = true
 		}
 		j++
 	}
-	if j < len(*text) && isQuote((*text)[j]) {
+	if j < len(*text) && isQuote(rune((*text)[j])) {
 		j++
-		for j < len(*text) && !isQuote((*text)[j]) {
+		for j < len(*text) && !isQuote(rune((*text)[j])) {
 			j++
 		}
-		if j < len(*text) && isQuote((*text)[j]) {
+		if j < len(*text) && isQuote(rune((*text)[j])) {
 			j++
-			for j < len(*text) && isWhitespace((*text)[j]) {
+			for j < len(*text) && isWhitespace(rune((*text)[j])) {
 				j++
 			}
 			if j < len(*text) && (*text)[j] == codeColon {
@@ -401,54 +1597,76 @@ func lookAheadForColon(text *[]rune, i int) bool {
 	return false
 }
 
-func parseCharacter(text *[]rune, i *int, output *strings.Builder, code rune) bool {
-	if *i < len(*text) && (*text)[*i] == code {
-		output.WriteRune((*text)[*i])
+// parseKeyValueSeparator consumes a key/value separator (":", "=", "=>" and, with
+// LenientSeparators, "->" or ":=") and writes it as ":"
+func parseKeyValueSeparator[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
+	n := keySeparatorLength(text, *i, opts)
+	if n == 0 {
+		return false
+	}
+	if (*text)[*i] == codeColon && n == 1 {
+		output.WriteRune(codeColon)
+	} else if (*text)[*i] == codeColon {
+		opts.record("replaced-separator", *i, "replaced "+textString((*text)[*i:*i+n])+" with a colon")
+		output.WriteRune(codeColon)
+	} else {
+		opts.record("replaced-separator", *i, "replaced "+textString((*text)[*i:*i+n])+" with a colon")
+		outputStr := insertBeforeLastWhitespace(output.String(), ":")
+		output.Reset()
+		output.WriteString(outputStr)
+	}
+	*i += n
+	return true
+}
+
+func parseCharacter[T textChar](text *[]T, i *int, output *strings.Builder, code rune) bool {
+	if *i < len(*text) && rune((*text)[*i]) == code {
+		output.WriteRune(rune((*text)[*i]))
 		*i++
 		return true
 	}
 	return false
 }
 
-func skipCharacter(text *[]rune, i *int, code rune) bool {
-	if *i < len(*text) && (*text)[*i] == code {
+func skipCharacter[T textChar](text *[]T, i *int, code rune) bool {
+	if *i < len(*text) && rune((*text)[*i]) == code {
 		*i++
 		return true
 	}
 	return false
 }
 
-func skipEscapeCharacter(text *[]rune, i *int) bool {
+func skipEscapeCharacter[T textChar](text *[]T, i *int) bool {
 	return skipCharacter(text, i, codeBackslash)
 }
 
-func skipEllipsis(text *[]rune, i *int, output *strings.Builder) bool {
-	parseWhitespaceAndSkipComments(text, i, output, true)
+func skipEllipsis[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
+	parseWhitespaceAndSkipComments(text, i, output, true, opts)
 	if *i+2 < len(*text) &&
 		(*text)[*i] == codeDot &&
 		(*text)[*i+1] == codeDot &&
 		(*text)[*i+2] == codeDot {
 		*i += 3
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		skipCharacter(text, i, codeComma)
 		return true
 	}
 	return false
 }
 
-func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace bool) (bool, error) {
+func parseObject[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) (bool, error) {
 	if *i >= len(*text) {
 		return false, nil
 	}
 	if (*text)[*i] == codeOpeningBrace {
-		output.WriteRune((*text)[*i])
+		output.WriteRune(rune((*text)[*i]))
 		*i++
 	} else {
 		iBefore := *i
 		var tempOutput strings.Builder
-		parseWhitespaceAndSkipComments(text, i, &tempOutput, true)
-		stringProcessed, _ := parseString(text, i, &tempOutput, false, -1, trimWhitespace)
-		processedKey := stringProcessed || parseUnquotedStringWithMode(text, i, &tempOutput, true, trimWhitespace)
+		parseWhitespaceAndSkipComments(text, i, &tempOutput, true, opts)
+		stringProcessed, _ := parseString(text, i, &tempOutput, false, -1, opts)
+		processedKey := stringProcessed || parseUnquotedStringWithMode(text, i, &tempOutput, true, opts)
 		if processedKey {
 			key := strings.Trim(strings.TrimSpace(tempOutput.String()), "\"")
 			// Strictness check for braceless objects:
@@ -465,8 +1683,9 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 				*i = iBefore
 				return false, nil
 			}
-			parseWhitespaceAndSkipComments(text, i, &tempOutput, true)
-			if *i < len(*text) && ((*text)[*i] == codeColon || (*text)[*i] == codeEqual) {
+			parseWhitespaceAndSkipComments(text, i, &tempOutput, true, opts)
+			if isKeyValueSeparator(text, *i) {
+				opts.record("opened-brace", iBefore, "inserted missing opening brace")
 				output.WriteRune('{')
 				*i = iBefore
 			} else {
@@ -478,13 +1697,13 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 			return false, nil
 		}
 	}
-	parseWhitespaceAndSkipComments(text, i, output, true)
+	parseWhitespaceAndSkipComments(text, i, output, true, opts)
 	if skipCharacter(text, i, codeComma) {
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 	}
 	initial := true
 	for {
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		if *i >= len(*text) || (*text)[*i] == codeClosingBrace {
 			break
 		}
@@ -494,9 +1713,12 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 			oBefore := output.Len()
 			processedComma := parseCharacter(text, i, output, codeComma)
 			if processedComma {
-				parseWhitespaceAndSkipComments(text, i, output, true)
+				parseWhitespaceAndSkipComments(text, i, output, true, opts)
+				// Unlike in arrays, doubled commas between members are collapsed: there is no
+				// position to keep, so {"a":1,,"b":2} gets no phantom member
 				for skipCharacter(text, i, codeComma) {
-					parseWhitespaceAndSkipComments(text, i, output, true)
+					opts.record("removed-comma", *i-1, "removed duplicate comma")
+					parseWhitespaceAndSkipComments(text, i, output, true, opts)
 				}
 				temp := output.String()
 				if strings.HasSuffix(temp, ",") {
@@ -518,14 +1740,15 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 				// Check if we're at the start of a new key without a comma
 				isNewKey := false
 				j := *i
-				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
+				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
 				if j < len(*text) {
 					char := (*text)[j]
-					if isQuote(char) || isLetter(char) {
+					if isQuote(rune(char)) || isLetter(rune(char)) {
 						isNewKey = true
 					}
 				}
 
+				opts.record("inserted-comma", *i, "inserted missing comma between object members")
 				if isNewKey {
 					outputStr := insertBeforeLastWhitespace(output.String(), ",")
 					output.Reset()
@@ -540,14 +1763,14 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 		} else {
 			initial = false
 		}
-		skipEllipsis(text, i, output)
+		skipEllipsis(text, i, output, opts)
 		iKeyStart := *i
 		var keyOutput strings.Builder
-		stringProcessed, err := parseString(text, i, &keyOutput, false, -1, trimWhitespace)
+		stringProcessed, err := parseString(text, i, &keyOutput, false, -1, opts)
 		if err != nil {
 			return false, err
 		}
-		processedKey := stringProcessed || parseUnquotedStringWithMode(text, i, &keyOutput, true, trimWhitespace)
+		processedKey := stringProcessed || parseUnquotedStringWithMode(text, i, &keyOutput, true, opts)
 		if !processedKey {
 			// Check if we have a stray comma before a closing brace
 			outputStr := output.String()
@@ -555,6 +1778,7 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 			if lastCommaIdx != -1 {
 				contentAfterLastComma := outputStr[lastCommaIdx+1:]
 				if strings.TrimSpace(contentAfterLastComma) == "" {
+					opts.record("removed-trailing-comma", *i, "removed trailing comma")
 					output.Reset()
 					output.WriteString(outputStr[:lastCommaIdx] + contentAfterLastComma)
 				}
@@ -562,7 +1786,7 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 
 			// If we just skipped a comma, we might be at the end of the object
 			j := *i
-			parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
+			parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
 			if j < len(*text) && (*text)[j] == codeClosingBrace {
 				*i = j
 				break
@@ -571,7 +1795,25 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 
 		if processedKey {
 			key := keyOutput.String()
-			if trimWhitespace {
+			if stringProcessed && opts.KeyControlChars != "" {
+				if pos := controlCharacterIndex(*text, iKeyStart, *i); pos != -1 {
+					if opts.KeyControlChars == "error" {
+						return false, newInvalidCharacterError("Control character in object key", pos)
+					}
+					var stripped []rune
+					for _, char := range (*text)[iKeyStart:*i] {
+						if !isControlCharacter(rune(char)) {
+							stripped = append(stripped, rune(char))
+						}
+					}
+					var strippedKey strings.Builder
+					j := 0
+					if ok, err := parseString(&stripped, &j, &strippedKey, false, -1, opts); err == nil && ok {
+						key = strippedKey.String()
+					}
+				}
+			}
+			if opts.TrimWhitespace {
 				// Remove quotes, trim, then re-add quotes
 				if strings.HasPrefix(key, "\"") && strings.HasSuffix(key, "\"") {
 					inner := key[1 : len(key)-1]
@@ -588,6 +1830,16 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 				*i = iKeyStart
 				return false, nil
 			}
+			if !stringProcessed {
+				opts.record("quoted-key", iKeyStart, "added quotes around key "+keyTrimmed)
+			}
+			if opts.EscapeKeyDots {
+				var name string
+				if json.Unmarshal([]byte(strings.TrimSpace(key)), &name) == nil && strings.ContainsAny(name, ".\\") {
+					opts.record("escaped-key-dots", iKeyStart, "escaped dots in key "+keyTrimmed)
+					key = encodeJSONString(escapeKeySegment(name, "."))
+				}
+			}
 			output.WriteString(key)
 		}
 		if !processedKey {
@@ -597,6 +1849,9 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 				(*text)[*i] == codeClosingBracket ||
 				(*text)[*i] == codeOpeningBracket ||
 				(*text)[*i] == 0 {
+				if strings.HasSuffix(strings.TrimSpace(output.String()), ",") {
+					opts.record("removed-trailing-comma", *i, "removed trailing comma")
+				}
 				outputStr := stripLastOccurrence(output.String(), ",", false)
 				output.Reset()
 				output.WriteString(outputStr)
@@ -605,32 +1860,33 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 			}
 			break
 		}
-		parseWhitespaceAndSkipComments(text, i, output, true)
-		for parseComment(text, i) {
-			parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
+		for parseComment(text, i, opts) {
+			parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		}
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		iBeforeColon := *i
-		processedColon := parseCharacter(text, i, output, codeColon)
+		processedColon := parseKeyValueSeparator(text, i, output, opts)
 		if !processedColon {
-			// Check if we have a colon after some whitespace
+			// Check if we have a separator after some whitespace
 			j := *i
-			parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
-			for parseComment(text, &j) {
-				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
+			parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
+			for parseComment(text, &j, opts) {
+				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
 			}
-			if j < len(*text) && (*text)[j] == codeColon {
+			if keySeparatorLength(text, j, opts) > 0 {
 				*i = j
-				processedColon = parseCharacter(text, i, output, codeColon)
+				processedColon = parseKeyValueSeparator(text, i, output, opts)
 			} else {
 				// Special case: "name" "value" (missing colon)
 				// Look ahead to see if there's a value starting
 				k := iBeforeColon
-				parseWhitespaceAndSkipComments(text, &k, &strings.Builder{}, true)
-				for parseComment(text, &k) {
-					parseWhitespaceAndSkipComments(text, &k, &strings.Builder{}, true)
+				parseWhitespaceAndSkipComments(text, &k, &strings.Builder{}, true, opts)
+				for parseComment(text, &k, opts) {
+					parseWhitespaceAndSkipComments(text, &k, &strings.Builder{}, true, opts)
 				}
-				if k < len(*text) && (isQuote((*text)[k]) || isLetter((*text)[k]) || isDigit((*text)[k]) || (*text)[k] == codeOpeningBrace || (*text)[k] == codeOpeningBracket) {
+				if k < len(*text) && (isQuote(rune((*text)[k])) || isLetter(rune((*text)[k])) || isDigit(rune((*text)[k])) || (*text)[k] == codeOpeningBrace || (*text)[k] == codeOpeningBracket) {
+					opts.record("inserted-colon", iBeforeColon, "inserted missing colon after key")
 					outputStr := insertBeforeLastWhitespace(output.String(), ":")
 					output.Reset()
 					output.WriteString(outputStr)
@@ -640,20 +1896,15 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 			}
 		}
 		if processedColon {
-			parseWhitespaceAndSkipComments(text, i, output, true)
+			parseWhitespaceAndSkipComments(text, i, output, true, opts)
 			for skipCharacter(text, i, codeColon) {
-				parseWhitespaceAndSkipComments(text, i, output, true)
+				parseWhitespaceAndSkipComments(text, i, output, true, opts)
 			}
 		}
-		if !processedColon && skipCharacter(text, i, codeEqual) {
-			outputStr := insertBeforeLastWhitespace(output.String(), ":")
-			output.Reset()
-			output.WriteString(outputStr)
-			processedColon = true
-		}
 		truncatedText := *i >= len(*text)
 		if !processedColon {
 			if truncatedText {
+				opts.record("inserted-colon", *i, "inserted missing colon after key")
 				outputStr := insertBeforeLastWhitespace(output.String(), ":")
 				output.Reset()
 				output.WriteString(outputStr)
@@ -662,28 +1913,45 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 				return false, newColonExpectedError(*i)
 			}
 		}
-		for parseComment(text, i) {
+		for parseComment(text, i, opts) {
 		}
-		parseWhitespaceAndSkipComments(text, i, output, true)
-		processedValue, err := parseValue(text, i, output, trimWhitespace)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
+		iValueStart := *i
+		oValueStart := output.Len()
+		processedValue, err := parseValue(text, i, output, opts)
 		if err != nil {
 			return false, err
 		}
+		if written := strings.TrimSpace(output.String()[oValueStart:]); processedValue && written != "" && written[0] != '{' && written[0] != '[' {
+			if end := unquotedPhraseEnd(text, iValueStart, *i, opts); end != -1 {
+				opts.record("quoted-phrase", iValueStart, "joined the words of an unquoted value into one string")
+				truncateOutput(output, oValueStart)
+				if opts.KeysOnly {
+					output.WriteString(textString((*text)[iValueStart:end]))
+				} else {
+					output.WriteString(encodeJSONString(textString((*text)[iValueStart:end])))
+				}
+				*i = end
+				parseWhitespaceAndSkipComments(text, i, output, true, opts)
+			}
+		}
 		if !processedValue {
 			if processedColon || truncatedText {
+				opts.record("inserted-null", *i, "inserted null for missing value")
 				output.WriteString("null")
 			} else {
 				return false, nil
 			}
 		}
-		for parseComment(text, i) {
+		for parseComment(text, i, opts) {
 		}
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 	}
 	if *i < len(*text) && (*text)[*i] == codeClosingBrace {
-		output.WriteRune((*text)[*i])
+		output.WriteRune(rune((*text)[*i]))
 		*i++
 	} else {
+		opts.record("closed-brace", *i, "inserted missing closing brace")
 		outputStr := insertBeforeLastWhitespace(output.String(), "}")
 		output.Reset()
 		output.WriteString(outputStr)
@@ -691,20 +1959,99 @@ func parseObject(text *[]rune, i *int, output *strings.Builder, trimWhitespace b
 	return true, nil
 }
 
-func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bool) (bool, error) {
+// unquotedPhraseEnd returns where an unquoted member value that goes on with more words on the
+// same line ends, as in title: The Great Gatsby, or -1 when the value parsed from start to i is
+// complete. Only a value starting with an unquoted word is joined: a number or a keyword such
+// as true or null followed by more text is left alone. The phrase stops before a delimiter, a
+// quote, a comment or the key of the next member, i.e. a word followed by a colon.
+func unquotedPhraseEnd[T textChar](text *[]T, start int, i int, opts *RepairOptions) int {
+	if start >= len(*text) || !(isFunctionNameCharStart(rune((*text)[start])) || unicode.IsLetter(rune((*text)[start]))) {
+		return -1
+	}
+	end := i
+	for end > start && isWhitespace(rune((*text)[end-1])) {
+		end--
+	}
+	switch textString((*text)[start:end]) {
+	case "true", "false", "null", "True", "False", "None", "undefined":
+		return -1
+	}
+	for j := start; j < end; j++ {
+		if (*text)[j] == codeNewline || (*text)[j] == codeReturn {
+			return -1
+		}
+	}
+
+	phraseEnd := -1
+	for j := end; ; {
+		k := j
+		for k < len(*text) && isWhitespace(rune((*text)[k])) && (*text)[k] != codeNewline && (*text)[k] != codeReturn {
+			k++
+		}
+		if k == j {
+			break
+		}
+		wordStart := k
+		for k < len(*text) && !isPhraseBoundary(text, k, opts) {
+			k++
+		}
+		if k == wordStart {
+			break
+		}
+		next := k
+		for next < len(*text) && isWhitespaceExceptNewline(rune((*text)[next])) {
+			next++
+		}
+		if next < len(*text) && isKeyValueSeparator(text, next) {
+			break
+		}
+		phraseEnd = k
+		j = k
+	}
+	return phraseEnd
+}
+
+// isPhraseBoundary reports whether the word of an unquoted phrase ends at i
+func isPhraseBoundary[T textChar](text *[]T, i int, opts *RepairOptions) bool {
+	char := (*text)[i]
+	switch {
+	case isWhitespace(rune(char)) || isQuote(rune(char)) || opts.skipsMark(rune(char)):
+		return true
+	case char == codeComma || char == codeOpeningBrace || char == codeClosingBrace ||
+		char == codeOpeningBracket || char == codeClosingBracket:
+		return true
+	case char == codeSlash && i+1 < len(*text) && ((*text)[i+1] == codeSlash || (*text)[i+1] == codeAsterisk):
+		return true
+	case isKeyValueSeparator(text, i):
+		return !isURLStart(text, i)
+	}
+	return false
+}
+
+func parseArray[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) (bool, error) {
+	return parseArrayWithFlush(text, i, output, opts, nil)
+}
+
+// parseArrayWithFlush parses an array and, when flush is not nil, calls it after every
+// element so the caller can move the completed part of output elsewhere
+func parseArrayWithFlush[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions, flush func(output *strings.Builder) error) (bool, error) {
 	if *i >= len(*text) {
 		return false, nil
 	}
 	if (*text)[*i] == codeOpeningBracket {
-		output.WriteRune((*text)[*i])
+		output.WriteRune(rune((*text)[*i]))
 		*i++
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		initial := true
+		// A key:value element is only taken for an object that lost its opening brace when it
+		// comes first or follows another such element; otherwise it belongs to the enclosing
+		// object of an unclosed array
+		bracelessAllowed := true
 		for *i < len(*text) && (*text)[*i] != codeClosingBracket {
 			if !initial {
 				iBefore := *i
 				oBefore := output.Len()
-				parseWhitespaceAndSkipComments(text, i, output, true)
+				parseWhitespaceAndSkipComments(text, i, output, true, opts)
 
 				// Before checking for comma, check if we're at the start of a new value
 				// without a comma (missing comma)
@@ -712,15 +2059,16 @@ func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 				if *i < len(*text) {
 					char := (*text)[*i]
 					// If it's a quote, brace, bracket, number, or start of unquoted string
-					if isQuote(char) || char == codeOpeningBrace || char == codeOpeningBracket ||
-						isDigit(char) || char == codeMinus || char == codePlus ||
-						isLetter(char) {
+					if isQuote(rune(char)) || char == codeOpeningBrace || char == codeOpeningBracket ||
+						isDigit(rune(char)) || char == codeMinus || char == codePlus ||
+						isLetter(rune(char)) {
 						isNewValue = true
 					}
 				}
 
 				processedComma := parseCharacter(text, i, output, codeComma)
 				if !processedComma {
+					opts.record("inserted-comma", *i, "inserted missing comma between array elements")
 					if isNewValue {
 						// Missing comma between array elements
 						outputStr := insertBeforeLastWhitespace(output.String(), ",")
@@ -728,26 +2076,32 @@ func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 						output.WriteString(outputStr)
 					} else {
 						*i = iBefore
-						tempStr := output.String()
-						output.Reset()
-						output.WriteString(tempStr[:oBefore])
+						truncateOutput(output, oBefore)
 						outputStr := insertBeforeLastWhitespace(output.String(), ",")
 						output.Reset()
 						output.WriteString(outputStr)
 					}
 				} else {
 					for {
+						// Look ahead in a scratch builder: most elements are followed by a single
+						// comma, and cutting the whitespace back out of output copies all of it
 						iBeforeExtra := *i
-						oBeforeExtra := output.Len()
-						parseWhitespaceAndSkipComments(text, i, output, true)
-						if parseCharacter(text, i, output, codeComma) {
+						var extra strings.Builder
+						parseWhitespaceAndSkipComments(text, i, &extra, true, opts)
+						if parseCharacter(text, i, &extra, codeComma) {
+							output.WriteString(extra.String())
 							outputStr := output.String()
 							lastCommaIdx := strings.LastIndex(outputStr, ",")
 							if lastCommaIdx != -1 {
 								j := *i
-								parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
+								parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
+								// Every doubled comma leaves a hole that becomes null to keep the
+								// positions of later elements; only a final comma is dropped, so
+								// [1,,] has two elements like in JavaScript
+								opts.record("inserted-null", *i-1, "inserted null for empty array element")
 								if j < len(*text) && (*text)[j] == codeClosingBracket {
-									newOutput := outputStr[:lastCommaIdx]
+									opts.record("removed-trailing-comma", *i-1, "removed trailing comma")
+									newOutput := outputStr[:lastCommaIdx] + "null"
 									output.Reset()
 									output.WriteString(newOutput)
 								} else {
@@ -758,41 +2112,43 @@ func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 							}
 						} else {
 							*i = iBeforeExtra
-							currentOutput := output.String()
-							output.Reset()
-							output.WriteString(currentOutput[:oBeforeExtra])
 							break
 						}
 					}
 				}
 			} else {
 				initial = false
-				if skipCharacter(text, i, codeComma) {
+				for skipCharacter(text, i, codeComma) {
+					opts.record("inserted-null", *i-1, "inserted null for empty array element")
 					output.WriteString("null,")
+					parseWhitespaceAndSkipComments(text, i, &strings.Builder{}, true, opts)
 				}
 			}
-			parseWhitespaceAndSkipComments(text, i, output, true)
-			skipEllipsis(text, i, output)
+			parseWhitespaceAndSkipComments(text, i, output, true, opts)
+			skipEllipsis(text, i, output, opts)
 
 			// Before parsing value, check if this is actually a key for an outer object
 			if !initial {
 				j := *i
-				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
+				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
 				if j < len(*text) {
 					iTemp := j
 					var keyTemp strings.Builder
-					stringProcessed, _ := parseString(text, &iTemp, &keyTemp, false, -1, trimWhitespace)
-					processedKey := stringProcessed || parseUnquotedStringWithMode(text, &iTemp, &keyTemp, true, trimWhitespace)
+					stringProcessed, _ := parseString(text, &iTemp, &keyTemp, false, -1, opts)
+					processedKey := stringProcessed || parseUnquotedStringWithMode(text, &iTemp, &keyTemp, true, opts)
 
 					isOuterElement := false
+					isBraceless := false
 					if processedKey {
-						parseWhitespaceAndSkipComments(text, &iTemp, &strings.Builder{}, true)
-						if iTemp < len(*text) && ((*text)[iTemp] == codeColon || (*text)[iTemp] == codeEqual) {
-							isOuterElement = true
+						parseWhitespaceAndSkipComments(text, &iTemp, &strings.Builder{}, true, opts)
+						if isKeyValueSeparator(text, iTemp) {
+							isBraceless = bracelessAllowed && isBracelessElement(text, j)
+							isOuterElement = !isBraceless
 						}
 					} else if (*text)[j] == codeClosingBrace {
 						isOuterElement = true
 					}
+					bracelessAllowed = isBraceless
 
 					if isOuterElement {
 						outputStr := output.String()
@@ -809,16 +2165,22 @@ func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 				}
 			}
 
-			processedValue, err := parseValue(text, i, output, trimWhitespace)
+			processedValue, err := parseValue(text, i, output, opts)
 			if err != nil {
 				return false, err
 			}
+			if processedValue && flush != nil {
+				if err := flush(output); err != nil {
+					return false, err
+				}
+			}
 			if !processedValue {
 				outputStr := output.String()
 				lastCommaIdx := strings.LastIndex(outputStr, ",")
 				if lastCommaIdx != -1 {
 					contentAfterLastComma := outputStr[lastCommaIdx+1:]
 					if strings.TrimSpace(contentAfterLastComma) == "" {
+						opts.record("removed-trailing-comma", *i, "removed trailing comma")
 						output.Reset()
 						output.WriteString(outputStr[:lastCommaIdx] + contentAfterLastComma)
 					}
@@ -827,9 +2189,10 @@ func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 			}
 		}
 		if *i < len(*text) && (*text)[*i] == codeClosingBracket {
-			output.WriteRune((*text)[*i])
+			output.WriteRune(rune((*text)[*i]))
 			*i++
 		} else {
+			opts.record("closed-bracket", *i, "inserted missing closing bracket")
 			outputStr := insertBeforeLastWhitespace(output.String(), "]")
 			output.Reset()
 			output.WriteString(outputStr)
@@ -839,45 +2202,294 @@ func parseArray(text *[]rune, i *int, output *strings.Builder, trimWhitespace bo
 	return false, nil
 }
 
-func parseNewlineDelimitedJSON(text *[]rune, i *int, output *strings.Builder, trimWhitespace bool) {
-	initial := true
-	processedValue := true
-	for processedValue {
-		if !initial {
-			output.WriteRune('\n')
-		} else {
-			initial = false
+// pythonCollectionCalls are the Python constructors unwrapped in PythonLiterals mode
+var pythonCollectionCalls = map[string]bool{"set": true, "frozenset": true, "tuple": true, "list": true}
+
+// parsePythonCollection parses a Python tuple (1, 2), a set {1, 2} or a constructor call such
+// as set() or tuple([1, 2]) at i and writes it as a JSON array
+func parsePythonCollection[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) (bool, error) {
+	if *i >= len(*text) {
+		return false, nil
+	}
+	switch char := (*text)[*i]; {
+	case char == codeOpenParenthesis:
+		return true, parsePythonItems(text, i, output, codeCloseParenthesis, opts)
+	case char == codeOpeningBrace && isPythonSet(text, *i):
+		return true, parsePythonItems(text, i, output, codeClosingBrace, opts)
+	case isFunctionNameCharStart(rune(char)):
+		j := *i
+		for j < len(*text) && isFunctionNameChar(rune((*text)[j])) {
+			j++
 		}
-		var lineOutput strings.Builder
-		var err error
-		processedValue, err = parseValue(text, i, &lineOutput, trimWhitespace)
-		if err != nil {
+		if !pythonCollectionCalls[textString((*text)[*i:j])] || j >= len(*text) || (*text)[j] != codeOpenParenthesis {
+			return false, nil
+		}
+		*i = j + 1
+		parseWhitespaceAndSkipComments(text, i, &strings.Builder{}, true, opts)
+		if *i < len(*text) && (*text)[*i] == codeCloseParenthesis {
+			*i++
+			output.WriteString("[]")
+			return true, nil
+		}
+		if _, err := parseValue(text, i, output, opts); err != nil {
+			return false, err
+		}
+		skipCharacter(text, i, codeCloseParenthesis)
+		return true, nil
+	}
+	return false, nil
+}
+
+// parsePythonItems parses the comma separated items after the opening delimiter at i up to
+// closing as a JSON array. Trailing commas as in (1,) are dropped.
+func parsePythonItems[T textChar](text *[]T, i *int, output *strings.Builder, closing rune, opts *RepairOptions) error {
+	*i++
+	output.WriteRune(codeOpeningBracket)
+	count := 0
+	for {
+		parseWhitespaceAndSkipComments(text, i, &strings.Builder{}, true, opts)
+		if *i >= len(*text) {
+			opts.record("closed-bracket", *i, "inserted missing closing bracket")
+			break
+		}
+		if rune((*text)[*i]) == closing {
+			*i++
 			break
 		}
-		if processedValue {
-			output.WriteString(lineOutput.String())
+		if (*text)[*i] == codeComma {
+			*i++
+			continue
 		}
-		for *i < len(*text) && (*text)[*i] != codeNewline && (*text)[*i] != codeReturn {
+		if count > 0 {
+			output.WriteRune(codeComma)
+		}
+		var item strings.Builder
+		processed, err := parseValue(text, i, &item, opts)
+		if err != nil {
+			return err
+		}
+		if !processed {
+			// Skip a character the parser cannot use rather than stopping at it
 			*i++
+			continue
+		}
+		output.WriteString(strings.TrimSpace(item.String()))
+		count++
+	}
+	output.WriteRune(codeClosingBracket)
+	return nil
+}
+
+// isPythonSet reports whether the brace at i opens a Python set rather than a dict: its first
+// item is followed by a comma or the closing brace instead of a colon. {} is an empty dict.
+func isPythonSet[T textChar](text *[]T, i int) bool {
+	depth := 0
+	var quote rune
+	for j := i + 1; j < len(*text); j++ {
+		char := (*text)[j]
+		if quote != 0 {
+			if char == codeBackslash {
+				j++
+			} else if rune(char) == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case char == codeDoubleQuote || char == codeQuote:
+			quote = rune(char)
+		case char == codeOpenParenthesis || char == codeOpeningBracket || char == codeOpeningBrace:
+			depth++
+		case char == codeCloseParenthesis || char == codeClosingBracket || char == codeClosingBrace:
+			if depth == 0 {
+				return strings.TrimSpace(textString((*text)[i+1:j])) != ""
+			}
+			depth--
+		case depth == 0 && char == codeColon:
+			return false
+		case depth == 0 && char == codeComma:
+			return true
+		}
+	}
+	return false
+}
+
+// parseDoubledQuotedString parses a string wrapped in two pairs of double quotes, ""x"", as the
+// string "x". The pattern only applies when the opening pair is directly followed by content
+// and a closing pair on the same line is followed by a delimiter, so "" stays an empty string.
+func parseDoubledQuotedString[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
+	start := *i + 2
+	if start >= len(*text) || (*text)[*i] != codeDoubleQuote || (*text)[*i+1] != codeDoubleQuote {
+		return false
+	}
+	if char := (*text)[start]; isWhitespace(rune(char)) || isDelimiter(rune(char)) || isQuote(rune(char)) {
+		return false
+	}
+	for k := start; k < len(*text) && (*text)[k] != codeNewline; k++ {
+		switch (*text)[k] {
+		case codeBackslash:
+			k++
+		case codeDoubleQuote:
+			if k+1 >= len(*text) || (*text)[k+1] != codeDoubleQuote {
+				return false
+			}
+			j := k + 2
+			for j < len(*text) && isWhitespace(rune((*text)[j])) {
+				j++
+			}
+			if j < len(*text) && !isDelimiter(rune((*text)[j])) {
+				return false
+			}
+			inner := append(append([]T{codeDoubleQuote}, (*text)[start:k]...), codeDoubleQuote)
+			innerIdx := 0
+			if processed, err := parseString(&inner, &innerIdx, output, false, -1, opts); err != nil || !processed {
+				return false
+			}
+			*i = k + 2
+			return true
+		}
+	}
+	return false
+}
+
+// foldTrailingPairs merges key:value pairs following a complete root object into it, so
+// {"a":1} "b":2 becomes {"a":1,"b":2}. Commas between the object and the pairs are skipped.
+func foldTrailingPairs[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) error {
+	root := strings.TrimRight(output.String(), " \t\r\n")
+	if !strings.HasSuffix(root, "}") {
+		return nil
+	}
+	j := *i
+	for {
+		parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
+		if !skipCharacter(text, &j, codeComma) {
+			break
+		}
+	}
+	if j >= len(*text) {
+		return nil
+	}
+	// Whatever follows the root is either folded into it or dropped, so is the whitespace before it
+	output.Reset()
+	output.WriteString(root)
+
+	// Only pairs are folded: a key followed by a separator, not another value
+	k := j
+	stringProcessed, _ := parseString(text, &k, &strings.Builder{}, false, -1, opts)
+	if !stringProcessed && !parseUnquotedStringWithMode(text, &k, &strings.Builder{}, true, opts) {
+		return nil
+	}
+	parseWhitespaceAndSkipComments(text, &k, &strings.Builder{}, true, opts)
+	if !isKeyValueSeparator(text, k) {
+		return nil
+	}
+
+	k = j
+	var pairs strings.Builder
+	processed, err := parseObject(text, &k, &pairs, opts)
+	if err != nil || !processed {
+		return err
+	}
+	members := strings.TrimSpace(pairs.String())
+	members = strings.TrimSpace(members[1 : len(members)-1])
+	if members == "" {
+		return nil
+	}
+
+	opts.record("folded-pairs", j, "moved closing brace after trailing key:value pairs")
+	body := strings.TrimSpace(root[:len(root)-1])
+	if body != "{" {
+		body += ","
+	}
+	output.Reset()
+	output.WriteString(body + members + "}")
+	*i = k
+	return nil
+}
+
+// isBracelessElement reports whether the key:value pair at j starts an array element whose
+// object lost its opening brace but kept the closing one, as in ["a":1}]. It only scans for
+// the bracket that ends the element, skipping quoted strings and nested values: a closing
+// brace makes it an object, a closing bracket or the end of the text does not. A closing
+// brace directly inside a nested array ends a nested element of the same kind.
+func isBracelessElement[T textChar](text *[]T, j int) bool {
+	var open []rune
+	for k := j; k < len(*text); k++ {
+		switch char := (*text)[k]; {
+		case isQuote(rune(char)):
+			// A string ends at a quote of the same kind; one left open ends at the line break
+			closes := isSingleQuoteLike
+			if isDoubleQuoteLike(rune(char)) {
+				closes = isDoubleQuoteLike
+			}
+			for k++; k < len(*text) && !closes(rune((*text)[k])) && (*text)[k] != codeNewline; k++ {
+				if (*text)[k] == codeBackslash {
+					k++
+				}
+			}
+		case char == codeOpeningBrace || char == codeOpeningBracket:
+			open = append(open, rune(char))
+		case char == codeClosingBrace || char == codeClosingBracket:
+			if len(open) == 0 {
+				return char == codeClosingBrace
+			}
+			if char == codeClosingBrace && open[len(open)-1] == codeOpeningBracket {
+				continue
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	return false
+}
+
+// parseNewlineDelimitedJSON repairs every line as one record and writes the records to
+// output, joined by separator. Each line is parsed on its own, so a truncated record cannot
+// swallow the lines after it, and anything after a value on the same line is dropped. Blank
+// and comment-only lines produce no record.
+func parseNewlineDelimitedJSON[T textChar](text *[]T, i *int, output *strings.Builder, separator string, opts *RepairOptions) error {
+	count := 0
+	for *i < len(*text) {
+		end := *i
+		for end < len(*text) && (*text)[end] != codeNewline && (*text)[end] != codeReturn {
+			end++
+		}
+		line := (*text)[*i:end]
+		j := 0
+		var lineOutput strings.Builder
+		processedValue, err := parseValue(&line, &j, &lineOutput, opts)
+		if err != nil {
+			return err
+		}
+		if record := strings.TrimSpace(lineOutput.String()); processedValue && record != "" {
+			if count > 0 {
+				output.WriteString(separator)
+			}
+			output.WriteString(record)
+			count++
 		}
+		*i = end
 		for *i < len(*text) && ((*text)[*i] == codeNewline || (*text)[*i] == codeReturn) {
 			*i++
 		}
 	}
+	return nil
 }
 
-func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter bool, stopAtIndex int, trimWhitespace bool) (bool, error) {
+func parseString[T textChar](text *[]T, i *int, output *strings.Builder, stopAtDelimiter bool, stopAtIndex int, opts *RepairOptions) (bool, error) {
 	if *i >= len(*text) {
 		return false, nil
 	}
 	char := (*text)[*i]
-	if isQuote(char) {
+	if opts.DoubledQuotes && parseDoubledQuotedString(text, i, output, opts) {
+		return true, nil
+	}
+	if isQuote(rune(char)) {
 		isEndQuote := isDoubleQuote
-		if isSingleQuote(char) {
+		if isSingleQuote(rune(char)) {
 			isEndQuote = isSingleQuote
-		} else if isDoubleQuoteLike(char) {
+		} else if isDoubleQuoteLike(rune(char)) {
 			isEndQuote = isDoubleQuoteLike
-		} else if isSingleQuoteLike(char) {
+		} else if isSingleQuoteLike(rune(char)) {
 			isEndQuote = isSingleQuoteLike
 		}
 
@@ -887,15 +2499,15 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 			var bestQuoteFunc func(rune) bool
 			for _, quoteFunc := range otherQuotes {
 				k := *i + 1
-				for k < len(*text) && !quoteFunc((*text)[k]) && (*text)[k] != codeNewline && (*text)[k] != codeReturn {
+				for k < len(*text) && !quoteFunc(rune((*text)[k])) && (*text)[k] != codeNewline && (*text)[k] != codeReturn {
 					k++
 				}
-				if k < len(*text) && quoteFunc((*text)[k]) {
+				if k < len(*text) && quoteFunc(rune((*text)[k])) {
 					nextIdx := k + 1
-					for nextIdx < len(*text) && isWhitespace((*text)[nextIdx]) {
+					for nextIdx < len(*text) && isWhitespace(rune((*text)[nextIdx])) {
 						nextIdx++
 					}
-					if nextIdx < len(*text) && ((*text)[nextIdx] == codeColon || (*text)[nextIdx] == codeEqual) {
+					if keySeparatorLength(text, nextIdx, opts) > 0 {
 						if bestK == -1 || k < bestK {
 							bestK = k
 							bestQuoteFunc = quoteFunc
@@ -913,23 +2525,24 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 		var str strings.Builder
 		for *i < len(*text) {
 			currentChar := (*text)[*i]
-			if isQuote(currentChar) {
+			if isQuote(rune(currentChar)) {
 				// Potential end quote. Check if it's followed by a delimiter.
 				isRealEndQuote := false
-				isOfficialEndQuote := isEndQuote(currentChar)
+				isOfficialEndQuote := isEndQuote(rune(currentChar))
 
 				j := *i + 1
 				// Skip whitespace and comments
-				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true)
+				parseWhitespaceAndSkipComments(text, &j, &strings.Builder{}, true, opts)
 
 				if j >= len(*text) {
 					isRealEndQuote = true
 				} else {
 					nextChar := (*text)[j]
 					if nextChar == codeComma || nextChar == codeClosingBrace || nextChar == codeClosingBracket ||
-						nextChar == codeColon || nextChar == codeEqual || nextChar == codePlus {
+						nextChar == codeColon || nextChar == codeEqual || nextChar == codePlus || keySeparatorLength(text, j, opts) > 0 ||
+						nextChar == codeCloseParenthesis && (opts.PythonLiterals || opts.callDepth > 0) {
 						isRealEndQuote = true
-					} else if isQuote(nextChar) || isLetter(nextChar) || isDigit(nextChar) {
+					} else if isQuote(rune(nextChar)) || isLetter(rune(nextChar)) || isDigit(rune(nextChar)) {
 						// Special case: "Basketball" "Swimming" (missing comma between array elements)
 						// Or "name" "value" (missing colon between key and value)
 						// If the next character is a quote, letter, or digit, it's likely a missing delimiter.
@@ -937,11 +2550,24 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 					}
 				}
 
+				proseEnd := -1
+				if isRealEndQuote && currentChar == codeDoubleQuote && opts.ProseQuotes {
+					var interior bool
+					if interior, proseEnd = proseInteriorQuote(text, *i); interior {
+						isRealEndQuote = false
+					}
+				}
+
 				if isRealEndQuote && !isOfficialEndQuote {
 					// Mismatched quote.
-					// Check if the official end quote exists later on the same line.
+					// Check if the official end quote exists later on the same line. Stop looking
+					// once a new quoted token starts, as a later quote then belongs to that token:
+					// in ['x", "y'] the ' after y closes "y, not 'x.
 					for k := *i + 1; k < len(*text) && (*text)[k] != codeNewline && (*text)[k] != codeReturn; k++ {
-						if isEndQuote((*text)[k]) {
+						if startsQuotedToken(text, k, opts) {
+							break
+						}
+						if isEndQuote(rune((*text)[k])) {
 							isRealEndQuote = false
 							break
 						}
@@ -950,25 +2576,40 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 
 				if isRealEndQuote {
 					*i++
-					if parseConcatenatedString(text, i, output, trimWhitespace) {
+					if parseConcatenatedString(text, i, output, opts) {
 						str.WriteString(output.String())
 						output.Reset()
 						continue
 					}
 
 					finalStr := str.String()
+					if opts.NormalizeStringNewlines {
+						finalStr = normalizeEscapedNewlines(finalStr)
+					}
 					if isNumericString(finalStr) {
 						output.WriteString(finalStr)
 					} else {
 						fmt.Fprintf(output, `"%s"`, finalStr)
 					}
 					return true, nil
+				} else if proseEnd != -1 {
+					// The words after the quote run to the end of the value and the closing
+					// quote is missing: keep them and close the string there
+					encoded := encodeJSONString(strings.TrimRight(textString((*text)[*i:proseEnd]), " \t"))
+					str.WriteString(encoded[1 : len(encoded)-1])
+					*i = proseEnd
+					finalStr := str.String()
+					if opts.NormalizeStringNewlines {
+						finalStr = normalizeEscapedNewlines(finalStr)
+					}
+					fmt.Fprintf(output, `"%s"`, finalStr)
+					return true, nil
 				} else {
 					// Not a real end quote, escape it and continue
 					if currentChar == '"' {
 						str.WriteString("\\\"")
 					} else {
-						str.WriteRune(currentChar)
+						str.WriteRune(rune(currentChar))
 					}
 					*i++
 					continue
@@ -982,15 +2623,15 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 				*i++
 				if *i < len(*text) {
 					char := (*text)[*i]
-					if _, ok := escapeCharacters[char]; ok {
+					if _, ok := escapeCharacters[rune(char)]; ok {
 						str.WriteString("\\")
-						str.WriteRune(char)
+						str.WriteRune(rune(char))
 						if char == 'u' {
 							// Check if we have 4 hex digits
 							hexCount := 0
-							for j := 0; j < 4 && *i+1 < len(*text) && isHex((*text)[*i+1]); j++ {
+							for j := 0; j < 4 && *i+1 < len(*text) && isHex(rune((*text)[*i+1])); j++ {
 								*i++
-								str.WriteRune((*text)[*i])
+								str.WriteRune(rune((*text)[*i]))
 								hexCount++
 							}
 							// If not 4 hex digits, we should probably escape the backslash and treat u as normal char
@@ -1012,6 +2653,10 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 							}
 						}
 						*i++
+					} else if char == codeQuote {
+						// \' is not a JSON escape, but a single quote needs none
+						str.WriteRune(rune(char))
+						*i++
 					} else {
 						// Not a standard escape character, treat as literal backslash
 						str.WriteString("\\\\")
@@ -1028,7 +2673,7 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 					if char == codeClosingBrace || char == codeClosingBracket {
 						// For closing delimiters, we check if there's an end quote later on the same line
 						for k := *i + 1; k < len(*text) && (*text)[k] != codeNewline && (*text)[k] != codeReturn; k++ {
-							if isEndQuote((*text)[k]) {
+							if isEndQuote(rune((*text)[k])) {
 								foundEndQuote = true
 								break
 							}
@@ -1036,22 +2681,22 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 					} else if char == codeComma {
 						// For comma, we check if it's followed by a valid key:value or value
 						nextIdx := *i + 1
-						for nextIdx < len(*text) && isWhitespace((*text)[nextIdx]) {
+						for nextIdx < len(*text) && isWhitespace(rune((*text)[nextIdx])) {
 							nextIdx++
 						}
 						if nextIdx < len(*text) {
 							nextChar := (*text)[nextIdx]
-							if isQuote(nextChar) {
+							if isQuote(rune(nextChar)) {
 								// Look ahead for the end of this potential next item
 								foundColonAfterQuote := false
 								for k := nextIdx + 1; k < len(*text) && (*text)[k] != codeNewline && (*text)[k] != codeReturn; k++ {
-									if isQuote((*text)[k]) {
+									if isQuote(rune((*text)[k])) {
 										// Found another quote, check if it's followed by a colon
 										n := k + 1
-										for n < len(*text) && isWhitespace((*text)[n]) {
+										for n < len(*text) && isWhitespace(rune((*text)[n])) {
 											n++
 										}
-										if n < len(*text) && ((*text)[n] == codeColon || (*text)[n] == codeEqual) {
+										if keySeparatorLength(text, n, opts) > 0 {
 											foundColonAfterQuote = true
 										}
 										break
@@ -1067,13 +2712,13 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 									// If it's the end quote, then the current delimiter is NOT a delimiter.
 									foundEndQuote = true
 								}
-							} else if isStartOfValue(nextChar) {
+							} else if isStartOfValue(rune(nextChar)) {
 								// In an array, a comma followed by a value is likely a delimiter
 								// But we need to be careful not to break strings like "a,b"
 								// So we check if there's an end quote for the CURRENT string later
 								hasEndQuoteLater := false
 								for k := *i + 1; k < len(*text) && (*text)[k] != codeNewline && (*text)[k] != codeReturn; k++ {
-									if isEndQuote((*text)[k]) {
+									if isEndQuote(rune((*text)[k])) {
 										hasEndQuoteLater = true
 										break
 									}
@@ -1086,8 +2731,8 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 							} else {
 								// Unquoted key?
 								hasColon := false
-								for k := nextIdx; k < len(*text) && (*text)[k] != codeNewline && (*text)[k] != codeReturn && !isDelimiter((*text)[k]); k++ {
-									if (*text)[k] == codeColon || (*text)[k] == codeEqual {
+								for k := nextIdx; k < len(*text) && (*text)[k] != codeNewline && (*text)[k] != codeReturn && !isDelimiter(rune((*text)[k])); k++ {
+									if keySeparatorLength(text, k, opts) > 0 {
 										hasColon = true
 										break
 									}
@@ -1107,44 +2752,90 @@ func parseString(text *[]rune, i *int, output *strings.Builder, stopAtDelimiter
 				}
 				if char == '"' {
 					str.WriteString("\\\"")
-				} else if isControlCharacter(char) {
-					if replacement, ok := controlCharacters[char]; ok {
+				} else if isControlCharacter(rune(char)) {
+					if replacement, ok := controlCharacters[rune(char)]; ok {
 						str.WriteString(replacement)
 					} else {
 						str.WriteString(fmt.Sprintf("\\u%04x", char))
 					}
 				} else {
-					str.WriteRune(char)
+					str.WriteRune(rune(char))
 				}
 				*i++
 			}
 		}
 		content := str.String()
-		if trimWhitespace {
+		if opts.NormalizeStringNewlines {
+			content = normalizeEscapedNewlines(content)
+		}
+		if opts.TrimWhitespace {
 			content = stringTrimRe.ReplaceAllString(content, "")
 		} else {
 			content = trailingWhitespaceRe.ReplaceAllString(content, "")
 		}
+		opts.record("closed-string", *i, "inserted missing closing quote")
+		if *i >= len(*text) && opts.TruncatedBase64 != "" {
+			if repaired, ok := repairTruncatedBase64(content, opts.TruncatedBase64); ok {
+				opts.record("truncated-base64", *i, "repaired base64 value cut off by the end of input")
+				content = repaired
+			}
+		}
 		fmt.Fprintf(output, `"%s"`, content)
 		return true, nil
 	}
 	return false, nil
 }
 
-func parseConcatenatedString(text *[]rune, i *int, output *strings.Builder, trimWhitespace bool) bool {
+// normalizeEscapedNewlines rewrites the \r\n and lone \r (or \u000d) escapes of the escaped
+// string content s to \n, leaving escaped backslashes such as \\r alone
+func normalizeEscapedNewlines(s string) string {
+	if !strings.Contains(s, `\r`) && !strings.Contains(strings.ToLower(s), `\u000d`) {
+		return s
+	}
+	var builder strings.Builder
+	for idx := 0; idx < len(s); idx++ {
+		if s[idx] != '\\' || idx+1 >= len(s) {
+			builder.WriteByte(s[idx])
+			continue
+		}
+		length := 0
+		if s[idx+1] == 'r' {
+			length = 2
+		} else if idx+6 <= len(s) && strings.EqualFold(s[idx:idx+6], `\u000d`) {
+			length = 6
+		}
+		if length == 0 {
+			// Copy the escape as a whole so its second character is not read as a new escape
+			builder.WriteString(s[idx : idx+2])
+			idx++
+			continue
+		}
+		builder.WriteString(`\n`)
+		idx += length
+		if strings.HasPrefix(s[idx:], `\n`) {
+			idx += 2
+		} else if idx+6 <= len(s) && strings.EqualFold(s[idx:idx+6], `\u000a`) {
+			idx += 6
+		}
+		idx--
+	}
+	return builder.String()
+}
+
+func parseConcatenatedString[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
 	processed := false
 	iBeforeWhitespace := *i
 	oBeforeWhitespace := output.Len()
-	parseWhitespaceAndSkipComments(text, i, output, true)
+	parseWhitespaceAndSkipComments(text, i, output, true, opts)
 	for *i < len(*text) && (*text)[*i] == '+' {
 		processed = true
 		*i++
-		parseWhitespaceAndSkipComments(text, i, output, true)
+		parseWhitespaceAndSkipComments(text, i, output, true, opts)
 		outputStr := stripLastOccurrence(output.String(), "\"", true)
 		output.Reset()
 		output.WriteString(outputStr)
 		start := output.Len()
-		stringProcessed, err := parseString(text, i, output, false, -1, trimWhitespace)
+		stringProcessed, err := parseString(text, i, output, false, -1, opts)
 		if err != nil {
 			stringProcessed = false
 		}
@@ -1160,107 +2851,283 @@ func parseConcatenatedString(text *[]rune, i *int, output *strings.Builder, trim
 			output.WriteString(outputStr)
 		}
 	}
-	if !processed {
-		*i = iBeforeWhitespace
-		tempStr := output.String()
-		output.Reset()
-		output.WriteString(tempStr[:oBeforeWhitespace])
+	if !processed {
+		*i = iBeforeWhitespace
+		truncateOutput(output, oBeforeWhitespace)
+	}
+	return processed
+}
+
+func parseNumber[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
+	start := *i
+	if *i < len(*text) && ((*text)[*i] == codeMinus || (*text)[*i] == codePlus) {
+		*i++
+		if atEndOfNumber(text, i, opts) {
+			repairNumberEndingWithNumericSymbol(text, start, i, output)
+			return true
+		}
+		if !isDigit(rune((*text)[*i])) && !startsLeadingDotNumber(text, *i) {
+			*i = start
+			return false
+		}
+	}
+	if parseHexNumber(text, i, start, output, opts) {
+		return true
+	}
+	skipDigitsWithSeparators(text, i)
+	if *i < len(*text) && (*text)[*i] == codeDot {
+		*i++
+		if atEndOfNumber(text, i, opts) {
+			repairNumberEndingWithNumericSymbol(text, start, i, output)
+			return true
+		}
+		// 1.e5 is read as 1.0e5 and 1.e as 1.0e0
+		if !isDigit(rune((*text)[*i])) && !startsExponentDigits(text, *i) && !isBareExponent(text, *i, opts) {
+			*i = start
+			return false
+		}
+		skipDigitsWithSeparators(text, i)
+	}
+	// Only an e or E after mantissa digits starts an exponent; words such as enabled or east
+	// are left to the unquoted string parser
+	if *i > start && *i < len(*text) && isExponentMarker(rune((*text)[*i])) {
+		repairExponent(text, start, i, output)
+		return true
+	}
+	if !atEndOfNumber(text, i, opts) {
+		*i = start
+		return false
+	}
+	if end := divisionEnd(text, *i); end > *i && *i > start {
+		output.WriteString(encodeJSONString(textString((*text)[start:end])))
+		*i = end
+		return true
+	}
+	if *i > start {
+		num := normalizeNumberText(textString((*text)[start:*i]))
+		hasInvalidLeadingZero := leadingZeroRe.MatchString(num)
+		if hasInvalidLeadingZero {
+			fmt.Fprintf(output, `"%s"`, num)
+		} else {
+			output.WriteString(num)
+		}
+		return true
+	}
+	return false
+}
+
+// isExponentMarker reports whether char is the e or E starting the exponent of a number
+func isExponentMarker(char rune) bool {
+	return char == codeLowercaseE || char == codeUppercaseE
+}
+
+// startsExponentDigits reports whether i holds an exponent with digits, such as e5 or E-3
+func startsExponentDigits[T textChar](text *[]T, i int) bool {
+	if i >= len(*text) || !isExponentMarker(rune((*text)[i])) {
+		return false
+	}
+	i++
+	if i < len(*text) && ((*text)[i] == codeMinus || (*text)[i] == codePlus) {
+		i++
+	}
+	return i < len(*text) && isDigit(rune((*text)[i]))
+}
+
+// isBareExponent reports whether i holds an e or E that ends the number, as in 1.e
+func isBareExponent[T textChar](text *[]T, i int, opts *RepairOptions) bool {
+	if i >= len(*text) || !isExponentMarker(rune((*text)[i])) {
+		return false
+	}
+	i++
+	return atEndOfNumber(text, &i, opts)
+}
+
+// repairExponent parses the exponent of a number whose mantissa spans start up to *i, where
+// *i is at the e or E, and writes the whole number. A malformed exponent is repaired to a valid
+// one: repeated or mixed signs collapse to a single sign, negative if any of them is a minus,
+// and missing digits become 0. Examples: 1.5e -> 1.5e0, 1e+ -> 1e+0, 1e--5 -> 1e-5,
+// 1e+-+ -> 1e-0 and 1.e5 -> 1.0e5. When letters are glued to the exponent, as in 1ex or
+// 1e+x, the token is not a number at all and is kept whole as a string.
+func repairExponent[T textChar](text *[]T, start int, i *int, output *strings.Builder) {
+	mantissa := textString((*text)[start:*i])
+	if strings.HasSuffix(mantissa, ".") {
+		mantissa += "0"
+	}
+	marker := string(rune((*text)[*i]))
+	*i++
+
+	sign := ""
+	for *i < len(*text) && ((*text)[*i] == codeMinus || (*text)[*i] == codePlus) {
+		if (*text)[*i] == codeMinus {
+			sign = "-"
+		} else if sign == "" {
+			sign = "+"
+		}
+		*i++
+	}
+
+	digitsStart := *i
+	skipDigitsWithSeparators(text, i)
+	if *i < len(*text) && (unicode.IsLetter(rune((*text)[*i])) || (*text)[*i] == '_') {
+		for *i < len(*text) && (unicode.IsLetter(rune((*text)[*i])) || unicode.IsDigit(rune((*text)[*i])) || (*text)[*i] == '_') {
+			*i++
+		}
+		output.WriteString(encodeJSONString(textString((*text)[start:*i])))
+		return
+	}
+	digits := textString((*text)[digitsStart:*i])
+	if digits == "" {
+		digits = "0"
+	}
+	output.WriteString(normalizeNumberText(mantissa + marker + sign + digits))
+}
+
+// startsLeadingDotNumber reports whether position i holds a JSON5 number like .5
+func startsLeadingDotNumber[T textChar](text *[]T, i int) bool {
+	return i+1 < len(*text) && (*text)[i] == codeDot && isDigit(rune((*text)[i+1]))
+}
+
+// skipDigitsWithSeparators skips digits, including underscores used as digit separators
+// (1_000_000). An underscore only counts when it sits between two digits.
+func skipDigitsWithSeparators[T textChar](text *[]T, i *int) {
+	for *i < len(*text) {
+		if isDigit(rune((*text)[*i])) {
+			*i++
+		} else if (*text)[*i] == '_' && *i > 0 && isDigit(rune((*text)[*i-1])) && *i+1 < len(*text) && isDigit(rune((*text)[*i+1])) {
+			*i++
+		} else {
+			break
+		}
+	}
+}
+
+// parseHexNumber parses a JSON5 hexadecimal literal such as 0xFF or -0x1a at i, where start
+// is the position of the optional sign, and writes it as a decimal integer
+func parseHexNumber[T textChar](text *[]T, i *int, start int, output *strings.Builder, opts *RepairOptions) bool {
+	if *i+2 >= len(*text) || (*text)[*i] != '0' || ((*text)[*i+1] != 'x' && (*text)[*i+1] != 'X') || !isHex(rune((*text)[*i+2])) {
+		return false
+	}
+	j := *i + 2
+	var digits strings.Builder
+	for j < len(*text) {
+		if isHex(rune((*text)[j])) {
+			digits.WriteRune(rune((*text)[j]))
+		} else if !((*text)[j] == '_' && isHex(rune((*text)[j-1])) && j+1 < len(*text) && isHex(rune((*text)[j+1]))) {
+			break
+		}
+		j++
+	}
+	if !atEndOfNumber(text, &j, opts) {
+		return false
 	}
-	return processed
+	value, ok := new(big.Int).SetString(digits.String(), 16)
+	if !ok {
+		return false
+	}
+	if (*text)[start] == codeMinus {
+		value.Neg(value)
+	}
+	*i = j
+	output.WriteString(value.String())
+	return true
+}
+
+// normalizeNumberText turns a JSON5 number into valid JSON: digit separators and a leading
+// plus sign are removed and a leading decimal point gets a zero (.5 -> 0.5)
+func normalizeNumberText(num string) string {
+	num = strings.ReplaceAll(num, "_", "")
+	num = strings.TrimPrefix(num, "+")
+	if strings.HasPrefix(num, ".") {
+		num = "0" + num
+	} else if strings.HasPrefix(num, "-.") {
+		num = "-0" + num[1:]
+	}
+	return num
 }
 
-func parseNumber(text *[]rune, i *int, output *strings.Builder) bool {
+func parseNumberWithUnit[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
+	if opts.UnitSuffixMode == "" {
+		return false
+	}
 	start := *i
-	if *i < len(*text) && ((*text)[*i] == codeMinus || (*text)[*i] == codePlus) {
-		*i++
-		if atEndOfNumber(text, i) {
-			repairNumberEndingWithNumericSymbol(text, start, i, output)
-			return true
-		}
-		if !isDigit((*text)[*i]) {
-			*i = start
-			return false
-		}
+	j := *i
+	if j < len(*text) && ((*text)[j] == codeMinus || (*text)[j] == codePlus) {
+		j++
 	}
-	for *i < len(*text) && isDigit((*text)[*i]) {
-		*i++
+	digitsStart := j
+	for j < len(*text) && isDigit(rune((*text)[j])) {
+		j++
 	}
-	if *i < len(*text) && (*text)[*i] == codeDot {
-		*i++
-		if atEndOfNumber(text, i) {
-			repairNumberEndingWithNumericSymbol(text, start, i, output)
-			return true
-		}
-		if !isDigit((*text)[*i]) {
-			*i = start
-			return false
-		}
-		for *i < len(*text) && isDigit((*text)[*i]) {
-			*i++
+	if j < len(*text) && (*text)[j] == codeDot {
+		j++
+		for j < len(*text) && isDigit(rune((*text)[j])) {
+			j++
 		}
 	}
-	if *i < len(*text) && ((*text)[*i] == codeLowercaseE || (*text)[*i] == codeUppercaseE) {
-		*i++
-		hasMinus := false
-		hasPlus := false
-		for *i < len(*text) && ((*text)[*i] == codeMinus || (*text)[*i] == codePlus) {
-			if (*text)[*i] == codeMinus {
-				hasMinus = true
-			} else {
-				hasPlus = true
-			}
-			*i++
-		}
-		if atEndOfNumber(text, i) {
-			repairNumberEndingWithNumericSymbol(text, start, i, output)
-			return true
-		}
-		if !isDigit((*text)[*i]) {
-			num := string((*text)[start:*i])
-			cleanNum := strings.TrimRight(num, "eE+-")
-			repairedNum := cleanNum + "e+0"
-			output.WriteString(strings.TrimPrefix(repairedNum, "+"))
-			return true
-		}
-		numSoFar := string((*text)[start:*i])
-		eIdx := strings.LastIndexAny(numSoFar, "eE")
-		if eIdx != -1 {
-			cleanNum := numSoFar[:eIdx+1]
-			if hasMinus {
-				cleanNum += "-"
-			} else if hasPlus {
-				cleanNum += "+"
-			}
-			startOfDigits := *i
-			for *i < len(*text) && isDigit((*text)[*i]) {
-				*i++
-			}
-			finalNum := cleanNum + string((*text)[startOfDigits:*i])
-			output.WriteString(strings.TrimPrefix(finalNum, "+"))
-			return true
-		}
+	if j == digitsStart || !isDigit(rune((*text)[digitsStart])) {
+		return false
 	}
-	if !atEndOfNumber(text, i) {
-		*i = start
+	numEnd := j
+	for j < len(*text) && (isLetter(rune((*text)[j])) || (*text)[j] == '%') {
+		j++
+	}
+	if j == numEnd || !atEndOfNumber(text, &j, opts) {
 		return false
 	}
-	if *i > start {
-		num := string((*text)[start:*i])
-		hasInvalidLeadingZero := regexp.MustCompile(`^0\d`).MatchString(num)
-		if hasInvalidLeadingZero {
-			fmt.Fprintf(output, `"%s"`, num)
-		} else {
-			if strings.HasPrefix(num, "+") {
-				num = num[1:]
-			}
-			output.WriteString(num)
-		}
-		return true
+
+	unitTable := opts.UnitTable
+	if unitTable == nil {
+		unitTable = DefaultUnitTable
 	}
-	return false
+	unit := textString((*text)[numEnd:j])
+	multiplier, ok := unitTable[unit]
+	if !ok {
+		return false
+	}
+	num := strings.TrimPrefix(textString((*text)[start:numEnd]), "+")
+	value, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return false
+	}
+
+	switch opts.UnitSuffixMode {
+	case "split":
+		fmt.Fprintf(output, `{"value":%s,"unit":%q}`, strconv.FormatFloat(value, 'f', -1, 64), unit)
+	case "canonical":
+		output.WriteString(strconv.FormatFloat(value*multiplier, 'f', -1, 64))
+	default:
+		return false
+	}
+	*i = j
+	return true
+}
+
+// parseUnquotedDate consumes an unquoted ISO-like date or timestamp such as 2024-01-15,
+// 2024-01-15T10:30:00Z or 2024-01-15 10:30:00+08:00 as a single string. Without this the
+// dashes and colons would be treated as a number and key/value separators.
+func parseUnquotedDate[T textChar](text *[]T, i *int, output *strings.Builder) bool {
+	// Most values cannot be a date; rule them out before building a string for the regexp
+	if *i+10 > len(*text) || !isDigit(rune((*text)[*i])) || (*text)[*i+4] != codeMinus {
+		return false
+	}
+	end := *i + 40
+	if end > len(*text) {
+		end = len(*text)
+	}
+	match := unquotedDateRe.FindString(textString((*text)[*i:end]))
+	if match == "" {
+		return false
+	}
+	j := *i + len(match) // the match is pure ASCII, so bytes equal runes
+	if j < len(*text) && (isLetter(rune((*text)[j])) || isDigit(rune((*text)[j])) || (*text)[j] == codeDot || (*text)[j] == codeColon) {
+		return false
+	}
+	output.WriteString(`"` + match + `"`)
+	*i = j
+	return true
 }
 
-func parseKeywords(text *[]rune, i *int, output *strings.Builder) bool {
+func parseKeywords[T textChar](text *[]T, i *int, output *strings.Builder) bool {
 	return parseKeyword(text, i, output, "true", "true") ||
 		parseKeyword(text, i, output, "false", "false") ||
 		parseKeyword(text, i, output, "null", "null") ||
@@ -1269,8 +3136,8 @@ func parseKeywords(text *[]rune, i *int, output *strings.Builder) bool {
 		parseKeyword(text, i, output, "None", "null")
 }
 
-func parseKeyword(text *[]rune, i *int, output *strings.Builder, name, value string) bool {
-	if len(*text)-*i >= len(name) && string((*text)[*i:*i+len(name)]) == name {
+func parseKeyword[T textChar](text *[]T, i *int, output *strings.Builder, name, value string) bool {
+	if len(*text)-*i >= len(name) && textString((*text)[*i:*i+len(name)]) == name {
 		output.WriteString(value)
 		*i += len(name)
 		return true
@@ -1278,26 +3145,26 @@ func parseKeyword(text *[]rune, i *int, output *strings.Builder, name, value str
 	return false
 }
 
-func parseUnquotedString(text *[]rune, i *int, output *strings.Builder, trimWhitespace bool) bool {
-	return parseUnquotedStringWithMode(text, i, output, false, trimWhitespace)
+func parseUnquotedString[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) bool {
+	return parseUnquotedStringWithMode(text, i, output, false, opts)
 }
 
-func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder, isKey bool, trimWhitespace bool) bool {
+func parseUnquotedStringWithMode[T textChar](text *[]T, i *int, output *strings.Builder, isKey bool, opts *RepairOptions) bool {
 	start := *i
 	if *i >= len(*text) {
 		return false
 	}
-	if isFunctionNameCharStart((*text)[*i]) {
-		for *i < len(*text) && isFunctionNameChar((*text)[*i]) {
+	if isFunctionNameCharStart(rune((*text)[*i])) {
+		for *i < len(*text) && isFunctionNameChar(rune((*text)[*i])) {
 			*i++
 		}
 		j := *i
-		for j < len(*text) && isWhitespace((*text)[j]) {
+		for j < len(*text) && isWhitespace(rune((*text)[j])) {
 			j++
 		}
 		if j < len(*text) && (*text)[j] == codeOpenParenthesis {
 			*i = j + 1
-			_, _ = parseValue(text, i, output, trimWhitespace)
+			parseFunctionCallArguments(text, i, output, opts)
 			if *i < len(*text) && (*text)[*i] == codeCloseParenthesis {
 				*i++
 				if *i < len(*text) && (*text)[*i] == codeSemicolon {
@@ -1307,28 +3174,28 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 			return true
 		}
 	}
-	for *i < len(*text) && !isUnquotedStringDelimiter((*text)[*i]) {
-		if isQuote((*text)[*i]) {
+	for *i < len(*text) && !isUnquotedStringDelimiter(rune((*text)[*i])) && !atTrailingMarks(text, *i, opts) {
+		if isQuote(rune((*text)[*i])) {
 			if isKey {
 				break
 			}
 			// If it's a value, we check if it's followed by a delimiter
 			j := *i + 1
-			for j < len(*text) && isWhitespace((*text)[j]) {
+			for j < len(*text) && isWhitespace(rune((*text)[j])) {
 				j++
 			}
-			if j < len(*text) && (isUnquotedStringDelimiter((*text)[j]) || (*text)[j] == codeColon || (*text)[j] == codeEqual) {
+			if j < len(*text) && (isUnquotedStringDelimiter(rune((*text)[j])) || isKeyValueSeparator(text, j)) {
 				break
 			}
 		}
-		if isKey && ((*text)[*i] == codeColon || (*text)[*i] == codeEqual) {
+		if isKey && keySeparatorLength(text, *i, opts) > 0 {
 			isURLProtocol := false
 			if (*text)[*i] == codeColon && *i+2 < len(*text) && (*text)[*i+1] == codeSlash && (*text)[*i+2] == codeSlash {
 				protocolStart := *i - 1
-				for protocolStart >= start && isLetter((*text)[protocolStart]) {
+				for protocolStart >= start && isLetter(rune((*text)[protocolStart])) {
 					protocolStart--
 				}
-				protocol := string((*text)[protocolStart+1 : *i])
+				protocol := textString((*text)[protocolStart+1 : *i])
 				if protocol == "http" || protocol == "https" || protocol == "ftp" {
 					isURLProtocol = true
 				}
@@ -1337,8 +3204,8 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 				break
 			}
 		}
-		if !isKey && ((*text)[*i] == codeColon || (*text)[*i] == codeEqual) {
-			if !isURLStart(text, *i) && lookAheadForColon(text, *i) {
+		if !isKey && isKeyValueSeparator(text, *i) {
+			if !isURLStart(text, *i) && lookAheadForColon(text, *i, opts) {
 				break
 			}
 		}
@@ -1349,7 +3216,7 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 			if isKey {
 				break
 			}
-			if lookAheadForColon(text, *i) {
+			if lookAheadForColon(text, *i, opts) {
 				break
 			}
 		}
@@ -1357,10 +3224,10 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 			isURLComment := false
 			if *i > 0 && (*text)[*i-1] == codeColon {
 				j := *i - 2
-				for j >= start && (isLetter((*text)[j])) {
+				for j >= start && (isLetter(rune((*text)[j]))) {
 					j--
 				}
-				protocol := string((*text)[j+1 : *i-1])
+				protocol := textString((*text)[j+1 : *i-1])
 				if protocol == "http" || protocol == "https" || protocol == "ftp" {
 					isURLComment = true
 				}
@@ -1369,15 +3236,21 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 				break
 			}
 		}
+		if isHashComment(text, *i, opts) {
+			break
+		}
 		*i++
 	}
 	if *i > start {
 		end := *i
-		for end > start && isWhitespace((*text)[end-1]) {
+		for end > start && isWhitespace(rune((*text)[end-1])) &&
+			!(opts.PreserveSpecialWhitespace && isSpecialWhitespace(rune((*text)[end-1]))) {
 			end--
 		}
-		symbol := string((*text)[start:end])
-		if symbol == "undefined" {
+		symbol := textString((*text)[start:end])
+		if opts.KeysOnly && !isKey {
+			output.WriteString(symbol)
+		} else if symbol == "undefined" {
 			output.WriteString("null")
 		} else {
 			repairedSymbol := strings.Builder{}
@@ -1392,12 +3265,14 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 					repairedSymbol.WriteString("\\r")
 				} else if char == '\t' {
 					repairedSymbol.WriteString("\\t")
+				} else if isControlCharacter(char) {
+					fmt.Fprintf(&repairedSymbol, "\\u%04x", char)
 				} else {
 					repairedSymbol.WriteRune(char)
 				}
 			}
 			content := repairedSymbol.String()
-			if trimWhitespace {
+			if opts.TrimWhitespace {
 				content = stringTrimRe.ReplaceAllString(content, "")
 			}
 			fmt.Fprintf(output, `"%s"`, content)
@@ -1410,41 +3285,111 @@ func parseUnquotedStringWithMode(text *[]rune, i *int, output *strings.Builder,
 	return false
 }
 
-func parseRegex(text *[]rune, i *int, output *strings.Builder) bool {
-	if *i < len(*text) && (*text)[*i] == codeSlash {
-		start := *i
-		*i++
-		for *i < len(*text) && ((*text)[*i] != codeSlash || (*text)[*i-1] == codeBackslash) {
-			*i++
+// parseFunctionCallArguments parses the comma separated arguments of a function call such as
+// NumberLong(123,) or Timestamp(1, 2) up to the closing parenthesis. A single argument is
+// written as is, no arguments as null and multiple arguments as an array, so Timestamp(1, 2)
+// keeps both values as [1, 2]; the function name itself is dropped. While the arguments are
+// parsed, a quote followed by ) closes its string.
+func parseFunctionCallArguments[T textChar](text *[]T, i *int, output *strings.Builder, opts *RepairOptions) {
+	opts.callDepth++
+	defer func() { opts.callDepth-- }()
+
+	var args []string
+	for {
+		parseWhitespaceAndSkipComments(text, i, &strings.Builder{}, true, opts)
+		for skipCharacter(text, i, codeComma) {
+			parseWhitespaceAndSkipComments(text, i, &strings.Builder{}, true, opts)
 		}
-		if *i < len(*text) && (*text)[*i] == codeSlash {
-			*i++
+		if *i >= len(*text) || (*text)[*i] == codeCloseParenthesis {
+			break
+		}
+		var arg strings.Builder
+		if processed, err := parseValue(text, i, &arg, opts); err != nil || !processed {
+			break
+		}
+		args = append(args, strings.TrimSpace(arg.String()))
+		parseWhitespaceAndSkipComments(text, i, &strings.Builder{}, true, opts)
+		if *i >= len(*text) || (*text)[*i] != codeComma {
+			break
 		}
-		regexContent := string((*text)[start:*i])
-		regexContent = strings.ReplaceAll(regexContent, "\\", "\\\\")
-		fmt.Fprintf(output, `"%s"`, regexContent)
-		return true
 	}
-	return false
+
+	switch len(args) {
+	case 0:
+		output.WriteString("null")
+	case 1:
+		output.WriteString(args[0])
+	default:
+		output.WriteString("[" + strings.Join(args, ", ") + "]")
+	}
+}
+
+// parseRegex reads a JavaScript regular expression literal such as /ab+c/i as a string. It only
+// applies when the closing slash (skipping escaped slashes and slashes in [...] classes) and
+// its flags end the value, so paths like /usr/local/bin are left to parseUnquotedString.
+func parseRegex[T textChar](text *[]T, i *int, output *strings.Builder) bool {
+	if *i+1 >= len(*text) || (*text)[*i] != codeSlash {
+		return false
+	}
+	// A second slash or an asterisk starts a comment
+	if (*text)[*i+1] == codeSlash || (*text)[*i+1] == codeAsterisk {
+		return false
+	}
+	j := *i + 1
+	inClass := false
+	for ; j < len(*text); j++ {
+		char := (*text)[j]
+		if char == codeBackslash {
+			j++
+			continue
+		}
+		if char == codeNewline || char == codeReturn {
+			return false
+		}
+		if char == codeOpeningBracket {
+			inClass = true
+		} else if char == codeClosingBracket {
+			inClass = false
+		} else if char == codeSlash && !inClass {
+			break
+		}
+	}
+	if j >= len(*text) {
+		return false
+	}
+	j++
+	for j < len(*text) && strings.ContainsRune("dgimsuyv", rune((*text)[j])) {
+		j++
+	}
+	end := j
+	for j < len(*text) && isWhitespace(rune((*text)[j])) {
+		j++
+	}
+	if j < len(*text) && (*text)[j] != codeComma && (*text)[j] != codeClosingBrace && (*text)[j] != codeClosingBracket {
+		return false
+	}
+	output.WriteString(encodeJSONString(textString((*text)[*i:end])))
+	*i = end
+	return true
 }
 
-func parseMarkdownCodeBlock(text *[]rune, i *int, blocks []string, output *strings.Builder, trimWhitespace bool) bool {
+func parseMarkdownCodeBlock[T textChar](text *[]T, i *int, blocks []string, output *strings.Builder, opts *RepairOptions) bool {
 	if skipMarkdownCodeBlock(text, i, blocks, output) {
-		if *i < len(*text) && isFunctionNameCharStart((*text)[*i]) {
+		if *i < len(*text) && isFunctionNameCharStart(rune((*text)[*i])) {
 			j := *i
-			for j < len(*text) && isFunctionNameChar((*text)[j]) {
+			for j < len(*text) && isFunctionNameChar(rune((*text)[j])) {
 				j++
 			}
-			name := string((*text)[*i:j])
+			name := textString((*text)[*i:j])
 			if name == "ObjectId" || name == "NumberLong" || name == "NumberInt" || name == "ISODate" || name == "BinData" {
 				k := j
-				for k < len(*text) && isWhitespace((*text)[k]) {
+				for k < len(*text) && isWhitespace(rune((*text)[k])) {
 					k++
 				}
 				if k >= len(*text) || (*text)[k] != codeOpenParenthesis {
 					*i = k
 					var innerValue strings.Builder
-					if parseUnquotedString(text, i, &innerValue, trimWhitespace) {
+					if parseUnquotedString(text, i, &innerValue, opts) {
 						fmt.Fprintf(output, `"%s"`, name)
 						val := innerValue.String()
 						if strings.HasPrefix(val, "\"") && strings.HasSuffix(val, "\"") {
@@ -1458,13 +3403,13 @@ func parseMarkdownCodeBlock(text *[]rune, i *int, blocks []string, output *strin
 					}
 				}
 			}
-			for *i < len(*text) && isFunctionNameChar((*text)[*i]) {
+			for *i < len(*text) && isFunctionNameChar(rune((*text)[*i])) {
 				*i++
 			}
 		}
-		for *i < len(*text) && (isWhitespace((*text)[*i]) || isSpecialWhitespace((*text)[*i])) {
-			if isWhitespace((*text)[*i]) {
-				output.WriteRune((*text)[*i])
+		for *i < len(*text) && (isWhitespace(rune((*text)[*i])) || isSpecialWhitespace(rune((*text)[*i]))) {
+			if isWhitespace(rune((*text)[*i])) {
+				output.WriteRune(rune((*text)[*i]))
 			} else {
 				output.WriteRune(' ')
 			}
@@ -1479,24 +3424,164 @@ func parseMarkdownCodeBlock(text *[]rune, i *int, blocks []string, output *strin
 // HELPER FUNCTIONS (from utils.go, errors.go)
 // ================================
 
-func prevNonWhitespaceIndex(text []rune, startIndex int) int {
+// textString converts a slice of the text being parsed to a string
+func textString[T textChar](text []T) string {
+	switch t := any(text).(type) {
+	case []byte:
+		return string(t)
+	case []rune:
+		return string(t)
+	}
+	return ""
+}
+
+func prevNonWhitespaceIndex[T textChar](text []T, startIndex int) int {
 	prev := startIndex
-	for prev >= 0 && isWhitespace(text[prev]) {
+	for prev >= 0 && isWhitespace(rune(text[prev])) {
 		prev--
 	}
 	return prev
 }
 
-func atEndOfBlockComment(text *[]rune, i *int) bool {
+// keyValueSeparatorLength returns the length of the key/value separator at position i
+// (":", "=", "=>" and, when lenient, "->" or ":="), or 0 if there is none
+func keyValueSeparatorLength[T textChar](text *[]T, i int, lenient bool) int {
+	if i < 0 || i >= len(*text) {
+		return 0
+	}
+	lenient = lenient && i+1 < len(*text)
+	switch (*text)[i] {
+	case codeColon:
+		if lenient && (*text)[i+1] == codeEqual {
+			return 2
+		}
+		return 1
+	case codeMinus:
+		if lenient && (*text)[i+1] == codeGreaterThan {
+			return 2
+		}
+	case codeEqual:
+		if i+1 < len(*text) && (*text)[i+1] == codeGreaterThan {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// keySeparatorLength is keyValueSeparatorLength right after an object key, the only place
+// where LenientSeparators applies, so "a->b" stays a single value elsewhere
+func keySeparatorLength[T textChar](text *[]T, i int, opts *RepairOptions) int {
+	return keyValueSeparatorLength(text, i, opts.LenientSeparators)
+}
+
+// proseInteriorQuote reports whether the double quote at i is followed on the same line
+// by more words and then by a quote that ends the line or precedes a delimiter, as in
+// "He said "hello" to me". When the words instead run to the end of the value without a
+// closing quote, as in {"a": "x" y}, it also returns the position where the value ends, and
+// -1 otherwise. Structural characters in between mean the quote really ends the string and
+// a delimiter is missing, as in {"a":"x" b:"y"}.
+func proseInteriorQuote[T textChar](text *[]T, i int) (bool, int) {
+	j := i + 1
+	for j < len(*text) && ((*text)[j] == ' ' || (*text)[j] == codeTab) {
+		j++
+	}
+	if j >= len(*text) || !(isLetter(rune((*text)[j])) || isDigit(rune((*text)[j]))) {
+		return false, -1
+	}
+	for k := j; k < len(*text); k++ {
+		switch (*text)[k] {
+		case codeColon, codeOpeningBrace, codeOpeningBracket:
+			return false, -1
+		case codeNewline, codeReturn, codeClosingBrace, codeClosingBracket:
+			return true, k
+		case codeComma:
+			n := k + 1
+			for n < len(*text) && isWhitespace(rune((*text)[n])) {
+				n++
+			}
+			if n >= len(*text) || isQuote(rune((*text)[n])) {
+				return true, k
+			}
+		case codeDoubleQuote:
+			n := k + 1
+			for n < len(*text) && ((*text)[n] == ' ' || (*text)[n] == codeTab) {
+				n++
+			}
+			if n >= len(*text) {
+				return true, -1
+			}
+			switch (*text)[n] {
+			case codeComma, codeClosingBrace, codeClosingBracket, codeNewline, codeReturn:
+				return true, -1
+			}
+		}
+	}
+	return true, len(*text)
+}
+
+// startsQuotedToken reports whether position i holds a comma or separator followed by a quote,
+// i.e. the start of the next quoted key or value
+func startsQuotedToken[T textChar](text *[]T, i int, opts *RepairOptions) bool {
+	var j int
+	if (*text)[i] == codeComma {
+		j = i + 1
+	} else if n := keySeparatorLength(text, i, opts); n > 0 {
+		j = i + n
+	} else {
+		return false
+	}
+	for j < len(*text) && isWhitespace(rune((*text)[j])) {
+		j++
+	}
+	return j < len(*text) && isQuote(rune((*text)[j]))
+}
+
+func isKeyValueSeparator[T textChar](text *[]T, i int) bool {
+	return keyValueSeparatorLength(text, i, false) > 0
+}
+
+func atEndOfBlockComment[T textChar](text *[]T, i *int) bool {
 	return *i+1 < len(*text) && (*text)[*i] == codeAsterisk && (*text)[*i+1] == codeSlash
 }
 
-func atEndOfNumber(text *[]rune, i *int) bool {
-	return *i >= len(*text) || isDelimiter((*text)[*i]) || isWhitespace((*text)[*i])
+func atEndOfNumber[T textChar](text *[]T, i *int, opts *RepairOptions) bool {
+	if *i < len(*text) && (*text)[*i] == codeSlash {
+		// Only a comment ends a number, 3/4 is read as the unquoted string "3/4"
+		return *i+1 < len(*text) && ((*text)[*i+1] == codeSlash || (*text)[*i+1] == codeAsterisk)
+	}
+	return *i >= len(*text) || isDelimiter(rune((*text)[*i])) || isWhitespace(rune((*text)[*i])) || opts.skipsMark(rune((*text)[*i]))
+}
+
+// divisionEnd returns where a division such as the " / 4" of 3 / 4 that follows a number at i
+// ends, or -1. The expression is kept as a string rather than a key-less "/" value.
+func divisionEnd[T textChar](text *[]T, i int) int {
+	j := i
+	for j < len(*text) && ((*text)[j] == codeSpace || (*text)[j] == codeTab) {
+		j++
+	}
+	if j+1 >= len(*text) || (*text)[j] != codeSlash || (*text)[j+1] == codeSlash || (*text)[j+1] == codeAsterisk {
+		return -1
+	}
+	j++
+	for j < len(*text) && ((*text)[j] == codeSpace || (*text)[j] == codeTab) {
+		j++
+	}
+	if j >= len(*text) || !(isDigit(rune((*text)[j])) || isLetter(rune((*text)[j])) || (*text)[j] == codeOpenParenthesis) {
+		return -1
+	}
+	for j < len(*text) && (*text)[j] != codeComma && (*text)[j] != codeClosingBrace && (*text)[j] != codeClosingBracket &&
+		(*text)[j] != codeNewline && (*text)[j] != codeReturn {
+		j++
+	}
+	for isWhitespace(rune((*text)[j-1])) {
+		j--
+	}
+	return j
 }
 
-func repairNumberEndingWithNumericSymbol(text *[]rune, start int, i *int, output *strings.Builder) {
-	output.WriteString(string((*text)[start:*i]) + "0")
+func repairNumberEndingWithNumericSymbol[T textChar](text *[]T, start int, i *int, output *strings.Builder) {
+	output.WriteString(normalizeNumberText(textString((*text)[start:*i]) + "0"))
 }
 
 func stripLastOccurrence(text, textToStrip string, stripRemainingText bool) string {
@@ -1566,6 +3651,20 @@ func isStartOfValue(char rune) bool {
 	return regexStartOfValue.MatchString(string(char)) || isQuote(char)
 }
 
+// controlCharacterIndex returns the position of the first control character in text[start:end],
+// ignoring trailing whitespace, or -1
+func controlCharacterIndex[T textChar](text []T, start, end int) int {
+	for end > start && isWhitespace(rune(text[end-1])) {
+		end--
+	}
+	for idx := start; idx < end; idx++ {
+		if isControlCharacter(rune(text[idx])) {
+			return idx
+		}
+	}
+	return -1
+}
+
 func isControlCharacter(code rune) bool {
 	return code >= 0 && code <= 0x1f
 }
@@ -1651,6 +3750,48 @@ func isUnquotedStringDelimiter(char rune) bool {
 	return false
 }
 
+// isInvisibleMark reports whether code is an invisible format character: zero-width spaces and
+// joiners, direction marks, bidi embeddings and isolates, the word joiner and U+FEFF
+func isInvisibleMark(code rune) bool {
+	return (code >= 0x200b && code <= 0x200f) ||
+		(code >= 0x202a && code <= 0x202e) ||
+		code == 0x2060 ||
+		(code >= 0x2066 && code <= 0x2069) ||
+		code == 0xfeff
+}
+
+// atTrailingMarks reports whether the invisible marks starting at i end an unquoted string,
+// i.e. are followed by the end of the input, whitespace, a delimiter or a colon. Marks inside
+// the string, such as the joiners of an emoji sequence, are kept.
+func atTrailingMarks[T textChar](text *[]T, i int, opts *RepairOptions) bool {
+	if !opts.skipsMark(rune((*text)[i])) {
+		return false
+	}
+	for i < len(*text) && opts.skipsMark(rune((*text)[i])) {
+		i++
+	}
+	return i >= len(*text) || isUnquotedStringDelimiter(rune((*text)[i])) || isWhitespace(rune((*text)[i])) ||
+		isKeyValueSeparator(text, i)
+}
+
+// truncateOutput cuts output back to its first n bytes. A strings.Builder cannot shrink in
+// place, so it is only rebuilt when something was written after n; rebuilding it for every
+// value made repairing large documents quadratic.
+func truncateOutput(output *strings.Builder, n int) {
+	if output.Len() == n {
+		return
+	}
+	s := output.String()[:n]
+	output.Reset()
+	output.WriteString(s)
+}
+
+// isZeroWidth reports whether code is a zero-width space, joiner or non-joiner, the word
+// joiner or U+FEFF
+func isZeroWidth(code rune) bool {
+	return (code >= 0x200b && code <= 0x200d) || code == 0x2060 || code == 0xfeff
+}
+
 func isWhitespaceExceptNewline(code rune) bool {
 	return code == codeSpace || code == codeTab || code == codeReturn
 }
@@ -1726,6 +3867,35 @@ func isBase64String(content string) bool {
 	return base64Re.MatchString(content)
 }
 
+// truncatedBase64Marker replaces a truncated base64 value in "marker" mode
+const truncatedBase64Marker = "<truncated base64>"
+
+// repairTruncatedBase64 pads or replaces content when it looks like a base64 blob. It reports
+// false when content is not base64.
+func repairTruncatedBase64(content string, mode string) (string, bool) {
+	if !isBase64String(content) {
+		return content, false
+	}
+
+	switch mode {
+	case "marker":
+		return truncatedBase64Marker, true
+	case "pad":
+		content = strings.TrimRight(content, "=")
+		switch len(content) % 4 {
+		case 1:
+			// A single leftover character carries less than a byte and cannot be padded
+			content = content[:len(content)-1]
+		case 2:
+			content += "=="
+		case 3:
+			content += "="
+		}
+		return content, true
+	}
+	return content, false
+}
+
 func hasURLEncoding(content string) bool {
 	return urlEncodingRe.MatchString(content)
 }
@@ -1971,14 +4141,14 @@ func isLikelyFilePath(content string) bool {
 	return false
 }
 
-func analyzePotentialFilePath(text *[]rune, startIndex int) bool {
+func analyzePotentialFilePath[T textChar](text *[]T, startIndex int) bool {
 	if startIndex < 0 || startIndex >= len(*text) {
 		return false
 	}
 
 	// Find the end of the string
 	endIndex := startIndex
-	if isQuote((*text)[endIndex]) {
+	if isQuote(rune((*text)[endIndex])) {
 		quote := (*text)[endIndex]
 		endIndex++
 		for endIndex < len(*text) {
@@ -1994,12 +4164,12 @@ func analyzePotentialFilePath(text *[]rune, startIndex int) bool {
 		}
 	} else {
 		// Unquoted string
-		for endIndex < len(*text) && !isDelimiter((*text)[endIndex]) && !isWhitespace((*text)[endIndex]) {
+		for endIndex < len(*text) && !isDelimiter(rune((*text)[endIndex])) && !isWhitespace(rune((*text)[endIndex])) {
 			endIndex++
 		}
 	}
 
-	content := string((*text)[startIndex:endIndex])
+	content := textString((*text)[startIndex:endIndex])
 	// Remove surrounding quotes if present
 	if len(content) >= 2 && isQuote(rune(content[0])) && isQuote(rune(content[len(content)-1])) {
 		content = content[1 : len(content)-1]
@@ -2008,15 +4178,15 @@ func analyzePotentialFilePath(text *[]rune, startIndex int) bool {
 	return isLikelyFilePath(content)
 }
 
-func isURLStart(text *[]rune, i int) bool {
+func isURLStart[T textChar](text *[]T, i int) bool {
 	if i <= 0 || (*text)[i] != codeColon {
 		return false
 	}
 	j := i - 1
-	for j >= 0 && isLetter((*text)[j]) {
+	for j >= 0 && isLetter(rune((*text)[j])) {
 		j--
 	}
-	protocol := string((*text)[j+1 : i])
+	protocol := textString((*text)[j+1 : i])
 	if protocol == "http" || protocol == "https" || protocol == "ftp" {
 		if i+2 < len(*text) && (*text)[i+1] == codeSlash && (*text)[i+2] == codeSlash {
 			return true
@@ -2025,15 +4195,15 @@ func isURLStart(text *[]rune, i int) bool {
 	return false
 }
 
-func skipMarkdownCodeBlock(text *[]rune, i *int, blocks []string, output *strings.Builder) bool {
-	parseWhitespace(text, i, output, true)
+func skipMarkdownCodeBlock[T textChar](text *[]T, i *int, blocks []string, output *strings.Builder) bool {
+	parseWhitespace(text, i, output, true, nil)
 	for _, block := range blocks {
 		blockRunes := []rune(block)
 		end := *i + len(blockRunes)
 		if end <= len(*text) {
 			match := true
 			for j := 0; j < len(blockRunes); j++ {
-				if (*text)[*i+j] != blockRunes[j] {
+				if rune((*text)[*i+j]) != blockRunes[j] {
 					match = false
 					break
 				}
@@ -2047,6 +4217,170 @@ func skipMarkdownCodeBlock(text *[]rune, i *int, blocks []string, output *string
 	return false
 }
 
+// unescapeSourceLiteral removes one level of source code string escaping, e.g. "{\"a\": 1}" -> {"a": 1}
+func unescapeSourceLiteral(text string) string {
+	trimmed := strings.TrimSpace(text)
+	trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, ";"))
+	if len(trimmed) >= 2 && trimmed[0] == '"' && trimmed[len(trimmed)-1] == '"' {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+	var sb strings.Builder
+	for i := 0; i < len(trimmed); i++ {
+		if trimmed[i] == '\\' && i+1 < len(trimmed) {
+			switch trimmed[i+1] {
+			case '"', '\\':
+				sb.WriteByte(trimmed[i+1])
+				i++
+				continue
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				sb.WriteByte('\t')
+				i++
+				continue
+			case 'r':
+				sb.WriteByte('\r')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(trimmed[i])
+	}
+	return sb.String()
+}
+
+// extractFencedBlocks returns the contents of all fenced code blocks tagged json, json5 or
+// jsonc, or not tagged at all. An unclosed fence runs to the end of the text.
+func extractFencedBlocks(text string) []string {
+	var blocks []string
+	lines := strings.Split(text, "\n")
+	for idx := 0; idx < len(lines); idx++ {
+		fence, info, ok := parseFenceLine(lines[idx])
+		if !ok {
+			continue
+		}
+		end := idx + 1
+		for end < len(lines) {
+			if closing, closingInfo, isFence := parseFenceLine(lines[end]); isFence && closingInfo == "" && strings.HasPrefix(closing, fence) {
+				break
+			}
+			end++
+		}
+		lang := ""
+		if fields := strings.Fields(info); len(fields) > 0 {
+			lang = strings.ToLower(fields[0])
+		}
+		if lang == "" || lang == "json" || lang == "json5" || lang == "jsonc" {
+			blocks = append(blocks, strings.Join(lines[idx+1:end], "\n"))
+		}
+		idx = end
+	}
+	return blocks
+}
+
+// parseFenceLine reports whether line opens or closes a fenced code block and returns the
+// fence (``` or longer, or ~~~) together with the info string after it
+func parseFenceLine(line string) (string, string, bool) {
+	trimmed := strings.TrimRight(strings.TrimLeft(line, " "), " \t\r")
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 {
+		return "", "", false
+	}
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			n := 0
+			for n < len(trimmed) && trimmed[n] == marker[0] {
+				n++
+			}
+			return trimmed[:n], strings.TrimSpace(trimmed[n:]), true
+		}
+	}
+	return "", "", false
+}
+
+// preprocessRepairInput applies the whole-document rewrites selected in opts before parsing
+func preprocessRepairInput(text string, opts RepairOptions) (string, error) {
+	if opts.FromSourceLiteral {
+		text = unescapeSourceLiteral(text)
+	}
+	if opts.ExtractFencedBlock {
+		if blocks := extractFencedBlocks(text); len(blocks) > 0 {
+			text = blocks[0]
+		}
+	}
+	if opts.StripJSONP {
+		return stripJSONPWrapper(text)
+	}
+	return text, nil
+}
+
+// stripJSONPWrapper returns the inner value of a JSONP wrapper like callback({...}); when the
+// parentheses wrap the entire document, and the text unchanged when it does not start with a
+// callback call. A call followed by more than a semicolon, as in cb({...}) + cb({...}), is
+// rejected, since repairing only the first call would silently drop the rest.
+func stripJSONPWrapper(text string) (string, error) {
+	trimmed := strings.TrimSpace(text)
+	leading := utf8.RuneCountInString(text[:strings.Index(text, trimmed)])
+	runes := []rune(trimmed)
+	i := 0
+	// Callback names may be dotted, e.g. jQuery.cb or window.handlers.onData
+	for {
+		if i >= len(runes) || !isFunctionNameCharStart(runes[i]) {
+			return text, nil
+		}
+		for i < len(runes) && isFunctionNameChar(runes[i]) {
+			i++
+		}
+		if i < len(runes) && runes[i] == codeDot {
+			i++
+			continue
+		}
+		break
+	}
+	for i < len(runes) && isWhitespace(runes[i]) {
+		i++
+	}
+	if i >= len(runes) || runes[i] != codeOpenParenthesis {
+		return text, nil
+	}
+	start := i + 1
+
+	depth := 0
+	var quote rune
+	for j := i; j < len(runes); j++ {
+		char := runes[j]
+		if quote != 0 {
+			if char == codeBackslash {
+				j++
+			} else if char == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case char == codeDoubleQuote || char == codeQuote:
+			quote = char
+		case char == codeOpenParenthesis:
+			depth++
+		case char == codeCloseParenthesis:
+			depth--
+			if depth == 0 {
+				rest := strings.TrimSpace(string(runes[j+1:]))
+				if rest != "" && rest != ";" {
+					k := j + 1
+					for isWhitespace(runes[k]) {
+						k++
+					}
+					return "", newUnexpectedCharacterError("Unexpected content after JSONP callback", leading+k)
+				}
+				return string(runes[start:j]), nil
+			}
+		}
+	}
+	return text, nil
+}
+
 func newJSONRepairError(message string, position int, err ...error) *Error {
 	var inner error
 	if len(err) > 0 {
@@ -2075,6 +4409,15 @@ func newInvalidUnicodeError(message string, position int) *Error {
 	return newJSONRepairError(message, position, ErrInvalidUnicode)
 }
 
+// newInvalidUTF8Error reports invalid UTF-8 at the given byte offsets; Position is the first offset
+func newInvalidUTF8Error(offsets []int) *Error {
+	return newJSONRepairError("Invalid UTF-8 at byte offset "+formatOffsets(offsets, 10), offsets[0], ErrInvalidUTF8)
+}
+
 func newInvalidCharacterError(message string, position int) *Error {
 	return newJSONRepairError(message, position, ErrInvalidCharacter)
 }
+
+func newMaxDepthExceededError(maxDepth int, position int) *Error {
+	return newJSONRepairError(fmt.Sprintf("Nesting deeper than %d levels", maxDepth), position, ErrMaxDepthExceeded)
+}
@@ -0,0 +1,1348 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// repairCase is an input and the repaired output expected for it
+type repairCase struct {
+	name  string
+	input string
+	want  string
+}
+
+// runRepairCases repairs every case with opts and compares the output
+func runRepairCases(t *testing.T, opts RepairOptions, tests []repairCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONRepairWithOptions(tt.input, opts)
+			if err != nil {
+				t.Fatalf("JSONRepairWithOptions(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("JSONRepairWithOptions(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairUnquotedDates(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"date", `{created: 2024-01-15}`, `{"created": "2024-01-15"}`},
+		{"datetime", `{created: 2024-01-15T10:30:00Z}`, `{"created": "2024-01-15T10:30:00Z"}`},
+		{"datetime with offset", `{created: 2024-01-15T10:30:00+08:00, n: 1}`, `{"created": "2024-01-15T10:30:00+08:00", "n": 1}`},
+		{"space separated", "{at: 2024-01-15 10:30:00.123\n}", "{\"at\": \"2024-01-15 10:30:00.123\"\n}"},
+		{"array element", `[2024-01-15, 2024-02-01]`, `["2024-01-15", "2024-02-01"]`},
+		{"not a date", `{n: 2024-01}`, `{"n": "2024-01"}`},
+	})
+}
+
+func TestJSONRepairArrayToMatchesJSONRepair(t *testing.T) {
+	inputs := []string{
+		`[1, 2, 3]`,
+		"[\n  {\"a\": 1},\n  {\"b\": 2}\n]",
+		`[{a: 1} {b: 'x'}, ]`,
+		`[1, 2, 3`,
+		`[{"a": [1, 2`,
+		"[1, 2] // done\n",
+		"```json\n[1, 2]\n```",
+		`[1 2 3]  `,
+		`{"a": 1}`,
+		`"text"`,
+	}
+	for _, input := range inputs {
+		want, err := JSONRepairWithOptions(input, RepairOptions{})
+		if err != nil {
+			t.Fatalf("JSONRepairWithOptions(%q) failed: %v", input, err)
+		}
+		var sb strings.Builder
+		if err := JSONRepairArrayTo(&sb, input, RepairOptions{}); err != nil {
+			t.Fatalf("JSONRepairArrayTo(%q) failed: %v", input, err)
+		}
+		if sb.String() != want {
+			t.Errorf("JSONRepairArrayTo(%q) = %q, JSONRepairWithOptions = %q", input, sb.String(), want)
+		}
+	}
+}
+
+func TestJSONRepairArrayToOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  RepairOptions
+	}{
+		{"byte order mark", "\uFEFF[1, 2]", RepairOptions{}},
+		{"replaced invalid UTF-8", "[\"a\xffb\"]", RepairOptions{ReplaceInvalidUTF8: true}},
+		{"duplicate keys", `[{"a": 1, "a": 2}, {"b": 1}]`, RepairOptions{DuplicateKeys: "keep-first"}},
+		{"fold trailing pairs", `[1, 2] "k": 3`, RepairOptions{FoldTrailingPairs: true}},
+		{"allow empty", "  // nothing\n", RepairOptions{AllowEmpty: true}},
+		{"jsonp", `cb([1, 2]);`, RepairOptions{StripJSONP: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := JSONRepairWithOptions(tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("JSONRepairWithOptions(%q) failed: %v", tt.input, err)
+			}
+			var sb strings.Builder
+			if err := JSONRepairArrayTo(&sb, tt.input, tt.opts); err != nil {
+				t.Fatalf("JSONRepairArrayTo(%q) failed: %v", tt.input, err)
+			}
+			if sb.String() != want {
+				t.Errorf("JSONRepairArrayTo(%q) = %q, JSONRepairWithOptions = %q", tt.input, sb.String(), want)
+			}
+		})
+	}
+
+	err := JSONRepairArrayTo(io.Discard, "[\"a\xffb\"]", RepairOptions{})
+	if !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("JSONRepairArrayTo on invalid UTF-8 = %v, want ErrInvalidUTF8", err)
+	}
+}
+
+// largeRecordArray returns a JSON array of n small objects
+func largeRecordArray(n int) string {
+	var sb strings.Builder
+	sb.WriteString("[\n")
+	for k := 0; k < n; k++ {
+		if k > 0 {
+			sb.WriteString(",\n")
+		}
+		fmt.Fprintf(&sb, `  {"id": %d, "name": "item %d", "tags": ["a", "b"]}`, k, k)
+	}
+	sb.WriteString("\n]")
+	return sb.String()
+}
+
+func BenchmarkJSONRepairArrayTo(b *testing.B) {
+	input := largeRecordArray(20000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if err := JSONRepairArrayTo(io.Discard, input, RepairOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONRepairLargeArray(b *testing.B) {
+	input := largeRecordArray(20000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if _, err := JSONRepairWithOptions(input, RepairOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	var output strings.Builder
+	output.WriteString(`{"a": 1, "b": `)
+	n := output.Len()
+	output.WriteString(`nul`)
+	truncateOutput(&output, n)
+	if got := output.String(); got != `{"a": 1, "b": ` {
+		t.Errorf("truncateOutput = %q", got)
+	}
+	// Undoing a lookahead that wrote nothing must not copy the output
+	if allocs := testing.AllocsPerRun(100, func() { truncateOutput(&output, output.Len()) }); allocs != 0 {
+		t.Errorf("truncateOutput without anything to cut allocates %v times", allocs)
+	}
+}
+
+func TestRepairAllocationsGrowLinearly(t *testing.T) {
+	allocated := func(records int) uint64 {
+		input := largeRecordArray(records)
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if _, err := JSONRepairWithOptions(input, RepairOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+	// Four times the records should take about four times the memory, not sixteen as when the
+	// output was rebuilt for every value
+	small, large := allocated(1000), allocated(4000)
+	if large > 8*small {
+		t.Errorf("repairing 4000 records allocated %d bytes, 1000 records %d", large, small)
+	}
+}
+
+// BenchmarkTruncateOutput undoes a lookahead on a 1 MB output, with and without anything to
+// cut. Only the second rebuilds the builder.
+func BenchmarkTruncateOutput(b *testing.B) {
+	var output strings.Builder
+	output.WriteString(strings.Repeat("x", 1<<20))
+	b.Run("unchanged", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			truncateOutput(&output, output.Len())
+		}
+	})
+	b.Run("cut", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			output.WriteString("null")
+			truncateOutput(&output, 1<<20)
+		}
+	})
+}
+
+// BenchmarkRepairText compares parsing ASCII input as bytes, as JSONRepairWithOptions does,
+// with converting it to runes first
+func BenchmarkRepairText(b *testing.B) {
+	input := strings.ReplaceAll(largeRecordArray(20000), `"name"`, `name`)
+	b.Run("bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			if _, err := repairText([]byte(input), RepairOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("runes", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			if _, err := repairText([]rune(input), RepairOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRepairTextBytesMatchRunes(t *testing.T) {
+	// wide replaces word in the non-ASCII variant of each input. It has as many runes as word,
+	// so error positions agree too.
+	const wide = "w\xc3\xa9r\xe4\xb8\xad"
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unquoted key, single quotes and comment", "{name: 'word', \"b\": [1, 2,], // word\n}"},
+		{"truncated string", `{"a": "word`},
+		{"unquoted and concatenated values", `[word, "word" "word"]`},
+		{"missing comma at a newline", "{a: word\n b: 2}"},
+		{"JSONP and Python constants", `callback({"word": True, "n": None})`},
+		{"markdown fence", "```json\n{\"word\": 1}\n```"},
+		{"missing comma between entries", `{"a": 1 "b": word}`},
+		{"missing value", `{"word": }`},
+		{"extra brackets and text", `[1, 2, 3 word ]] extra`},
+		{"block comment and missing brackets", `/* word */ {"a": [word]`},
+		{"invalid escape", `{"a": "word\q"}`},
+		{"numbers", `[0x1F, 1e, .5, +3, NaN, undefined, word]`},
+		{"colon expected", `{"word": 1, @}`},
+		{"trailing comma at the end", `{"word": 1,`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromBytes, errBytes := repairText([]byte(tt.input), RepairOptions{})
+			fromRunes, errRunes := repairText([]rune(tt.input), RepairOptions{})
+			if fromBytes != fromRunes || fmt.Sprint(errBytes) != fmt.Sprint(errRunes) {
+				t.Errorf("as bytes = %q, %v; as runes = %q, %v", fromBytes, errBytes, fromRunes, errRunes)
+			}
+
+			got, err := JSONRepairWithOptions(strings.ReplaceAll(tt.input, "word", wide), RepairOptions{})
+			if got != strings.ReplaceAll(fromBytes, "word", wide) || fmt.Sprint(err) != fmt.Sprint(errBytes) {
+				t.Errorf("non-ASCII input = %q, %v; want %q, %v", got, err, strings.ReplaceAll(fromBytes, "word", wide), errBytes)
+			}
+		})
+	}
+}
+
+func TestRepairTruncatedBase64(t *testing.T) {
+	// blob is 24 characters of complete base64; the cases cut it at every length modulo 4
+	const blob = "SGVsbG8sIFdvcmxkISBIaSEh"
+	tests := []struct {
+		name  string
+		input string
+		mode  string
+		want  string
+	}{
+		{"kept by default", `{"data": "` + blob + `SGV`, "", `{"data": "` + blob + `SGV"}`},
+		{"pad complete", `{"data": "` + blob, "pad", `{"data": "` + blob + `"}`},
+		{"pad one extra", `{"data": "` + blob + `S`, "pad", `{"data": "` + blob + `"}`},
+		{"pad two extra", `{"data": "` + blob + `SG`, "pad", `{"data": "` + blob + `SG=="}`},
+		{"pad three extra", `{"data": "` + blob + `SGV`, "pad", `{"data": "` + blob + `SGV="}`},
+		{"pad partial padding", `{"data": "` + blob + `SG=`, "pad", `{"data": "` + blob + `SG=="}`},
+		{"marker", `{"data": "` + blob + `SGV`, "marker", `{"data": "<truncated base64>"}`},
+		{"marker in array", `["a", "` + blob + `SGV`, "marker", `["a", "<truncated base64>"]`},
+		{"marker nested", `{"files": [{"name": "a.png", "content": "` + blob + `SGV`, "marker", `{"files": [{"name": "a.png", "content": "<truncated base64>"}]}`},
+		{"complete value untouched", `{"data": "` + blob + `SGV", "n": 1`, "marker", `{"data": "` + blob + `SGV", "n": 1}`},
+		{"short value untouched", `{"data": "SGVsbG8`, "marker", `{"data": "SGVsbG8"}`},
+		{"not base64", `{"text": "hello world this is not base64`, "marker", `{"text": "hello world this is not base64"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONRepairWithOptions(tt.input, RepairOptions{TruncatedBase64: tt.mode})
+			if err != nil {
+				t.Fatalf("JSONRepairWithOptions(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("JSONRepairWithOptions(%q, %q) = %q, want %q", tt.input, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairJSON5Numbers(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"hex", `{"a": 0xFF}`, `{"a": 255}`},
+		{"hex lower case prefix and digits", `[0xdeadbeef]`, `[3735928559]`},
+		{"hex upper case prefix", `[0XaB]`, `[171]`},
+		{"negative hex", `[-0x10]`, `[-16]`},
+		{"positive hex", `[+0x10]`, `[16]`},
+		{"hex with separators", `[0xFF_FF]`, `[65535]`},
+		{"leading dot", `[.25]`, `[0.25]`},
+		{"negative leading dot", `[-.5]`, `[-0.5]`},
+		{"trailing dot", `[42.]`, `[42.0]`},
+		{"separators in integer", `[1_000_000]`, `[1000000]`},
+		{"separators in fraction", `[3.141_592]`, `[3.141592]`},
+		{"separators in exponent", `[1e1_0]`, `[1e10]`},
+	})
+}
+
+func TestRepairStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"records", "{\"a\": 1}\n{b: 2}\n", "{\"a\":1}\n{\"b\":2}\n"},
+		{"blank lines skipped", "\n  \n{\"a\": 1}\n\n\t\n[1, 2,]\n\n", "{\"a\":1}\n[1,2]\n"},
+		{"record over several lines", "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n{\"b\": 2}\n", "{\"a\":[1,2]}\n{\"b\":2}\n"},
+		{"truncated record", "{\"a\": [1, 2\n{\"b\": 2}\n", "{\"a\":[1,2]}\n{\"b\":2}\n"},
+		{"brackets in strings", "{\"a\": \"{[\"}\n{\"b\": \"]}\"}\n", "{\"a\":\"{[\"}\n{\"b\":\"]}\"}\n"},
+		{"no final newline", "{\"a\": 1}", "{\"a\":1}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := RepairStream(strings.NewReader(tt.input), &sb, RepairOptions{}); err != nil {
+				t.Fatalf("RepairStream(%q) failed: %v", tt.input, err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("RepairStream(%q) = %q, want %q", tt.input, sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairStreamErrorLine(t *testing.T) {
+	err := RepairStream(strings.NewReader("{\"a\": 1}\n\n\n]\n"), io.Discard, RepairOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+		t.Errorf("RepairStream error = %v, want one for line 4", err)
+	}
+}
+
+// recordReader streams a record line over and over until size bytes have been read, so a
+// benchmark can repair a large input without holding it in memory
+type recordReader struct {
+	record    string
+	remaining int
+	offset    int
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && r.remaining > 0 {
+		c := copy(p[n:], r.record[r.offset:])
+		if c > r.remaining {
+			c = r.remaining
+		}
+		n += c
+		r.remaining -= c
+		r.offset = (r.offset + c) % len(r.record)
+	}
+	return n, nil
+}
+
+// heapSampler discards what is written to it and samples the heap every 4096 writes
+type heapSampler struct {
+	writes int
+	peak   uint64
+}
+
+func (h *heapSampler) Write(p []byte) (int, error) {
+	h.writes++
+	if h.writes%4096 == 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapInuse > h.peak {
+			h.peak = stats.HeapInuse
+		}
+	}
+	return len(p), nil
+}
+
+// BenchmarkRepairStream reports the peak heap while streaming: it stays the same whether the
+// stream is 4 MB or 64 MB, since only the current record is held
+func BenchmarkRepairStream(b *testing.B) {
+	const record = "{id: 1, \"name\": 'item', \"tags\": [\"a\", \"b\",], \"price\": 12.50}\n"
+	for _, size := range []int{4 << 20, 64 << 20} {
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			runtime.GC()
+			sampler := &heapSampler{}
+			for n := 0; n < b.N; n++ {
+				if err := RepairStream(&recordReader{record: record, remaining: size}, sampler, RepairOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(sampler.peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
+
+// BenchmarkRepairStreamLongRecord streams one record spanning 20000 lines, which is held in
+// the window until its brackets balance
+func BenchmarkRepairStreamLongRecord(b *testing.B) {
+	input := largeRecordArray(20000)
+	b.SetBytes(int64(len(input)))
+	for n := 0; n < b.N; n++ {
+		if err := RepairStream(strings.NewReader(input), io.Discard, RepairOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRepairBracelessElements(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"root object", `"a":1,"b":2}`, `{"a":1,"b":2}`},
+		{"root unquoted keys", `a:1, b:2}`, `{"a":1, "b":2}`},
+		{"single element", `["a":1}]`, `[{"a":1}]`},
+		{"several elements", `["a":1}, "b":2}]`, `[{"a":1}, {"b":2}]`},
+		{"several members", `["a":1, "b":2}, "c":3}]`, `[{"a":1, "b":2}, {"c":3}]`},
+		{"unquoted keys", `[a:1}, b:'x'}]`, `[{"a":1}, {"b":"x"}]`},
+		{"nested value with braces in strings", `["a": {"x": [1, "}"]}, "b": 2}]`, `[{"a": {"x": [1, "}"]}, "b": 2}]`},
+		{"followed by a plain value", `["a":1}, 2]`, `[{"a":1}, 2]`},
+		{"key after a plain value closes the array", `{"a": [1, "b": 2}`, `{"a": [1], "b": 2}`},
+		{"key after an object closes the array", `{"list": [1, 2, "next": true, "z": {"q": 1}}`, `{"list": [1, 2], "next": true, "z": {"q": 1}}`},
+		{"nested unclosed arrays", `{"a": [1, "b": [1, "c": 2}}`, `{"a": [1], "b": [1], "c": 2}`},
+	})
+}
+
+func TestRepairDeeplyNestedBracelessElements(t *testing.T) {
+	// Each level used to be parsed once speculatively and once for real, doubling per level
+	input := strings.Repeat(`["a":`, 40) + "1" + strings.Repeat("}]", 40)
+	want := strings.Repeat(`[{"a":`, 40) + "1" + strings.Repeat("}]", 40)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		got, err := JSONRepairWithOptions(input, RepairOptions{})
+		if err != nil || got != want {
+			t.Errorf("JSONRepairWithOptions(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("repairing 40 nested braceless elements did not finish in 10s")
+	}
+}
+
+func TestRepairHashComments(t *testing.T) {
+	runRepairCases(t, RepairOptions{HashComments: true}, []repairCase{
+		{"comment line", "{\n# name\n\"a\": 1\n}", "{\n\n\"a\": 1\n}"},
+		{"leading comment", "# config\n{\"a\": 1}", "\n{\"a\": 1}"},
+		{"trailing comment", "{\"a\": 1} # done", "{\"a\": 1} "},
+		{"after a value", "{\"a\": 1, # first\n\"b\": 2}", "{\"a\": 1, \n\"b\": 2}"},
+		{"in a string", `{"a": "x # y"}`, `{"a": "x # y"}`},
+		{"url fragment", `{"url": "http://x#section"}`, `{"url": "http://x#section"}`},
+		{"unquoted url fragment", "{url: http://x#section\n}", "{\"url\": \"http://x#section\"\n}"},
+		{"unquoted value with hash", "{tag: a#b\n}", "{\"tag\": \"a#b\"\n}"},
+		{"array", "[1, # one\n2]", "[1, \n2]"},
+	})
+}
+
+func TestRepairFromSourceLiteral(t *testing.T) {
+	runRepairCases(t, RepairOptions{FromSourceLiteral: true}, []repairCase{
+		{"java literal", `"{\"name\": \"Ann\", \"age\": 30}"`, `{"name": "Ann", "age": 30}`},
+		{"java statement", `"{\"tags\": [\"a\", \"b\"]}";`, `{"tags": ["a", "b"]}`},
+		{"without outer quotes", `{\"a\":1}`, `{"a":1}`},
+		{"go escaped newline", `"{\"msg\":\"x\\ny\"}"`, `{"msg":"x\ny"}`},
+		{"go escaped backslash", `"{\"re\":\"a\\\\d+\"}"`, `{"re":"a\\d+"}`},
+		{"literal newline and tab", `"{\n\t\"a\": 1\n}"`, "{\n\t\"a\": 1\n}"},
+	})
+}
+
+func TestJSONRepairUnmarshal(t *testing.T) {
+	type person struct {
+		Name string   `json:"name"`
+		Age  int      `json:"age"`
+		Tags []string `json:"tags"`
+	}
+	var p person
+	if err := JSONRepairUnmarshal(`{name: 'Ann', age: 30, tags: ['a' 'b'],}`, &p, RepairOptions{}); err != nil {
+		t.Fatalf("JSONRepairUnmarshal into a struct failed: %v", err)
+	}
+	if want := (person{Name: "Ann", Age: 30, Tags: []string{"a", "b"}}); !reflect.DeepEqual(p, want) {
+		t.Errorf("JSONRepairUnmarshal = %+v, want %+v", p, want)
+	}
+
+	var m map[string]interface{}
+	if err := JSONRepairUnmarshal(`{"a": [1, 2, "b": true`, &m, RepairOptions{}); err != nil {
+		t.Fatalf("JSONRepairUnmarshal into a map failed: %v", err)
+	}
+	if want := map[string]interface{}{"a": []interface{}{1.0, 2.0}, "b": true}; !reflect.DeepEqual(m, want) {
+		t.Errorf("JSONRepairUnmarshal = %#v, want %#v", m, want)
+	}
+}
+
+func TestJSONRepairUnmarshalErrors(t *testing.T) {
+	var repairErr *Error
+	if err := JSONRepairUnmarshal("", &map[string]interface{}{}, RepairOptions{}); !errors.As(err, &repairErr) {
+		t.Errorf("JSONRepairUnmarshal on empty input = %v, want a wrapped *Error", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	var n int
+	if err := JSONRepairUnmarshal(`{a: 1}`, &n, RepairOptions{}); !errors.As(err, &typeErr) || errors.As(err, &repairErr) {
+		t.Errorf("JSONRepairUnmarshal into a mismatched type = %v, want a wrapped *json.UnmarshalTypeError", err)
+	}
+}
+
+func TestRepairMixedSeparators(t *testing.T) {
+	// Whitespace before a separator is kept and ends up after the colon
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"mixed in one object", `{"a": 1, "b" = 2, "c" => 3}`, `{"a": 1, "b":  2, "c":  3}`},
+		{"unquoted keys", `{a = 1, b => "x", c: true}`, `{"a":  1, "b":  "x", "c": true}`},
+		{"without spaces", `{"a"=1,"b"=>2,"c":3}`, `{"a":1,"b":2,"c":3}`},
+		{"nested", `{"outer" => {"inner" = [1, 2]}, "n": null}`, `{"outer":  {"inner":  [1, 2]}, "n": null}`},
+		{"array of objects", `[{"a" => 1}, {"a" = 2}, {"a": 3}]`, `[{"a":  1}, {"a":  2}, {"a": 3}]`},
+		{"separators in values stay", `{"op" => "a=>b", "eq" = "x=y"}`, `{"op":  "a=>b", "eq":  "x=y"}`},
+	})
+}
+
+func TestRepairUnitSuffixes(t *testing.T) {
+	input := `{timeout: 30s, delay: 1.5h, size: 10MB, rate: 50%, other: 3px}`
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"kept as strings", input, `{"timeout": "30s", "delay": "1.5h", "size": "10MB", "rate": "50%", "other": "3px"}`},
+	})
+	runRepairCases(t, RepairOptions{UnitSuffixMode: "split"}, []repairCase{
+		{"split", input, `{"timeout": {"value":30,"unit":"s"}, "delay": {"value":1.5,"unit":"h"}, "size": {"value":10,"unit":"MB"}, "rate": {"value":50,"unit":"%"}, "other": "3px"}`},
+		{"array elements", `[5ms, -2d]`, `[{"value":5,"unit":"ms"}, {"value":-2,"unit":"d"}]`},
+	})
+	runRepairCases(t, RepairOptions{UnitSuffixMode: "canonical"}, []repairCase{
+		{"canonical", input, `{"timeout": 30, "delay": 5400, "size": 10485760, "rate": 0.5, "other": "3px"}`},
+		{"unknown unit", `{len: 2km}`, `{"len": "2km"}`},
+	})
+	runRepairCases(t, RepairOptions{UnitSuffixMode: "canonical", UnitTable: map[string]float64{"km": 1000}}, []repairCase{
+		{"custom unit", `{len: 2km, t: 30s}`, `{"len": 2000, "t": "30s"}`},
+	})
+}
+
+func TestRepairMismatchedQuotes(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"single then double", `{"a": 'x"}`, `{"a": "x"}`},
+		{"double then single", `{"a": "x'}`, `{"a": "x"}`},
+		{"key and value", `{"key': 'value"}`, `{"key": "value"}`},
+		{"followed by comma", `{"a": 'x", "b": "y'}`, `{"a": "x", "b": "y"}`},
+		{"in array", `['x", "y']`, `["x", "y"]`},
+		{"curly then straight", `{"a": “x"}`, `{"a": "x"}`},
+		{"straight then curly", `{"a": "x”}`, `{"a": "x"}`},
+		{"curly single then double", `{"a": ‘x"}`, `{"a": "x"}`},
+		{"apostrophe inside", `{"a": "it's"}`, `{"a": "it's"}`},
+	})
+}
+
+func TestRepairJSONP(t *testing.T) {
+	runRepairCases(t, RepairOptions{StripJSONP: true}, []repairCase{
+		{"object", `cb({"a": 1})`, `{"a": 1}`},
+		{"array with semicolon", `cb([1, 2]);`, `[1, 2]`},
+		{"dotted callback", ` jQuery.handlers.onData ({"a": "x)"}) ; `, `{"a": "x)"}`},
+		{"callback text inside a string", `{"a": "cb(1)"}`, `{"a": "cb(1)"}`},
+		{"no wrapper", `{"a": 1}`, `{"a": 1}`},
+	})
+	for _, input := range []string{`cb({"a":1}) + cb({"b":2})`, `cb({"a":1}); cb({"b":2});`, `cb({"a":1}) x`} {
+		_, err := JSONRepairWithOptions(input, RepairOptions{StripJSONP: true})
+		if !errors.Is(err, ErrUnexpectedCharacter) {
+			t.Errorf("JSONRepairWithOptions(%q) error = %v, want ErrUnexpectedCharacter", input, err)
+		}
+	}
+	var repairErr *Error
+	if _, err := JSONRepairWithOptions("  cb(1)  + x", RepairOptions{StripJSONP: true}); !errors.As(err, &repairErr) || repairErr.Position != 9 {
+		t.Errorf("JSONRepairWithOptions error = %v, want position 9", err)
+	}
+}
+
+func TestJSONRepairSequence(t *testing.T) {
+	input := "\x1e{\"a\": 1}\n\x1e{'b': 2,}\n\x1e[1, 2\n\x1e\n"
+	got, err := JSONRepairSequence(input, RepairOptions{})
+	if err != nil {
+		t.Fatalf("JSONRepairSequence failed: %v", err)
+	}
+	want := []string{`{"a": 1}`, `{"b": 2}`, `[1, 2]`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONRepairSequence = %q, want %q", got, want)
+	}
+
+	if _, err := JSONRepairSequence("\x1e{\"a\": 1}\n\x1e]\n", RepairOptions{}); err == nil || !strings.HasPrefix(err.Error(), "record 2:") {
+		t.Errorf("JSONRepairSequence error = %v, want an error for record 2", err)
+	}
+}
+
+func TestRepairFunctionCalls(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"quoted argument", `{"a": ObjectId("abc")}`, `{"a": "abc"}`},
+		{"quoted date", `{"a": ISODate("2020-01-01"), "b": 1}`, `{"a": "2020-01-01", "b": 1}`},
+		{"single quoted argument", `{"a": ObjectId('abc')}`, `{"a": "abc"}`},
+		{"number argument", `{"a": NumberLong(123)}`, `{"a": 123}`},
+		{"trailing comma", `{"a": NumberLong(123,)}`, `{"a": 123}`},
+		{"quoted argument with trailing comma", `{"a": ObjectId("abc", ), "b": 2}`, `{"a": "abc", "b": 2}`},
+		{"no arguments", `{"a": Date()}`, `{"a": null}`},
+		{"multiple arguments", `{"a": Timestamp(1, 2)}`, `{"a": [1, 2]}`},
+		{"multiple quoted arguments", `{"a": Foo("x", "y",), "b": 1}`, `{"a": ["x", "y"], "b": 1}`},
+		{"nested call", `{"a": Foo(Bar("x"), 2)}`, `{"a": ["x", 2]}`},
+		{"in an array", `[ObjectId("a"), ObjectId("b")]`, `["a", "b"]`},
+		{"paren inside a string stays", `{"a": "x)"}`, `{"a": "x)"}`},
+	})
+}
+
+func TestJSONRepairFencedBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"prose around a block", "Here is the data:\n\n```json\n{name: 'a',}\n```\n\nLet me know if you need more.", []string{`{"name": "a"}`}},
+		{"multiple blocks", "First:\n```json\n[1, 2,]\n```\nThen some code:\n```python\nprint(1)\n```\nAnd bare:\n```\n{\"b\": true}\n```\n", []string{`[1, 2]`, `{"b": true}`}},
+		{"unclosed fence", "Result:\n```json\n{\"a\": [1, 2", []string{`{"a": [1, 2]}`}},
+		{"no fences", `{"a": 1,}`, []string{`{"a": 1}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONRepairFencedBlocks(tt.input, RepairOptions{})
+			if err != nil {
+				t.Fatalf("JSONRepairFencedBlocks failed: %v", err)
+			}
+			for idx := range got {
+				got[idx] = strings.TrimSpace(got[idx])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSONRepairFencedBlocks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	runRepairCases(t, RepairOptions{ExtractFencedBlock: true}, []repairCase{
+		{"extract first block", "Sure!\n```json\n{\"a\": 1}\n```\nand\n```json\n{\"b\": 2}\n```", "{\"a\": 1}"},
+	})
+}
+
+func TestRepairKeyControlChars(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"escaped by default", "{\"a\tb\": 1}", `{"a\tb": 1}`},
+	})
+	runRepairCases(t, RepairOptions{KeyControlChars: "strip"}, []repairCase{
+		{"strip tab", "{\"a\tb\": 1}", `{"ab": 1}`},
+		{"strip newline", "{\"first\nname\": \"x\"}", `{"firstname": "x"}`},
+		{"values keep control characters", "{\"k\": \"a\tb\"}", `{"k": "a\tb"}`},
+		{"clean keys unchanged", `{"a": {"b": 1}}`, `{"a": {"b": 1}}`},
+	})
+
+	tests := []struct {
+		input    string
+		position int
+	}{
+		{"{\"a\tb\": 1}", 3},
+		{"{\"ok\": 1, \"bad\nkey\": 2}", 14},
+	}
+	for _, tt := range tests {
+		_, err := JSONRepairWithOptions(tt.input, RepairOptions{KeyControlChars: "error"})
+		var repairErr *Error
+		if !errors.Is(err, ErrInvalidCharacter) || !errors.As(err, &repairErr) || repairErr.Position != tt.position {
+			t.Errorf("JSONRepairWithOptions(%q) error = %v, want ErrInvalidCharacter at %d", tt.input, err, tt.position)
+		}
+	}
+	if _, err := JSONRepairWithOptions("{\"k\": \"a\tb\"}", RepairOptions{KeyControlChars: "error"}); err != nil {
+		t.Errorf("control character in a value rejected: %v", err)
+	}
+}
+
+func TestRepairProseQuotes(t *testing.T) {
+	runRepairCases(t, RepairOptions{ProseQuotes: true}, []repairCase{
+		{"quoted word", `{"a": "He said "hello" to me"}`, `{"a": "He said \"hello\" to me"}`},
+		{"several quoted words", `{"a": "say "hi" and "bye" now", "b": 2}`, `{"a": "say \"hi\" and \"bye\" now", "b": 2}`},
+		{"words after the last quote", `{"a": "x" y}`, `{"a": "x\" y"}`},
+		{"words before the next key", `{"a": "x" y, "b": 1}`, `{"a": "x\" y", "b": 1}`},
+		{"words before a newline", "{\"a\": \"x\" y\n\"b\": 1}", "{\"a\": \"x\\\" y\",\n\"b\": 1}"},
+		{"array elements", `["x" y, "z"]`, `["x\" y", "z"]`},
+		{"missing delimiter before a key", `{"a":"x" b:"y"}`, `{"a":"x", "b":"y"}`},
+		{"missing comma between strings", `["a" "b"]`, `["a", "b"]`},
+	})
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"default splits at the quote", `["x" y]`, `["x", "y"]`},
+	})
+}
+
+func TestRepairLenientSeparators(t *testing.T) {
+	runRepairCases(t, RepairOptions{LenientSeparators: true}, []repairCase{
+		{"arrow", `{"a" -> 1}`, `{"a":  1}`},
+		{"short assignment", `{"b" := 2}`, `{"b" : 2}`},
+		{"unquoted keys", `{a -> 1, b := "x"}`, `{"a":  1, "b" : "x"}`},
+		{"missing comma", `{"a" -> "x" "b" -> 2}`, `{"a":  "x", "b":  2}`},
+		{"newline separated", "{\n  a -> 1\n  b -> 2\n}", "{\n  \"a\":  1,\n  \"b\":  2\n}"},
+		{"arrow in an unquoted value", `{"y": a->b}`, `{"y": "a->b"}`},
+		{"arrow in a value before a key", `{"y": a->b, "z" -> 1}`, `{"y": "a->b", "z":  1}`},
+		{"arrow in an array", `[a->b, c]`, `["a->b", "c"]`},
+		{"arrow in a string", `{"y": "s->t", "z": "u := v"}`, `{"y": "s->t", "z": "u := v"}`},
+	})
+
+	if _, err := JSONRepairWithOptions(`{"a" -> 1}`, RepairOptions{}); err == nil {
+		t.Errorf("-> was accepted without LenientSeparators")
+	}
+}
+
+func TestJSONRepairWithReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		actions []string
+	}{
+		{"valid input", `{"a": 1}`, `{"a": 1}`, []string{}},
+		{"key, comma and brace", `{a: 1 "b": 2`, `{"a": 1, "b": 2}`, []string{"quoted-key@1", "inserted-comma@6", "closed-brace@12"}},
+		{"array comma and trailing comma", `[1 2,]`, `[1, 2]`, []string{"inserted-comma@3", "removed-trailing-comma@5"}},
+		{"line comment", `{"a": 1} // c`, `{"a": 1} `, []string{"removed-comment@9"}},
+		{"block comment", `{"a": 1 /* c */}`, `{"a": 1 }`, []string{"removed-comment@8"}},
+		{"missing colon", `{"a" 1}`, `{"a": 1}`, []string{"inserted-colon@5"}},
+		{"missing value", `{"a": }`, `{"a": null}`, []string{"inserted-null@6"}},
+		{"empty array element", `[1,,2]`, `[1,null,2]`, []string{"inserted-null@3"}},
+		{"unterminated string", `["abc`, `["abc"]`, []string{"closed-string@5", "closed-bracket@5"}},
+		{"arrow separator", `{"a" => 1}`, `{"a":  1}`, []string{"replaced-separator@5"}},
+		{"missing opening brace", `"a":1}`, `{"a":1}`, []string{"opened-brace@0"}},
+		// Positions count the byte order mark the parser drops
+		{"byte order mark", "\uFEFF{a: 1}", `{"a": 1}`, []string{"quoted-key@2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, actions, err := JSONRepairWithReport(tt.input, false)
+			if err != nil {
+				t.Fatalf("JSONRepairWithReport(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("JSONRepairWithReport(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if plain, _ := JSONRepair(tt.input, false); plain != got {
+				t.Errorf("JSONRepair(%q) = %q, want the same output as the report variant %q", tt.input, plain, got)
+			}
+			kinds := []string{}
+			for _, action := range actions {
+				if action.Description == "" {
+					t.Errorf("action %+v has no description", action)
+				}
+				kinds = append(kinds, action.Kind+"@"+strconv.Itoa(action.Position))
+			}
+			if !reflect.DeepEqual(kinds, tt.actions) {
+				t.Errorf("JSONRepairWithReport(%q) actions = %v, want %v", tt.input, kinds, tt.actions)
+			}
+		})
+	}
+
+	if _, actions, err := JSONRepairWithReport(`]`, false); err == nil || actions != nil {
+		t.Errorf("JSONRepairWithReport on unrepairable input = %v, %v, want an error and no actions", actions, err)
+	}
+}
+
+func TestJSONRepairFirst(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		remainder string
+	}{
+		{"two objects", `{"a":1} {"b":2}`, `{"a":1}`, `{"b":2}`},
+		{"adjacent values", `{a:1}{b:2}`, `{"a":1}`, `{b:2}`},
+		{"broken remainder kept as is", `[1, 2] [3`, `[1, 2]`, `[3`},
+		{"newline separated numbers", "1\n2\n3", `1`, "2\n3"},
+		{"trailing words", `"x" tail`, `"x"`, `tail`},
+		{"single value", `{"a":1}`, `{"a":1}`, ``},
+		{"repaired first value", `{"a" 1 `, `{"a": 1}`, ``},
+		{"byte order mark", "\uFEFF[1] [2]", `[1]`, `[2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, remainder, err := JSONRepairFirst(tt.input, RepairOptions{})
+			if err != nil {
+				t.Fatalf("JSONRepairFirst(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want || remainder != tt.remainder {
+				t.Errorf("JSONRepairFirst(%q) = %q, %q, want %q, %q", tt.input, got, remainder, tt.want, tt.remainder)
+			}
+		})
+	}
+
+	// Peeling values one at a time consumes the whole stream
+	var values []string
+	for rest := `{"id": 1} [true] "s" 4.5 {id: 5`; rest != ""; {
+		value, remainder, err := JSONRepairFirst(rest, RepairOptions{})
+		if err != nil {
+			t.Fatalf("JSONRepairFirst(%q) failed: %v", rest, err)
+		}
+		values = append(values, value)
+		rest = remainder
+	}
+	if want := []string{`{"id": 1}`, `[true]`, `"s"`, `4.5`, `{"id": 5}`}; !reflect.DeepEqual(values, want) {
+		t.Errorf("peeled values = %q, want %q", values, want)
+	}
+
+	for _, input := range []string{"", "  ", "]"} {
+		if _, _, err := JSONRepairFirst(input, RepairOptions{}); !errors.Is(err, ErrUnexpectedEnd) {
+			t.Errorf("JSONRepairFirst(%q) error = %v, want ErrUnexpectedEnd", input, err)
+		}
+	}
+}
+
+func TestRepairNDJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		lines string
+		array string
+	}{
+		{"valid records", "{\"a\":1}\n{\"b\":2}\n", "{\"a\":1}\n{\"b\":2}", `[{"a":1},{"b":2}]`},
+		{"blank and comment lines skipped", "{a:1}\n\n// c\n{b:2, \n[1 2]", "{\"a\":1}\n{\"b\":2}\n[1, 2]", `[{"a":1},{"b":2},[1, 2]]`},
+		{"text after a record dropped", "{\"a\":1} extra\n2", "{\"a\":1}\n2", `[{"a":1},2]`},
+		{"byte order mark", "\uFEFF{\"a\":1}\n", `{"a":1}`, `[{"a":1}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := RepairNDJSON(tt.input, false, false)
+			if err != nil || lines != tt.lines {
+				t.Errorf("RepairNDJSON(%q) = %q, %v, want %q", tt.input, lines, err, tt.lines)
+			}
+			array, err := RepairNDJSON(tt.input, false, true)
+			if err != nil || array != tt.array {
+				t.Errorf("RepairNDJSON(%q, wrapInArray) = %q, %v, want %q", tt.input, array, err, tt.array)
+			}
+		})
+	}
+
+	if _, err := RepairNDJSON("\n\n", false, false); !errors.Is(err, ErrUnexpectedEnd) {
+		t.Errorf("RepairNDJSON of blank lines error = %v, want ErrUnexpectedEnd", err)
+	}
+	if got, err := RepairNDJSON("\n\n", false, true); err != nil || got != "[]" {
+		t.Errorf("RepairNDJSON of blank lines as array = %q, %v, want []", got, err)
+	}
+	if _, err := RepairNDJSON("\"a\xff\"\n", false, true); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("RepairNDJSON of invalid UTF-8 error = %v, want ErrInvalidUTF8", err)
+	}
+}
+
+func TestRepairMalformedExponents(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"bare e", `[1e]`, `[1e0]`},
+		{"bare E after fraction", `[1.5E]`, `[1.5E0]`},
+		{"e at end of input", `1.5e`, `1.5e0`},
+		{"plus only", `[1.5e+]`, `[1.5e+0]`},
+		{"minus only", `[-1e-]`, `[-1e-0]`},
+		{"doubled minus", `[1e--5]`, `[1e-5]`},
+		{"doubled plus", `[1e++5]`, `[1e+5]`},
+		{"mixed signs", `[1e+-+]`, `[1e-0]`},
+		{"dot before exponent", `[1.e5]`, `[1.0e5]`},
+		{"dot before bare exponent", `[1.e, 2]`, `[1.0e0, 2]`},
+		{"leading dot", `[.5e]`, `[0.5e0]`},
+		{"separators in exponent", `[1e1_0]`, `[1e10]`},
+		{"letters after bare e in object", `{"d": 1ex}`, `{"d": "1ex"}`},
+		{"letters after bare e in array", `[1ex, 2]`, `["1ex", 2]`},
+		{"letters after sign", `{"d": 1e+x, "e": 2}`, `{"d": "1e+x", "e": 2}`},
+		{"letters after uppercase E", `{"d": 1.5Ex}`, `{"d": "1.5Ex"}`},
+		{"letters after digits", `{"d": 1e5x}`, `{"d": "1e5x"}`},
+		{"non-ASCII letters", `[1eé]`, `["1eé"]`},
+		{"whitespace after bare e", `[1e 2]`, `[1e0, 2]`},
+		{"comment after bare e", `[1e/*c*/]`, `[1e0]`},
+		{"bare e before closing brace", `{"a": 1E}`, `{"a": 1E0}`},
+	})
+}
+
+func TestRepairWordsStartingWithE(t *testing.T) {
+	// An e or E only starts an exponent after mantissa digits
+	cases := []repairCase{
+		{"object value", `{status: enabled}`, `{"status": "enabled"}`},
+		{"short word", `{name: eve}`, `{"name": "eve"}`},
+		{"array of words", `[error, warning]`, `["error", "warning"]`},
+		{"direction", `{a: east}`, `{"a": "east"}`},
+		{"uppercase", `{a: E}`, `{"a": "E"}`},
+		{"after a number", `[1e, e]`, `[1e0, "e"]`},
+	}
+	runRepairCases(t, RepairOptions{}, cases)
+	for _, c := range cases {
+		if got, err := JSONRepair(c.input, false); err != nil || !json.Valid([]byte(got)) {
+			t.Errorf("%s: JSONRepair(%q) = %q, %v, want valid JSON", c.name, c.input, got, err)
+		}
+	}
+}
+
+func TestRepairPythonLiteral(t *testing.T) {
+	runRepairCases(t, RepairOptions{PythonLiterals: true}, []repairCase{
+		{"tuple, set and constants", `{'a': (1, 2), 'b': {1, 2}, 'c': None, 'd': True}`, `{"a": [1,2], "b": [1,2], "c": null, "d": true}`},
+		{"single-element tuple", `(1,)`, `[1]`},
+		{"empty tuple", `()`, `[]`},
+		{"empty tuple value", `{'a': ()}`, `{"a": []}`},
+		{"nested tuples", `{'t': ('x', ('y', 'z'))}`, `{"t": ["x",["y","z"]]}`},
+		{"tuples in a list", `[(1, 2), (3, 4)]`, `[[1,2], [3,4]]`},
+		{"mixed set", `{1, 'a'}`, `[1,"a"]`},
+		{"empty dict", `{}`, `{}`},
+		{"set call", `set([1, 2])`, `[1, 2]`},
+		{"frozenset call", `frozenset({3})`, `[3]`},
+		{"empty set call", `{'s': set()}`, `{"s": []}`},
+		{"complex number", `{'n': -1.5e3, 'x': 1j}`, `{"n": -1.5e3, "x": "1j"}`},
+		{"escaped single quote", `{'k': 'it\'s'}`, `{"k": "it's"}`},
+		{"escaped single quote in a list", `['it\'s', 'b']`, `["it's", "b"]`},
+	})
+
+	// repr() output escapes single quotes the same way without PythonLiterals
+	if got, err := JSONRepair(`'it\'s'`, false); err != nil || got != `"it's"` {
+		t.Errorf("JSONRepair('it\\'s') = %s, %v, want \"it's\"", got, err)
+	}
+}
+
+func TestValidateStrict(t *testing.T) {
+	valid := []string{
+		`{"a": [1, -0.5, 2e10, 3E-2, true, false, null], "b": {"c": "é\n"}}`,
+		" \t\n[]\r\n",
+		`"text"`,
+		`0`,
+		`{}`,
+	}
+	for _, input := range valid {
+		if err := ValidateStrict(input); err != nil {
+			t.Errorf("ValidateStrict(%q) = %v, want nil", input, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		sentinel error
+		position int
+	}{
+		{"missing colon", `{"a" 1}`, ErrColonExpected, 5},
+		{"unquoted key", `{a: 1}`, ErrObjectKeyExpected, 1},
+		{"single quotes", `{'a': 1}`, ErrObjectKeyExpected, 1},
+		{"trailing comma in object", `{"a": 1,}`, ErrObjectKeyExpected, 8},
+		{"trailing comma in array", `[1, 2,]`, ErrUnexpectedCharacter, 6},
+		{"missing comma", `[1 2]`, ErrUnexpectedCharacter, 3},
+		{"unclosed array", `[1, 2`, ErrUnexpectedEnd, 5},
+		{"unclosed string", `"abc`, ErrUnexpectedEnd, 4},
+		{"empty input", ``, ErrUnexpectedEnd, 0},
+		{"leading zero", `[01]`, ErrUnexpectedCharacter, 2},
+		{"leading plus", `+1`, ErrUnexpectedCharacter, 0},
+		{"bare fraction", `[1.]`, ErrInvalidCharacter, 3},
+		{"bare exponent", `1e`, ErrUnexpectedEnd, 2},
+		{"control character", "[\"a\tb\"]", ErrInvalidCharacter, 3},
+		{"invalid escape", `["\x"]`, ErrInvalidCharacter, 3},
+		{"short unicode escape", `["\u12g4"]`, ErrInvalidUnicode, 2},
+		{"comment", `[1] // note`, ErrUnexpectedCharacter, 4},
+		{"python constant", `[None]`, ErrUnexpectedCharacter, 1},
+		{"second document", `{} {}`, ErrUnexpectedCharacter, 3},
+		{"positions count runes", `{"é": 1 2}`, ErrUnexpectedCharacter, 8},
+		{"invalid UTF-8", "[\"\xff\"]", ErrInvalidUTF8, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStrict(tt.input)
+			var strictErr *Error
+			if !errors.Is(err, tt.sentinel) || !errors.As(err, &strictErr) || strictErr.Position != tt.position {
+				t.Errorf("ValidateStrict(%q) = %v, want %v at %d", tt.input, err, tt.sentinel, tt.position)
+			}
+		})
+	}
+}
+
+func TestRepairDoubledCommas(t *testing.T) {
+	// Object members have no position, so extra commas collapse; array elisions become null
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"object doubled comma", `{"a":1,,"b":2}`, `{"a":1,"b":2}`},
+		{"object tripled comma", `{"a":1,,,"b":2}`, `{"a":1,"b":2}`},
+		{"object leading comma", `{,"a":1}`, `{"a":1}`},
+		{"object doubled trailing comma", `{"a":1,,}`, `{"a":1}`},
+		{"array doubled comma", `[1,,2]`, `[1,null,2]`},
+		{"array tripled comma", `[1,,,2]`, `[1,null,null,2]`},
+		{"array leading comma", `[,1]`, `[null,1]`},
+		{"array doubled leading comma", `[,,2]`, `[null,null,2]`},
+		{"array doubled trailing comma", `[1,,]`, `[1,null]`},
+		{"array single trailing comma", `[1,]`, `[1]`},
+		{"comment between commas", `[1, /*x*/ ,2]`, `[1,  null,2]`},
+		{"nested", `{"a": [1,,2],, "b": {"c": 1,, "d": 2}}`, `{"a": [1,null,2], "b": {"c": 1, "d": 2}}`},
+	})
+
+	_, actions, err := JSONRepairWithReport(`{"a":1,,"b":2}`, false)
+	if err != nil || len(actions) != 1 || actions[0].Kind != "removed-comma" || actions[0].Position != 7 {
+		t.Errorf("JSONRepairWithReport doubled object comma actions = %+v, %v, want one removed-comma at 7", actions, err)
+	}
+}
+
+func TestRepairConcatenated(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"objects", `{"a":1}{"b":2}`, `[{"a":1},{"b":2}]`},
+		{"arrays", `[1][2, 3]`, `[[1],[2, 3]]`},
+		{"mixed scalars", `1 "x" true null`, `[1,"x",true,null]`},
+		{"adjacent strings", `"a""b"`, `["a","b"]`},
+		{"single value", `{"a":1}`, `[{"a":1}]`},
+		{"comments between values", "{\"a\":1} /* c */ // d\n{\"b\":2}", `[{"a":1},{"b":2}]`},
+		{"malformed later values", `{"a":1}{b: 2,}{"c": [1`, `[{"a":1},{"b": 2},{"c": [1]}]`},
+		{"unclosed last object", `{"a":1}{"b":2`, `[{"a":1},{"b":2}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepairConcatenated(tt.input)
+			if err != nil {
+				t.Fatalf("RepairConcatenated(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("RepairConcatenated(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := RepairConcatenated("  "); !errors.Is(err, ErrUnexpectedEnd) {
+		t.Errorf("RepairConcatenated of blank input error = %v, want ErrUnexpectedEnd", err)
+	}
+	if _, err := RepairConcatenated(`{"a":1}]`); !errors.Is(err, ErrUnexpectedCharacter) {
+		t.Errorf("RepairConcatenated with a stray bracket error = %v, want ErrUnexpectedCharacter", err)
+	}
+}
+
+func TestRepairMaxDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxDepth int
+		position int
+	}{
+		{"100k opening brackets", strings.Repeat("[", 100000), 0, DefaultMaxDepth},
+		{"nested objects", strings.Repeat(`{"a":`, 1000), 100, 500},
+		{"custom limit", `[[[1]]]`, 3, 3},
+		{"custom limit in an object", `{"a": {"b": [1]}}`, 3, 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONRepairWithOptions(tt.input, RepairOptions{MaxDepth: tt.maxDepth})
+			var repairErr *Error
+			if !errors.Is(err, ErrMaxDepthExceeded) || !errors.As(err, &repairErr) || repairErr.Position != tt.position {
+				t.Errorf("JSONRepairWithOptions error = %v, want ErrMaxDepthExceeded at %d", err, tt.position)
+			}
+		})
+	}
+
+	// Values up to the limit are still repaired
+	deep := strings.Repeat("[", DefaultMaxDepth-1) + "1"
+	if got, err := JSONRepair(deep, false); err != nil || got != deep+strings.Repeat("]", DefaultMaxDepth-1) {
+		t.Errorf("JSONRepair at the depth limit failed: %v", err)
+	}
+	if got, err := JSONRepairWithOptions(`[[1]]`, RepairOptions{MaxDepth: 3}); err != nil || got != `[[1]]` {
+		t.Errorf("JSONRepairWithOptions([[1]], MaxDepth 3) = %s, %v", got, err)
+	}
+
+	resp := NewApp().ProcessJSON(strings.Repeat("[", 100000), "2", false, true)
+	if resp.Success || !strings.Contains(resp.Error, ErrMaxDepthExceeded.Error()) {
+		t.Errorf("ProcessJSON of 100k opening brackets = %+v, want a depth error", resp)
+	}
+}
+
+func TestRepairDoubledQuotes(t *testing.T) {
+	runRepairCases(t, RepairOptions{DoubledQuotes: true}, []repairCase{
+		{"key and value", `{""name"": ""x""}`, `{"name": "x"}`},
+		{"compact key", `{""k"":1}`, `{"k":1}`},
+		{"array values", `[""a b"", ""c""]`, `["a b", "c"]`},
+		{"root value", `""x""`, `"x"`},
+		{"space before closing brace", `{"a": ""x"" }`, `{"a": "x" }`},
+		{"escaped quote inside", `{"a": ""it\"s""}`, `{"a": "it\"s"}`},
+		{"unclosed object", `{"a": ""x""`, `{"a": "x"}`},
+		{"empty values stay empty", `{"a": "", "b": ""}`, `{"a": "", "b": ""}`},
+		{"adjacent empty strings", `["",""]`, `["",""]`},
+	})
+
+	// Without the option the first "" is taken for an empty string
+	if got, err := JSONRepairWithOptions(`""x""`, RepairOptions{}); err != nil || got != `""` {
+		t.Errorf(`JSONRepairWithOptions(""x"") without DoubledQuotes = %s, %v, want ""`, got, err)
+	}
+}
+
+func TestRepairFoldTrailingPairs(t *testing.T) {
+	runRepairCases(t, RepairOptions{FoldTrailingPairs: true}, []repairCase{
+		{"one pair", `{"a":1} "b":2`, `{"a":1,"b":2}`},
+		{"several pairs with commas", `{"a":1}, "b":2, c: 3`, `{"a":1,"b":2, "c": 3}`},
+		{"empty root object", `{} "b":2`, `{"b":2}`},
+		{"pairs on new lines", "{\"a\":1}\n\"b\":2\n", `{"a":1,"b":2}`},
+		{"trailing array dropped", `{"a":1} [2]`, `{"a":1}`},
+		{"trailing number dropped", `{"a":1} 2`, `{"a":1}`},
+		{"trailing string dropped", `{"a":1} "b"`, `{"a":1}`},
+		{"comment before a dropped value", `{"a":1} /* c */ [2]`, `{"a":1}`},
+		{"trailing whitespace only", "{\"a\":1}\n", "{\"a\":1}\n"},
+		{"root array", `[1] "b":2`, `[1] `},
+	})
+
+	// Without the option the pairs are ignored
+	if got, err := JSONRepairWithOptions(`{"a":1} "b":2`, RepairOptions{}); err != nil || got != `{"a":1} ` {
+		t.Errorf("JSONRepairWithOptions without FoldTrailingPairs = %q, %v", got, err)
+	}
+}
+
+func TestRepairAllowEmpty(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  RepairOptions
+	}{
+		{"empty", "", RepairOptions{}},
+		{"whitespace only", "  \n\t", RepairOptions{}},
+		{"block comment only", "/* x */", RepairOptions{}},
+		{"line comment only", "// note\n", RepairOptions{}},
+		{"mixed comments", " /* a */ // b", RepairOptions{}},
+		{"unclosed comment", "/* unclosed", RepairOptions{}},
+		{"hash comment only", "# note\n", RepairOptions{HashComments: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if _, err := JSONRepairWithOptions(tt.input, opts); !errors.Is(err, ErrUnexpectedEnd) {
+				t.Errorf("JSONRepairWithOptions(%q) error = %v, want ErrUnexpectedEnd", tt.input, err)
+			}
+			opts.AllowEmpty = true
+			if got, err := JSONRepairWithOptions(tt.input, opts); err != nil || got != "" {
+				t.Errorf("JSONRepairWithOptions(%q) with AllowEmpty = %q, %v, want an empty result", tt.input, got, err)
+			}
+			if !tt.opts.HashComments {
+				if resp := NewApp().ProcessJSON(tt.input, "2", false, true); !resp.Success || resp.Data != "" || resp.Repaired {
+					t.Errorf("ProcessJSON(%q) = %+v, want an empty success", tt.input, resp)
+				}
+			}
+		})
+	}
+
+	// Content after a comment is still repaired
+	if got, err := JSONRepairWithOptions("/* x */ [1,", RepairOptions{AllowEmpty: true}); err != nil || got != " [1]" {
+		t.Errorf("JSONRepairWithOptions with a leading comment = %q, %v", got, err)
+	}
+}
+
+func TestRepairInvisibleMarks(t *testing.T) {
+	runRepairCases(t, RepairOptions{InvisibleMarks: true}, []repairCase{
+		{"BOM between entries", "{\"a\": 1,\uFEFF \"b\": 2}", `{"a": 1, "b": 2}`},
+		{"direction marks around a key and value", "{\u200E\"a\"\u200F: \u202A1\u202C, \"b\": 2}", `{"a": 1, "b": 2}`},
+		{"marks after array elements", "[1\uFEFF, 2\u2066]", `[1, 2]`},
+		{"mark ending an unquoted value", "{\"k\": abc\u200E}", `{"k": "abc"}`},
+		{"leading direction mark", "\u200E[1]", `[1]`},
+		{"word joiner before a value", "{\"a\":\u2060true}", `{"a":true}`},
+		{"marks inside strings kept", "{\"a\": \"x\u200Ey\uFEFF\"}", "{\"a\": \"x\u200Ey\uFEFF\"}"},
+	})
+
+	// Without the option a direction mark is taken for content
+	if got, err := JSONRepairWithOptions("[1, 2\u2066]", RepairOptions{}); err != nil || got != "[1, \"2\u2066\"]" {
+		t.Errorf("JSONRepairWithOptions without InvisibleMarks = %q, %v", got, err)
+	}
+}
+
+func TestRepairRegexAndDivision(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"division", `{"ratio": 3/4}`, `{"ratio": "3/4"}`},
+		{"chained division", `{"r": 10/2/5, "b": 1}`, `{"r": "10/2/5", "b": 1}`},
+		{"spaced division", `{"r": 3 / 4}`, `{"r": "3 / 4"}`},
+		{"division by a name", `{"r": 3 / x}`, `{"r": "3 / x"}`},
+		{"division in an array", `[1/2]`, `["1/2"]`},
+		{"regex with flags", `{"re": /ab+c/i}`, `{"re": "/ab+c/i"}`},
+		{"slash in a character class", `{"re": /[a/]/g, "n": 1}`, `{"re": "/[a/]/g", "n": 1}`},
+		{"escaped slash", `[/a\/b/]`, `["/a\\/b/"]`},
+		{"quote in a regex", `[/x"y/]`, `["/x\"y/"]`},
+		{"path", `{"p": /usr/local/bin}`, `{"p": "/usr/local/bin"}`},
+		{"path before a key", `{"p": /usr/local/bin, "q": 1}`, `{"p": "/usr/local/bin", "q": 1}`},
+		{"line comment after a number", "{\"a\": 3 // c\n}", "{\"a\": 3 \n}"},
+		{"block comment after a number", `{"a": 3/* c */}`, `{"a": 3}`},
+	})
+}
+
+func TestRepairPreserveSpecialWhitespace(t *testing.T) {
+	cases := []struct {
+		name, input, trimmed, kept string
+	}{
+		{"inside strings", "{\"a\": \"x\u3000\", \"b\": \"\u00A0y\u00A0\"}",
+			"{\"a\": \"x\u3000\", \"b\": \"\u00A0y\u00A0\"}", "{\"a\": \"x\u3000\", \"b\": \"\u00A0y\u00A0\"}"},
+		{"ending unquoted values", "{a: hello\u3000, b: world\u00A0}",
+			`{"a": "hello", "b": "world"}`, "{\"a\": \"hello\u3000\", \"b\": \"world\u00A0\"}"},
+		{"both ending an array element", "[foo\u3000\u00A0]", `["foo"]`, "[\"foo\u3000\u00A0\"]"},
+		{"between tokens", "{a: 1,\u3000b:\u00A02}", `{"a": 1, "b": 2}`, `{"a": 1, "b": 2}`},
+		{"before an unquoted value", "{a:\u00A0val}", `{"a": "val"}`, `{"a": "val"}`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, opts := range []struct {
+				keep bool
+				want string
+			}{{false, tt.trimmed}, {true, tt.kept}} {
+				got, err := JSONRepairWithOptions(tt.input, RepairOptions{PreserveSpecialWhitespace: opts.keep})
+				if err != nil || got != opts.want {
+					t.Errorf("JSONRepairWithOptions(%q, PreserveSpecialWhitespace=%v) = %q, %v, want %q", tt.input, opts.keep, got, err, opts.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRepairNormalizeStringNewlines(t *testing.T) {
+	runRepairCases(t, RepairOptions{NormalizeStringNewlines: true}, []repairCase{
+		{"raw CRLF", "{\"a\": \"x\r\ny\"}", `{"a": "x\ny"}`},
+		{"raw lone CR", "{\"a\": \"x\ry\"}", `{"a": "x\ny"}`},
+		{"escaped CRLF", `{"a": "x\r\ny"}`, `{"a": "x\ny"}`},
+		{"escaped lone CRs", `{"a": "x\ry\r"}`, `{"a": "x\ny\n"}`},
+		{"unicode escapes", `{"a": "x\u000d\u000Ay"}`, `{"a": "x\ny"}`},
+		{"single quoted", "{a: 'x\r\ny'}", `{"a": "x\ny"}`},
+		{"unterminated string", "{\"a\": \"x\r\ny", `{"a": "x\ny"}`},
+		{"escaped backslashes kept", `{"a": "x\\r\\ny"}`, `{"a": "x\\r\\ny"}`},
+		{"structural CRLF kept", "{\r\n\"a\": \"x\",\r\n\"b\": 1\r\n}", "{\r\n\"a\": \"x\",\r\n\"b\": 1\r\n}"},
+	})
+
+	// Without the option a CRLF is only escaped
+	if got, err := JSONRepairWithOptions("{\"a\": \"x\r\ny\"}", RepairOptions{}); err != nil || got != `{"a": "x\r\ny"}` {
+		t.Errorf("JSONRepairWithOptions without NormalizeStringNewlines = %q, %v", got, err)
+	}
+}
+
+func TestRepairBOMAndZeroWidth(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"BOM-prefixed object", "\uFEFF{\"a\": 1}", `{"a": 1}`},
+		{"zero-width space before a colon", "{\"key\"\u200B: 1}", `{"key": 1}`},
+		{"zero-width space after an unquoted key", "{key\u200B: 1}", `{"key": 1}`},
+		{"zero-width spaces around an element", "[1,\u200B2\u200B]", `[1,2]`},
+		{"BOM and zero-width space before the root", "\uFEFF\u200B[1]", `[1]`},
+		{"kept inside strings", "{\"a\": \"x\u200By\uFEFF\"}", "{\"a\": \"x\u200By\uFEFF\"}"},
+		{"joiner inside an unquoted value kept", "{\"e\": a\u200Db}", "{\"e\": \"a\u200Db\"}"},
+	})
+
+	// A BOM alone makes otherwise valid input a repair
+	if resp := NewApp().ProcessJSONOpts("\uFEFF{\"a\": 1}", FormatOptions{KeepOrder: true}); !resp.Success || !resp.Repaired {
+		t.Errorf("ProcessJSONOpts with a BOM = %+v, want a repaired success", resp)
+	}
+}
+
+func TestRepairKeysOnly(t *testing.T) {
+	runRepairCases(t, RepairOptions{KeysOnly: true}, []repairCase{
+		{"unquoted values kept", `{name: hello, age: 30}`, `{"name": hello, "age": 30}`},
+		{"undefined kept", `{a: undefined, b: null}`, `{"a": undefined, "b": null}`},
+		{"nested", `{a: [x, y], b: {c: z}}`, `{"a": [x, y], "b": {"c": z}}`},
+		{"multi-word value", `{a: hello world, b: 1}`, `{"a": hello world, "b": 1}`},
+		{"single quotes still replaced", `{'a': 'v', "b": "w"}`, `{"a": "v", "b": "w"}`},
+		{"other repairs still made", "{a: x, // c\nb: y,}", "{\"a\": x, \n\"b\": y}"},
+		{"array of unquoted values", `[foo, bar]`, `[foo, bar]`},
+	})
+
+	// Duplicate keys are resolved by decoding the output, which KeysOnly leaves invalid
+	opts := RepairOptions{KeysOnly: true, DuplicateKeys: "keep-last"}
+	if got, err := JSONRepairWithOptions(`{a: x, a: y}`, opts); err == nil || !strings.Contains(err.Error(), "KeysOnly") {
+		t.Errorf("JSONRepairWithOptions with KeysOnly and DuplicateKeys = %q, %v, want an error", got, err)
+	}
+	if err := JSONRepairArrayTo(io.Discard, `[{a: x, a: y}]`, opts); err == nil || !strings.Contains(err.Error(), "KeysOnly") {
+		t.Errorf("JSONRepairArrayTo with KeysOnly and DuplicateKeys = %v, want an error", err)
+	}
+}
+
+func TestRepairUnquotedPhrases(t *testing.T) {
+	runRepairCases(t, RepairOptions{}, []repairCase{
+		{"followed by a key after a comma", `{title: The Great Gatsby, author: F. Scott Fitzgerald}`,
+			`{"title": "The Great Gatsby", "author": "F. Scott Fitzgerald"}`},
+		{"followed by a key on the next line", "{title: The Great Gatsby\nauthor: Scott}", "{\"title\": \"The Great Gatsby\",\n\"author\": \"Scott\"}"},
+		{"followed by a key on the same line", `{title: The Great Gatsby year: 1925}`, `{"title": "The Great Gatsby", "year": 1925}`},
+		{"followed by a quoted key", `{title: The Great Gatsby "year": 1925}`, `{"title": "The Great Gatsby", "year": 1925}`},
+		{"before the closing brace", `{title: The Great Gatsby}`, `{"title": "The Great Gatsby"}`},
+		{"identifier characters", `{a: _private value, b: $ref thing}`, `{"a": "_private value", "b": "$ref thing"}`},
+		{"non-ASCII words", "{a: \xe4\xb8\xad \xe6\x96\x87, b: 1}", "{\"a\": \"\xe4\xb8\xad \xe6\x96\x87\", \"b\": 1}"},
+	})
+
+	// Only a value starting with an unquoted word is joined
+	for _, input := range []string{`{"a": 1 2}`, `{"d": 1 x}`, `{"a": -1 x}`, `{"a": true false}`, `{"a": null 1}`, `{"t": false positive}`} {
+		if got, err := JSONRepairWithOptions(input, RepairOptions{}); !errors.Is(err, ErrColonExpected) {
+			t.Errorf("JSONRepairWithOptions(%q) = %q, %v, want a colon expected error", input, got, err)
+		}
+	}
+}
+
+func TestRepairConcatenatedWithMode(t *testing.T) {
+	overlapping := `{"a": 1, "n": {"x": 1, "y": [1]}, "s": "p"} {"a": 2, "n": {"x": 1, "z": true}, "b": null} {a: 3, n: {x: 2}, "s": "p"}`
+	tests := []struct {
+		name  string
+		input string
+		mode  string
+		want  string
+	}{
+		{"default wraps", overlapping, "",
+			`[{"a": 1, "n": {"x": 1, "y": [1]}, "s": "p"},{"a": 2, "n": {"x": 1, "z": true}, "b": null},{"a": 3, "n": {"x": 2}, "s": "p"}]`},
+		{"wrapArray", overlapping, "wrapArray",
+			`[{"a": 1, "n": {"x": 1, "y": [1]}, "s": "p"},{"a": 2, "n": {"x": 1, "z": true}, "b": null},{"a": 3, "n": {"x": 2}, "s": "p"}]`},
+		{"mergeObjects", overlapping, "mergeObjects", `{"a":3,"n":{"x":2,"y":[1],"z":true},"s":"p","b":null}`},
+		{"mergeToArrays", overlapping, "mergeToArrays", `{"a":[1,2,3],"n":{"x":[1,2],"y":[1],"z":true},"s":"p","b":null}`},
+		{"mergeObjects replaces an object with a scalar", `{"a": {"b": 1}} {"a": 2}`, "mergeObjects", `{"a":2}`},
+		{"mergeToArrays collects an object and a scalar", `{"a": {"b": 1}} {"a": 2}`, "mergeToArrays", `{"a":[{"b":1},2]}`},
+		{"mergeToArrays keeps arrays as values", `{"a": [1, 2]} {"a": 3} {"a": 4}`, "mergeToArrays", `{"a":[[1,2],3,4]}`},
+		{"single object", `{"a": 1}`, "mergeToArrays", `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepairConcatenatedWithMode(tt.input, tt.mode)
+			if err != nil {
+				t.Fatalf("RepairConcatenatedWithMode(%q, %q) failed: %v", tt.input, tt.mode, err)
+			}
+			if got != tt.want {
+				t.Errorf("RepairConcatenatedWithMode(%q, %q) = %s, want %s", tt.input, tt.mode, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := RepairConcatenatedWithMode(`{"a": 1} [2]`, "mergeObjects"); err == nil {
+		t.Error("mergeObjects accepted an array")
+	}
+	if _, err := RepairConcatenatedWithMode(`{"a": 1}`, "bogus"); err == nil {
+		t.Error("RepairConcatenatedWithMode accepted an unknown mode")
+	}
+}
+
+func TestRepairRaw(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		trim         bool
+		want         string
+		wantRepaired bool
+	}{
+		{"valid input unchanged", "{\n  \"a\" :  1 }", false, "{\n  \"a\" :  1 }", false},
+		{"surrounding whitespace kept", " [1, 2] ", false, " [1, 2] ", false},
+		{"valid input not trimmed", `{"a": " x "}`, true, `{"a": " x "}`, false},
+		{"spacing kept while repairing", "{a: 1,\n  b: [1,2,]}", false, "{\"a\": 1,\n  \"b\": [1,2]}", true},
+		{"comment removed", "{\n\t'k': 'v'  // c\n}", false, "{\n\t\"k\": \"v\"  \n}", true},
+		{"truncated", `{"a": 1`, false, `{"a": 1}`, true},
+		{"keys trimmed while repairing", `{" a ": 1,}`, true, `{"a": 1}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repaired, err := RepairRaw(tt.input, tt.trim)
+			if err != nil {
+				t.Fatalf("RepairRaw(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want || repaired != tt.wantRepaired {
+				t.Errorf("RepairRaw(%q) = %q, %v, want %q, %v", tt.input, got, repaired, tt.want, tt.wantRepaired)
+			}
+		})
+	}
+
+	if got, repaired, err := RepairRaw("", false); err == nil || repaired {
+		t.Errorf("RepairRaw of empty input = %q, %v, %v, want an error", got, repaired, err)
+	}
+}
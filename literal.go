@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ConvertToGoLiteral converts JSON to a Go composite literal built from map[string]interface{}
+// and []interface{}, suitable for pasting into test fixtures. Key order is preserved.
+func (a *App) ConvertToGoLiteral(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	var builder strings.Builder
+	writeGoLiteral(&builder, gjson.Parse(validInput), 0)
+	return JSONResponse{Success: true, Data: builder.String()}
+}
+
+// writeGoLiteral writes res as a gofmt-style Go literal. Nested composite literals keep their
+// explicit type so the result also compiles when assigned to an interface{}. A repeated key
+// keeps its last value, like JSON.parse, since a map literal cannot repeat a key.
+func writeGoLiteral(builder *strings.Builder, res gjson.Result, depth int) {
+	indent := strings.Repeat("\t", depth+1)
+	switch {
+	case res.IsObject():
+		builder.WriteString("map[string]interface{}{")
+		members, keys := objectMembers(res)
+		for _, key := range keys {
+			builder.WriteString("\n" + indent)
+			builder.WriteString(strconv.Quote(key))
+			builder.WriteString(": ")
+			writeGoLiteral(builder, members[key], depth+1)
+			builder.WriteString(",")
+		}
+		if len(keys) > 0 {
+			builder.WriteString("\n" + strings.Repeat("\t", depth))
+		}
+		builder.WriteString("}")
+	case res.IsArray():
+		builder.WriteString("[]interface{}{")
+		empty := true
+		res.ForEach(func(_, value gjson.Result) bool {
+			empty = false
+			builder.WriteString("\n" + indent)
+			writeGoLiteral(builder, value, depth+1)
+			builder.WriteString(",")
+			return true
+		})
+		if !empty {
+			builder.WriteString("\n" + strings.Repeat("\t", depth))
+		}
+		builder.WriteString("}")
+	case res.Type == gjson.String:
+		builder.WriteString(strconv.Quote(res.String()))
+	case res.Type == gjson.True:
+		builder.WriteString("true")
+	case res.Type == gjson.False:
+		builder.WriteString("false")
+	case res.Type == gjson.Null:
+		builder.WriteString("nil")
+	default:
+		builder.WriteString(goNumberLiteral(res.Raw))
+	}
+}
+
+// goNumberLiteral returns the JSON number raw as a Go expression. JSON numbers are valid Go
+// numeric literals, and untyped constants in interface{} become int or float64 depending on
+// the presence of a fraction or exponent. An integer beyond int64 would overflow int, so it is
+// converted to float64 as encoding/json decodes it; a number beyond float64 is kept exactly as
+// a json.Number.
+func goNumberLiteral(raw string) string {
+	if _, err := strconv.ParseFloat(raw, 64); err != nil {
+		return "json.Number(" + strconv.Quote(raw) + ")"
+	}
+	if strings.ContainsAny(raw, ".eE") {
+		return raw
+	}
+	if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+		return "float64(" + raw + ")"
+	}
+	return raw
+}
+
+// ConvertToPythonLiteral converts JSON to a Python dict/list literal with True, False and None
+func (a *App) ConvertToPythonLiteral(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	var builder strings.Builder
+	writePythonLiteral(&builder, gjson.Parse(validInput), 0)
+	return JSONResponse{Success: true, Data: builder.String()}
+}
+
+// writePythonLiteral writes res as a Python literal indented with 4 spaces
+func writePythonLiteral(builder *strings.Builder, res gjson.Result, depth int) {
+	indent := strings.Repeat("    ", depth+1)
+	switch {
+	case res.IsObject() || res.IsArray():
+		openDelim, closeDelim := "[", "]"
+		if res.IsObject() {
+			openDelim, closeDelim = "{", "}"
+		}
+		builder.WriteString(openDelim)
+		empty := true
+		res.ForEach(func(key, value gjson.Result) bool {
+			empty = false
+			builder.WriteString("\n" + indent)
+			if res.IsObject() {
+				builder.WriteString(pythonStringLiteral(key.String()))
+				builder.WriteString(": ")
+			}
+			writePythonLiteral(builder, value, depth+1)
+			builder.WriteString(",")
+			return true
+		})
+		if !empty {
+			builder.WriteString("\n" + strings.Repeat("    ", depth))
+		}
+		builder.WriteString(closeDelim)
+	case res.Type == gjson.String:
+		builder.WriteString(pythonStringLiteral(res.String()))
+	case res.Type == gjson.True:
+		builder.WriteString("True")
+	case res.Type == gjson.False:
+		builder.WriteString("False")
+	case res.Type == gjson.Null:
+		builder.WriteString("None")
+	default:
+		builder.WriteString(res.Raw)
+	}
+}
+
+// pythonStringLiteral quotes s for Python. The JSON escapes produced by canonicalString
+// (\", \\, \n, \uXXXX, ...) are all valid Python string escapes.
+func pythonStringLiteral(s string) string {
+	return canonicalString(s)
+}
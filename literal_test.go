@@ -0,0 +1,102 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestConvertToGoLiteral(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"scalars", `{"s": "a\"b\n", "i": 1, "f": -1.5e3, "t": true, "n": null}`,
+			"map[string]interface{}{\n\t\"s\": \"a\\\"b\\n\",\n\t\"i\": 1,\n\t\"f\": -1.5e3,\n\t\"t\": true,\n\t\"n\": nil,\n}"},
+		{"nesting", `{"list": [1, {"k": []}], "empty": {}}`,
+			"map[string]interface{}{\n\t\"list\": []interface{}{\n\t\t1,\n\t\tmap[string]interface{}{\n\t\t\t\"k\": []interface{}{},\n\t\t},\n\t},\n\t\"empty\": map[string]interface{}{},\n}"},
+		{"top-level array", `["é", false]`, "[]interface{}{\n\t\"é\",\n\tfalse,\n}"},
+		{"top-level scalar", `"x"`, `"x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToGoLiteral(tt.input)
+			if !resp.Success {
+				t.Fatalf("ConvertToGoLiteral failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToGoLiteral = %s, want %s", resp.Data, tt.want)
+			}
+			if _, err := parser.ParseExpr(resp.Data); err != nil {
+				t.Errorf("ConvertToGoLiteral output is not a Go expression: %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertToGoLiteralCompiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"duplicate keys", `{"a": 1, "b": 2, "a": 3}`, "map[string]interface{}{\n\t\"a\": 3,\n\t\"b\": 2,\n}"},
+		{"integer beyond int64", `{"id": 18446744073709551616, "a":1, "a":2}`,
+			"map[string]interface{}{\n\t\"id\": float64(18446744073709551616),\n\t\"a\": 2,\n}"},
+		{"int64 bounds", `[9223372036854775807, -9223372036854775808, -9223372036854775809]`,
+			"[]interface{}{\n\t9223372036854775807,\n\t-9223372036854775808,\n\tfloat64(-9223372036854775809),\n}"},
+		{"number beyond float64", `[1e400, 1e-400]`, "[]interface{}{\n\tjson.Number(\"1e400\"),\n\t1e-400,\n}"},
+	}
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToGoLiteral(tt.input)
+			if !resp.Success {
+				t.Fatalf("ConvertToGoLiteral failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToGoLiteral = %s, want %s", resp.Data, tt.want)
+			}
+
+			// The literal must type-check, not just parse
+			src := "package fixture\n\nimport \"encoding/json\"\n\nvar _ json.Number\n\nvar v interface{} = " + resp.Data + "\n"
+			file, err := parser.ParseFile(fset, "fixture.go", src, 0)
+			if err != nil {
+				t.Fatalf("ConvertToGoLiteral output does not parse: %v", err)
+			}
+			if _, err := conf.Check("fixture", fset, []*ast.File{file}, nil); err != nil {
+				t.Errorf("ConvertToGoLiteral output does not compile: %v\n%s", err, resp.Data)
+			}
+		})
+	}
+}
+
+func TestConvertToPythonLiteral(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"scalars", `{"s": "a\"b\\\n", "t": true, "f": false, "n": null, "x": 2.5}`,
+			"{\n    \"s\": \"a\\\"b\\\\\\n\",\n    \"t\": True,\n    \"f\": False,\n    \"n\": None,\n    \"x\": 2.5,\n}"},
+		{"nesting", `{"a": [1, {"b": []}], "c": {}}`,
+			"{\n    \"a\": [\n        1,\n        {\n            \"b\": [],\n        },\n    ],\n    \"c\": {},\n}"},
+		{"control characters", `["\u0001\t"]`, "[\n    \"\\u0001\\t\",\n]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToPythonLiteral(tt.input)
+			if !resp.Success {
+				t.Fatalf("ConvertToPythonLiteral failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToPythonLiteral = %s, want %s", resp.Data, tt.want)
+			}
+		})
+	}
+}
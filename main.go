@@ -17,9 +17,11 @@ func main() {
 	// Check for existing instance
 	conn, err := net.Dial("tcp", "127.0.0.1:52109")
 	if err == nil {
-		// Existing instance found, send the file path if provided
+		// Existing instance found, send the file path or piped JSON if provided
 		if len(os.Args) > 1 {
-			conn.Write([]byte(os.Args[1]))
+			conn.Write(encodeInstanceMessage("path", os.Args[1]))
+		} else if content, ok := readPipedInput(pipedInputTimeout); ok {
+			conn.Write(encodeInstanceMessage("content", content))
 		}
 		conn.Close()
 		os.Exit(0)
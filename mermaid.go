@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxMermaidNodes caps the nodes of a Mermaid diagram, larger diagrams are unreadable and slow
+// to render
+const maxMermaidNodes = 200
+
+// ConvertToMermaid draws the structure of the JSON as a Mermaid flowchart: one node per value,
+// labelled with its key (or [index]) and type, and an edge from every object or array to its
+// members. Objects are drawn as rectangles, arrays as subroutines and scalars as rounded
+// nodes. After maxMermaidNodes nodes the rest is summarized in a single node.
+func (a *App) ConvertToMermaid(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	graph := &mermaidGraph{}
+	graph.node(gjson.Parse(validInput), "$", "")
+
+	var builder strings.Builder
+	builder.WriteString("graph TD\n")
+	for _, line := range graph.lines {
+		builder.WriteString("    " + line + "\n")
+	}
+	if graph.omitted > 0 {
+		builder.WriteString("    more" + mermaidLabel("... "+strconv.Itoa(graph.omitted)+" more nodes", "[", "]") + "\n")
+	}
+	return JSONResponse{Success: true, Data: builder.String(), Repaired: validInput != input}
+}
+
+// mermaidGraph collects the node and edge lines of a diagram
+type mermaidGraph struct {
+	count   int
+	omitted int
+	lines   []string
+}
+
+// node adds value as a node named label, linked from the node parent ("" for the root), and
+// then its members
+func (g *mermaidGraph) node(value gjson.Result, label string, parent string) {
+	if g.count >= maxMermaidNodes {
+		g.omitted += countJSONNodes(value)
+		return
+	}
+	id := "n" + strconv.Itoa(g.count)
+	g.count++
+
+	typ := jsonTypeName(value)
+	switch {
+	case value.IsObject():
+		g.lines = append(g.lines, id+mermaidLabel(label+": "+typ, "[", "]"))
+	case value.IsArray():
+		typ += "[" + strconv.Itoa(len(value.Array())) + "]"
+		g.lines = append(g.lines, id+mermaidLabel(label+": "+typ, "[[", "]]"))
+	default:
+		g.lines = append(g.lines, id+mermaidLabel(label+": "+typ, "(", ")"))
+	}
+	if parent != "" {
+		g.lines = append(g.lines, parent+" --> "+id)
+	}
+
+	if !value.IsObject() && !value.IsArray() {
+		return
+	}
+	idx := 0
+	value.ForEach(func(key, member gjson.Result) bool {
+		name := key.String()
+		if value.IsArray() {
+			name = "[" + strconv.Itoa(idx) + "]"
+		}
+		idx++
+		g.node(member, name, id)
+		return true
+	})
+}
+
+// countJSONNodes returns the number of values in res, including res itself
+func countJSONNodes(res gjson.Result) int {
+	count := 1
+	if res.IsObject() || res.IsArray() {
+		res.ForEach(func(_, member gjson.Result) bool {
+			count += countJSONNodes(member)
+			return true
+		})
+	}
+	return count
+}
+
+// mermaidLabel quotes text as a Mermaid node label between the shape delimiters open and close.
+// Double quotes become the #quot; entity and line breaks spaces.
+func mermaidLabel(text string, open string, close string) string {
+	text = strings.ReplaceAll(text, `"`, "#quot;")
+	text = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+	return open + `"` + text + `"` + close
+}
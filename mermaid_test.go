@@ -0,0 +1,77 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestConvertToMermaid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"nested document",
+			`{"user": {"name": "a", "tags": ["x", 1]}, "ok": true, "n": null}`,
+			"graph TD\n" +
+				"    n0[\"$: object\"]\n" +
+				"    n1[\"user: object\"]\n    n0 --> n1\n" +
+				"    n2(\"name: string\")\n    n1 --> n2\n" +
+				"    n3[[\"tags: array[2]\"]]\n    n1 --> n3\n" +
+				"    n4(\"[0]: string\")\n    n3 --> n4\n" +
+				"    n5(\"[1]: number\")\n    n3 --> n5\n" +
+				"    n6(\"ok: boolean\")\n    n0 --> n6\n" +
+				"    n7(\"n: null\")\n    n0 --> n7\n"},
+		{"quotes and line breaks in keys", `{"say \"hi\"": 1, "a\nb": 2}`,
+			"graph TD\n    n0[\"$: object\"]\n    n1(\"say #quot;hi#quot;: number\")\n    n0 --> n1\n    n2(\"a b: number\")\n    n0 --> n2\n"},
+		{"scalar root", `42`, "graph TD\n    n0(\"$: number\")\n"},
+		{"empty array", `[]`, "graph TD\n    n0[[\"$: array[0]\"]]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToMermaid(tt.input)
+			if !resp.Success {
+				t.Fatalf("ConvertToMermaid failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToMermaid = %q, want %q", resp.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertToMermaidSyntax(t *testing.T) {
+	nodeRe := regexp.MustCompile(`^(n\d+|more)(\["[^"]*"\]|\[\["[^"]*"\]\]|\("[^"]*"\))$`)
+	edgeRe := regexp.MustCompile(`^(n\d+) --> (n\d+)$`)
+
+	input := "{items: [" + strings.Repeat("{\"v\": 1}, ", 150) + "]}"
+	resp := NewApp().ConvertToMermaid(input)
+	if !resp.Success || !resp.Repaired {
+		t.Fatalf("ConvertToMermaid = %+v, want a repaired success", resp)
+	}
+	lines := strings.Split(strings.TrimSuffix(resp.Data, "\n"), "\n")
+	if lines[0] != "graph TD" {
+		t.Fatalf("first line = %q, want graph TD", lines[0])
+	}
+	nodes := map[string]bool{}
+	for _, line := range lines[1:] {
+		line = strings.TrimPrefix(line, "    ")
+		if m := nodeRe.FindStringSubmatch(line); m != nil {
+			nodes[m[1]] = true
+		} else if m := edgeRe.FindStringSubmatch(line); m != nil {
+			if !nodes[m[1]] || !nodes[m[2]] {
+				t.Errorf("edge %q links an undefined node", line)
+			}
+		} else {
+			t.Errorf("invalid line %q", line)
+		}
+	}
+	if len(nodes) != maxMermaidNodes+1 {
+		t.Errorf("got %d nodes, want %d and the summary", len(nodes), maxMermaidNodes)
+	}
+	// The root, the array, 150 objects and their 150 members are 302 values
+	if last := lines[len(lines)-1]; last != `    more["... 102 more nodes"]` {
+		t.Errorf("last line = %q, want the summary of 102 nodes", last)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxSchemaExamples limits the distinct example values collected per property
+const maxSchemaExamples = 3
+
+// InferSchemaWithExamples infers a JSON Schema (draft 2020-12) from a sample document for use
+// as API documentation. Every scalar property carries up to maxSchemaExamples distinct sample
+// values in "examples" and the first one as "default". Array elements are merged, so the
+// schema of an array of records lists every field seen in any record, and "required" holds
+// the fields present in all of them. Properties keep their document order.
+func (a *App) InferSchemaWithExamples(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	root := &schemaNode{}
+	root.observe(gjson.Parse(validInput))
+	resp := indentedResponse(`{"$schema":"https://json-schema.org/draft/2020-12/schema",` + strings.TrimPrefix(root.render(), "{"))
+	resp.Repaired = validInput != input
+	return resp
+}
+
+// schemaNode accumulates what has been observed at one place of the document
+type schemaNode struct {
+	types      []string
+	objects    int
+	keys       []string
+	properties map[string]*schemaNode
+	seen       map[string]int
+	items      *schemaNode
+	examples   []string
+}
+
+// observe merges value into the node
+func (n *schemaNode) observe(value gjson.Result) {
+	n.addType(schemaTypeName(value))
+	switch {
+	case value.IsObject():
+		n.objects++
+		if n.properties == nil {
+			n.properties = make(map[string]*schemaNode)
+			n.seen = make(map[string]int)
+		}
+		value.ForEach(func(key, member gjson.Result) bool {
+			name := key.String()
+			property, ok := n.properties[name]
+			if !ok {
+				property = &schemaNode{}
+				n.properties[name] = property
+				n.keys = append(n.keys, name)
+			}
+			n.seen[name]++
+			property.observe(member)
+			return true
+		})
+	case value.IsArray():
+		if n.items == nil {
+			n.items = &schemaNode{}
+		}
+		value.ForEach(func(_, element gjson.Result) bool {
+			n.items.observe(element)
+			return true
+		})
+	case value.Type != gjson.Null && len(n.examples) < maxSchemaExamples:
+		raw := compactRaw(value)
+		for _, example := range n.examples {
+			if example == raw {
+				return
+			}
+		}
+		n.examples = append(n.examples, raw)
+	}
+}
+
+// addType records a JSON Schema type, widening integer to number
+func (n *schemaNode) addType(name string) {
+	for idx, existing := range n.types {
+		switch {
+		case existing == name:
+			return
+		case existing == "integer" && name == "number":
+			n.types[idx] = "number"
+			return
+		case existing == "number" && name == "integer":
+			return
+		}
+	}
+	n.types = append(n.types, name)
+}
+
+// render returns the node as a compact JSON Schema object
+func (n *schemaNode) render() string {
+	var parts []string
+	switch len(n.types) {
+	case 0:
+	case 1:
+		parts = append(parts, `"type":`+canonicalString(n.types[0]))
+	default:
+		quoted := make([]string, len(n.types))
+		for idx, name := range n.types {
+			quoted[idx] = canonicalString(name)
+		}
+		parts = append(parts, `"type":[`+strings.Join(quoted, ",")+`]`)
+	}
+
+	if n.properties != nil {
+		properties := make([]string, len(n.keys))
+		var required []string
+		for idx, key := range n.keys {
+			properties[idx] = canonicalString(key) + ":" + n.properties[key].render()
+			if n.seen[key] == n.objects {
+				required = append(required, canonicalString(key))
+			}
+		}
+		parts = append(parts, `"properties":{`+strings.Join(properties, ",")+`}`)
+		if len(required) > 0 {
+			parts = append(parts, `"required":[`+strings.Join(required, ",")+`]`)
+		}
+	}
+	if n.items != nil && len(n.items.types) > 0 {
+		parts = append(parts, `"items":`+n.items.render())
+	}
+	if len(n.examples) > 0 {
+		parts = append(parts, `"examples":[`+strings.Join(n.examples, ",")+`]`)
+		parts = append(parts, `"default":`+n.examples[0])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// schemaTypeName returns the JSON Schema type of a value
+func schemaTypeName(value gjson.Result) string {
+	switch {
+	case value.IsObject():
+		return "object"
+	case value.IsArray():
+		return "array"
+	}
+	switch value.Type {
+	case gjson.String:
+		return "string"
+	case gjson.Number:
+		if strings.ContainsAny(value.Raw, ".eE") {
+			return "number"
+		}
+		return "integer"
+	case gjson.True, gjson.False:
+		return "boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestInferSchemaWithExamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		repaired bool
+	}{
+		{"array of records",
+			`{"users": [{"id": 1, "name": "a", "tags": ["x"]}, {"id": 2, "name": "b"}, {"id": 3, "name": "a", "score": 1.5}, {"id": 4, "name": null}]}`,
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"users":{"type":"array","items":{"type":"object","properties":{` +
+				`"id":{"type":"integer","examples":[1,2,3],"default":1},` +
+				`"name":{"type":["string","null"],"examples":["a","b"],"default":"a"},` +
+				`"tags":{"type":"array","items":{"type":"string","examples":["x"],"default":"x"}},` +
+				`"score":{"type":"number","examples":[1.5],"default":1.5}},` +
+				`"required":["id","name"]}}},"required":["users"]}`, false},
+		{"integer widened to number", `[{"n": 1}, {"n": 2.5}]`,
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"object","properties":{"n":{"type":"number","examples":[1,2.5],"default":1}},"required":["n"]}}`, false},
+		{"mixed scalars", `[1, "a", null]`,
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":["integer","string","null"],"examples":[1,"a"],"default":1}}`, false},
+		{"repaired input", `{a: 1}`,
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"a":{"type":"integer","examples":[1],"default":1}},"required":["a"]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().InferSchemaWithExamples(tt.input)
+			if !resp.Success {
+				t.Fatalf("InferSchemaWithExamples failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want || resp.Repaired != tt.repaired {
+				t.Errorf("InferSchemaWithExamples = %s (repaired %v), want %s (repaired %v)", got, resp.Repaired, tt.want, tt.repaired)
+			}
+		})
+	}
+}
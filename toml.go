@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// tomlBareKey matches keys that TOML allows without quotes
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ConvertToTOML converts JSON to TOML. Object keys become key/value pairs, nested objects
+// [table] sections with dotted headers and arrays of objects [[array-of-tables]] sections.
+// TOML has no null: the default null policy omits null values, the other policies behave as
+// for YAML. The root must be an object.
+func (a *App) ConvertToTOML(input string, trimWhitespace bool, keepOrder bool) JSONResponse {
+	return a.ConvertToTOMLOpts(input, ConvertOptions{TrimWhitespace: trimWhitespace, KeepOrder: keepOrder})
+}
+
+// ConvertToTOMLOpts is ConvertToTOML with its settings in a ConvertOptions
+func (a *App) ConvertToTOMLOpts(input string, opts ConvertOptions) JSONResponse {
+	resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
+	if !resp.Success {
+		return resp
+	}
+	if err := checkNullPolicy(opts.NullPolicy); err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	root := gjson.Parse(resp.Data)
+	if !root.IsObject() {
+		return JSONResponse{Success: false, Error: "TOML 的根必须是对象"}
+	}
+
+	var builder strings.Builder
+	if err := a.writeTOMLTable(&builder, root, "", "$", false, &opts); err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	return JSONResponse{Success: true, Data: strings.TrimPrefix(builder.String(), "\n")}
+}
+
+// writeTOMLTable writes the key/value pairs of table, then its sub-tables and arrays of
+// tables. header is the dotted header of table, empty for the root.
+func (a *App) writeTOMLTable(builder *strings.Builder, table gjson.Result, header string, path string, precise bool, opts *ConvertOptions) error {
+	type section struct {
+		key   string
+		value gjson.Result
+	}
+	var pairs strings.Builder
+	var sections []section
+	var err error
+	table.ForEach(func(key, value gjson.Result) bool {
+		if value.Type == gjson.Null && (opts.NullPolicy == "" || opts.NullPolicy == "skip") {
+			return true
+		}
+		if value.IsObject() || isTOMLTableArray(value, opts) {
+			sections = append(sections, section{key.String(), value})
+			return true
+		}
+		var literal string
+		literal, err = a.tomlValue(value, childJSONPath(path, key.String()), precise || isPreciseField(key.String(), opts.PreciseFields), opts)
+		if err != nil {
+			return false
+		}
+		pairs.WriteString(tomlKey(key.String()) + " = " + literal + "\n")
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// A table holding only sub-tables is declared implicitly by their headers
+	if header != "" && (pairs.Len() > 0 || len(sections) == 0) {
+		builder.WriteString("\n[" + header + "]\n")
+	}
+	builder.WriteString(pairs.String())
+
+	for _, sec := range sections {
+		childHeader := tomlKey(sec.key)
+		if header != "" {
+			childHeader = header + "." + childHeader
+		}
+		childPath := childJSONPath(path, sec.key)
+		childPrecise := precise || isPreciseField(sec.key, opts.PreciseFields)
+		if sec.value.IsObject() {
+			if err := a.writeTOMLTable(builder, sec.value, childHeader, childPath, childPrecise, opts); err != nil {
+				return err
+			}
+			continue
+		}
+		idx := 0
+		sec.value.ForEach(func(_, elem gjson.Result) bool {
+			elemPath := childPath + "[" + strconv.Itoa(idx) + "]"
+			idx++
+			if elem.Type == gjson.Null {
+				return true
+			}
+			var element strings.Builder
+			if err = a.writeTOMLTable(&element, elem, childHeader, elemPath, childPrecise, opts); err != nil {
+				return false
+			}
+			// Every element gets its own [[header]], even when it only holds sub-tables
+			builder.WriteString("\n[[" + childHeader + "]]\n")
+			builder.WriteString(strings.TrimPrefix(element.String(), "\n["+childHeader+"]\n"))
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTOMLTableArray reports whether value is a non-empty array of objects, written as an array
+// of tables. Null elements are allowed when the null policy drops them.
+func isTOMLTableArray(value gjson.Result, opts *ConvertOptions) bool {
+	if !value.IsArray() {
+		return false
+	}
+	objects := 0
+	tableArray := true
+	value.ForEach(func(_, elem gjson.Result) bool {
+		switch {
+		case elem.IsObject():
+			objects++
+		case elem.Type == gjson.Null && (opts.NullPolicy == "" || opts.NullPolicy == "skip"):
+		default:
+			tableArray = false
+		}
+		return tableArray
+	})
+	return tableArray && objects > 0
+}
+
+// tomlValue returns value as an inline TOML value: arrays and objects nested in arrays are
+// written inline, numbers keep their source token
+func (a *App) tomlValue(value gjson.Result, path string, precise bool, opts *ConvertOptions) (string, error) {
+	switch {
+	case value.IsObject():
+		var members []string
+		var err error
+		value.ForEach(func(key, member gjson.Result) bool {
+			if member.Type == gjson.Null && (opts.NullPolicy == "" || opts.NullPolicy == "skip") {
+				return true
+			}
+			var literal string
+			literal, err = a.tomlValue(member, childJSONPath(path, key.String()), precise || isPreciseField(key.String(), opts.PreciseFields), opts)
+			if err != nil {
+				return false
+			}
+			members = append(members, tomlKey(key.String())+" = "+literal)
+			return true
+		})
+		if len(members) == 0 {
+			return "{}", err
+		}
+		return "{ " + strings.Join(members, ", ") + " }", err
+	case value.IsArray():
+		var elements []string
+		var err error
+		idx := 0
+		value.ForEach(func(_, elem gjson.Result) bool {
+			elemPath := path + "[" + strconv.Itoa(idx) + "]"
+			idx++
+			if elem.Type == gjson.Null && (opts.NullPolicy == "" || opts.NullPolicy == "skip") {
+				return true
+			}
+			var literal string
+			literal, err = a.tomlValue(elem, elemPath, precise, opts)
+			if err != nil {
+				return false
+			}
+			elements = append(elements, literal)
+			return true
+		})
+		return "[" + strings.Join(elements, ", ") + "]", err
+	case value.Type == gjson.String:
+		if opts.TOMLDatetimes {
+			if _, err := time.Parse(time.RFC3339Nano, value.String()); err == nil {
+				return value.String(), nil
+			}
+		}
+		return tomlString(value.String()), nil
+	case value.Type == gjson.Number:
+		if precise {
+			return tomlString(value.Raw), nil
+		}
+		if !strings.ContainsAny(value.Raw, ".eE") {
+			if _, err := strconv.ParseInt(value.Raw, 10, 64); err != nil {
+				// TOML integers are 64-bit, keep larger ones exact as strings
+				return tomlString(value.Raw), nil
+			}
+		}
+		return value.Raw, nil
+	case value.Type == gjson.True || value.Type == gjson.False:
+		return value.Raw, nil
+	default:
+		if opts.NullPolicy == "error" {
+			return "", errors.New("无法导出 null 值: " + path)
+		}
+		return `""`, nil
+	}
+}
+
+// tomlKey returns key bare when TOML allows it, quoted otherwise (e.g. keys containing dots)
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\f':
+			builder.WriteString(`\f`)
+		case '\r':
+			builder.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				builder.WriteString(fmt.Sprintf(`\u%04X`, r))
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertToTOMLNullPolicy(t *testing.T) {
+	input := `{"name": "x", "note": null, "tags": ["a", null], "items": [{"id": 1, "v": null}]}`
+	tests := []struct {
+		policy  string
+		want    string
+		wantErr string
+	}{
+		{"", "name = \"x\"\ntags = [\"a\"]\n\n[[items]]\nid = 1\n", ""},
+		{"skip", "name = \"x\"\ntags = [\"a\"]\n\n[[items]]\nid = 1\n", ""},
+		{"empty", "name = \"x\"\nnote = \"\"\ntags = [\"a\", \"\"]\n\n[[items]]\nid = 1\nv = \"\"\n", ""},
+		{"error", "", "$.note"},
+		{"nope", "", "nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			resp := NewApp().ConvertToTOMLOpts(input, ConvertOptions{KeepOrder: true, NullPolicy: tt.policy})
+			if tt.wantErr != "" {
+				if resp.Success || !strings.Contains(resp.Error, tt.wantErr) {
+					t.Fatalf("ConvertToTOMLOpts(%q) = %+v, want an error about %s", tt.policy, resp, tt.wantErr)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("ConvertToTOMLOpts(%q) failed: %s", tt.policy, resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToTOMLOpts(%q) =\n%s\nwant\n%s", tt.policy, resp.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertToYAMLNullPolicy(t *testing.T) {
+	input := `{"a": null, "b": [1, null]}`
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{"", "a: null\nb:\n    - 1\n    - null\n"},
+		{"skip", "b:\n    - 1\n"},
+		{"empty", "a: \"\"\nb:\n    - 1\n    - \"\"\n"},
+	}
+	for _, tt := range tests {
+		for _, keepOrder := range []bool{false, true} {
+			resp := NewApp().ConvertToYAMLOpts(input, ConvertOptions{KeepOrder: keepOrder, NullPolicy: tt.policy})
+			if !resp.Success || resp.Data != tt.want {
+				t.Errorf("ConvertToYAMLOpts(%q, keepOrder %v) = %+v, want %q", tt.policy, keepOrder, resp, tt.want)
+			}
+		}
+	}
+	resp := NewApp().ConvertToYAMLOpts(input, ConvertOptions{NullPolicy: "error"})
+	if resp.Success || !strings.Contains(resp.Error, "$.a") {
+		t.Errorf("error policy: got %+v", resp)
+	}
+}
+
+func TestConvertToTOML(t *testing.T) {
+	input := `{"title": "x", "a.b": 1, "when": "2024-01-02T03:04:05Z", "f": 1.5, "big": 12345678901234567890, "ok": true, "owner": {"name": "n", "dob": "1979-05-27T07:32:00-08:00", "deep": {"x": [1, 2]}}, "products": [{"name": "h", "sku": 1}, {"name": "n"}], "mixed": [1, "a"], "s": "line\nbreak \"q\""}`
+	body := func(when, dob string) string {
+		return "title = \"x\"\n\"a.b\" = 1\nwhen = " + when + "\nf = 1.5\nbig = \"12345678901234567890\"\nok = true\nmixed = [1, \"a\"]\ns = \"line\\nbreak \\\"q\\\"\"\n" +
+			"\n[owner]\nname = \"n\"\ndob = " + dob + "\n" +
+			"\n[owner.deep]\nx = [1, 2]\n" +
+			"\n[[products]]\nname = \"h\"\nsku = 1\n" +
+			"\n[[products]]\nname = \"n\"\n"
+	}
+	tests := []struct {
+		name  string
+		input string
+		opts  ConvertOptions
+		want  string
+	}{
+		{"datetimes as strings", input, ConvertOptions{KeepOrder: true},
+			body(`"2024-01-02T03:04:05Z"`, `"1979-05-27T07:32:00-08:00"`)},
+		{"datetimes bare", input, ConvertOptions{KeepOrder: true, TOMLDatetimes: true},
+			body(`2024-01-02T03:04:05Z`, `1979-05-27T07:32:00-08:00`)},
+		{"dotted key in a table header", `{"a.b": {"c": {"d": 1}}}`, ConvertOptions{KeepOrder: true},
+			"[\"a.b\".c]\nd = 1\n"},
+		{"repaired input", `{a: {b: 1}`, ConvertOptions{KeepOrder: true}, "[a]\nb = 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToTOMLOpts(tt.input, tt.opts)
+			if !resp.Success {
+				t.Fatalf("ConvertToTOMLOpts failed: %s", resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("ConvertToTOMLOpts =\n%s\nwant\n%s", resp.Data, tt.want)
+			}
+		})
+	}
+
+	if resp := NewApp().ConvertToTOML(`[1]`, false, true); resp.Success {
+		t.Errorf("ConvertToTOML of an array = %+v, want an error", resp)
+	}
+}
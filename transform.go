@@ -0,0 +1,1088 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultWrapperKeys are the single-key wrapper names collapsed by CollapseSingleKeyWrappers by default
+var defaultWrapperKeys = []string{"value", "data", "node"}
+
+// CollapseSingleKeyWrappers replaces objects that have exactly one key from keyNames
+// with their inner value, recursively. Key order of all other objects is preserved.
+func (a *App) CollapseSingleKeyWrappers(input string, keyNames []string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	if len(keyNames) == 0 {
+		keyNames = defaultWrapperKeys
+	}
+	allowed := make(map[string]bool)
+	for _, k := range keyNames {
+		allowed[k] = true
+	}
+
+	return indentedResponse(collapseWrappers(gjson.Parse(validInput), allowed))
+}
+
+// collapseWrappers recursively rebuilds res as compact JSON with allowlisted wrappers removed
+func collapseWrappers(res gjson.Result, allowed map[string]bool) string {
+	if res.IsObject() {
+		var keys []gjson.Result
+		var values []gjson.Result
+		res.ForEach(func(key, value gjson.Result) bool {
+			keys = append(keys, key)
+			values = append(values, value)
+			return true
+		})
+		if len(keys) == 1 && allowed[keys[0].String()] {
+			return collapseWrappers(values[0], allowed)
+		}
+		var sb strings.Builder
+		sb.WriteString("{")
+		for idx := range keys {
+			if idx > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(keys[idx].Raw)
+			sb.WriteString(":")
+			sb.WriteString(collapseWrappers(values[idx], allowed))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	}
+	if res.IsArray() {
+		var sb strings.Builder
+		sb.WriteString("[")
+		first := true
+		res.ForEach(func(key, value gjson.Result) bool {
+			if !first {
+				sb.WriteString(",")
+			}
+			sb.WriteString(collapseWrappers(value, allowed))
+			first = false
+			return true
+		})
+		sb.WriteString("]")
+		return sb.String()
+	}
+	return res.Raw
+}
+
+// indentedResponse formats compact JSON with 4-space indentation into a JSONResponse
+func indentedResponse(compact string) JSONResponse {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(compact), "", "    "); err != nil {
+		return JSONResponse{Success: false, Error: "格式化错误: " + err.Error()}
+	}
+	return JSONResponse{Success: true, Data: buf.String()}
+}
+
+// ExpandDottedKeys expands object keys containing separator into nested objects,
+// e.g. {"server.port": 8080} -> {"server": {"port": 8080}}. When numericIndices is true,
+// objects whose keys are all numeric segments up to maxExpandedIndex become arrays, with null
+// for missing indices. A key that is both a leaf and a parent (e.g. "a" and "a.b") is
+// reported as a conflict. A separator escaped with a backslash stays part of the key, so
+// {"labels.app\\.kubernetes\\.io/name": "x"} becomes {"labels": {"app.kubernetes.io/name": "x"}};
+// a doubled backslash stands for a backslash.
+func (a *App) ExpandDottedKeys(input string, separator string, numericIndices bool) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	if separator == "" {
+		separator = "."
+	}
+
+	res := gjson.Parse(validInput)
+	root := newExpandNode()
+	var conflicts []string
+	if res.IsObject() {
+		root.insertObject(res, separator, "", &conflicts)
+	} else {
+		root.raw = expandValue(res, separator, &conflicts)
+		root.isLeaf = true
+	}
+	if len(conflicts) > 0 {
+		return JSONResponse{Success: false, Error: "键冲突（既是值又是父级）: " + strings.Join(conflicts, ", ")}
+	}
+
+	return indentedResponse(root.render(numericIndices))
+}
+
+// expandNode is an ordered tree used to rebuild objects with expanded keys
+type expandNode struct {
+	isLeaf   bool
+	array    bool
+	raw      string
+	keys     []string
+	children map[string]*expandNode
+}
+
+func newExpandNode() *expandNode {
+	return &expandNode{children: make(map[string]*expandNode)}
+}
+
+// insertObject inserts every key of obj below n, splitting keys on separator
+func (n *expandNode) insertObject(obj gjson.Result, separator string, prefix string, conflicts *[]string) {
+	obj.ForEach(func(key, value gjson.Result) bool {
+		node := n
+		path := prefix
+		segments := splitKeySegments(key.String(), separator)
+		for idx, segment := range segments {
+			if path != "" {
+				path += separator
+			}
+			path += segment
+			child, exists := node.children[segment]
+			last := idx == len(segments)-1
+			if exists && (child.isLeaf || (last && !value.IsObject())) {
+				*conflicts = append(*conflicts, path)
+				return true
+			}
+			if !exists {
+				child = newExpandNode()
+				node.children[segment] = child
+				node.keys = append(node.keys, segment)
+			}
+			node = child
+		}
+		if value.IsObject() {
+			node.insertObject(value, separator, path, conflicts)
+		} else {
+			node.isLeaf = true
+			node.raw = expandValue(value, separator, conflicts)
+		}
+		return true
+	})
+}
+
+// splitKeySegments splits key on separator, except where the separator is escaped with a
+// backslash, and unescapes the segments
+func splitKeySegments(key string, separator string) []string {
+	var segments []string
+	var segment strings.Builder
+	for idx := 0; idx < len(key); idx++ {
+		switch {
+		case key[idx] == '\\' && strings.HasPrefix(key[idx+1:], separator):
+			segment.WriteString(separator)
+			idx += len(separator)
+		case key[idx] == '\\' && strings.HasPrefix(key[idx+1:], "\\"):
+			segment.WriteByte('\\')
+			idx++
+		case strings.HasPrefix(key[idx:], separator):
+			segments = append(segments, segment.String())
+			segment.Reset()
+			idx += len(separator) - 1
+		default:
+			segment.WriteByte(key[idx])
+		}
+	}
+	return append(segments, segment.String())
+}
+
+// escapeKeySegment escapes backslashes and separator in a key, the inverse of splitKeySegments
+// for a single segment
+func escapeKeySegment(key string, separator string) string {
+	key = strings.ReplaceAll(key, "\\", "\\\\")
+	return strings.ReplaceAll(key, separator, "\\"+separator)
+}
+
+// FlattenDottedKeys is the inverse of ExpandDottedKeys: nested objects become keys joined with
+// separator, e.g. {"server": {"port": 8080}} -> {"server.port": 8080}. Separators and
+// backslashes inside keys are escaped with a backslash, so keys such as "user.name" survive
+// the round trip through ExpandDottedKeys. Arrays and empty objects are kept as values;
+// objects inside arrays are flattened on their own.
+func (a *App) FlattenDottedKeys(input string, separator string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	if separator == "" {
+		separator = "."
+	}
+	return indentedResponse(flattenValue(gjson.Parse(validInput), separator))
+}
+
+// flattenValue returns value as compact JSON with nested objects flattened
+func flattenValue(value gjson.Result, separator string) string {
+	switch {
+	case value.IsObject():
+		var members []string
+		flattenMembers(value, "", separator, &members)
+		return "{" + strings.Join(members, ",") + "}"
+	case value.IsArray():
+		var elems []string
+		value.ForEach(func(_, elem gjson.Result) bool {
+			elems = append(elems, flattenValue(elem, separator))
+			return true
+		})
+		return "[" + strings.Join(elems, ",") + "]"
+	default:
+		return value.Raw
+	}
+}
+
+// flattenMembers appends the leaves of obj to members, with keys prefixed by prefix
+func flattenMembers(obj gjson.Result, prefix string, separator string, members *[]string) {
+	obj.ForEach(func(key, value gjson.Result) bool {
+		name := prefix + escapeKeySegment(key.String(), separator)
+		if value.IsObject() && hasChildren(value) {
+			flattenMembers(value, name+separator, separator, members)
+		} else {
+			*members = append(*members, canonicalString(name)+":"+flattenValue(value, separator))
+		}
+		return true
+	})
+}
+
+// expandValue expands dotted keys in objects nested inside arrays
+func expandValue(value gjson.Result, separator string, conflicts *[]string) string {
+	if !value.IsArray() {
+		return value.Raw
+	}
+	var sb strings.Builder
+	sb.WriteString("[")
+	first := true
+	value.ForEach(func(_, elem gjson.Result) bool {
+		if !first {
+			sb.WriteString(",")
+		}
+		first = false
+		if elem.IsObject() {
+			node := newExpandNode()
+			node.insertObject(elem, separator, "", conflicts)
+			sb.WriteString(node.render(false))
+		} else {
+			sb.WriteString(expandValue(elem, separator, conflicts))
+		}
+		return true
+	})
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// render writes the node as compact JSON
+func (n *expandNode) render(numericIndices bool) string {
+	if n.isLeaf {
+		return n.raw
+	}
+	if n.array || numericIndices && len(n.keys) > 0 {
+		// Explicit [i] segments may index past maxExpandedIndex as long as the array is not
+		// mostly padding
+		limit := maxExpandedIndex
+		if n.array {
+			limit += len(n.keys)
+		}
+		if length, ok := arrayLength(n.keys, limit); ok {
+			elems := make([]string, length)
+			for idx := range elems {
+				elems[idx] = "null"
+			}
+			for _, key := range n.keys {
+				idx, _ := strconv.Atoi(key)
+				elems[idx] = n.children[key].render(numericIndices)
+			}
+			return "[" + strings.Join(elems, ",") + "]"
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("{")
+	for idx, key := range n.keys {
+		if idx > 0 {
+			sb.WriteString(",")
+		}
+		keyJSON, _ := json.Marshal(key)
+		sb.Write(keyJSON)
+		sb.WriteString(":")
+		sb.WriteString(n.children[key].render(numericIndices))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// maxExpandedIndex is the largest index that expands into an array element. Higher indices
+// would fill a huge array with nulls from a single key, so such keys stay object keys.
+const maxExpandedIndex = 9999
+
+// arrayLength reports whether all keys are non-negative integers no larger than limit and
+// returns the resulting array length
+func arrayLength(keys []string, limit int) (int, bool) {
+	length := 0
+	for _, key := range keys {
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx > limit || strconv.Itoa(idx) != key {
+			return 0, false
+		}
+		if idx+1 > length {
+			length = idx + 1
+		}
+	}
+	return length, true
+}
+
+// EscapeNonASCII returns the JSON with every non-ASCII character written as a \uXXXX escape,
+// so the output is safe for ASCII-only transports. Code points above U+FFFF are written
+// as a UTF-16 surrogate pair, e.g. U+1F600 -> \ud83d\ude00.
+func (a *App) EscapeNonASCII(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	return JSONResponse{Success: true, Data: escapeNonASCII(validInput), Repaired: validInput != input}
+}
+
+// escapeNonASCII escapes all runes >= 0x80. Outside of strings valid JSON is pure ASCII,
+// so the text can be processed without tracking string state.
+func escapeNonASCII(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			sb.WriteRune(r)
+		case r > 0xffff:
+			high, low := utf16.EncodeRune(r)
+			fmt.Fprintf(&sb, "\\u%04x\\u%04x", high, low)
+		default:
+			fmt.Fprintf(&sb, "\\u%04x", r)
+		}
+	}
+	return sb.String()
+}
+
+// FullyCanonicalize recursively sorts object keys and sorts arrays that contain only scalars,
+// so two documents that differ only in key order or scalar list order produce identical output.
+// Arrays containing objects or arrays keep their order since it is usually meaningful.
+// Scalars are ordered null < false < true < numbers < strings.
+func (a *App) FullyCanonicalize(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	return indentedResponse(canonicalize(gjson.Parse(validInput)))
+}
+
+// canonicalize rebuilds res as compact JSON in canonical order
+func canonicalize(res gjson.Result) string {
+	switch {
+	case res.IsObject():
+		var keys []string
+		values := make(map[string]gjson.Result)
+		res.ForEach(func(key, value gjson.Result) bool {
+			if _, exists := values[key.String()]; !exists {
+				keys = append(keys, key.String())
+			}
+			values[key.String()] = value
+			return true
+		})
+		sort.Strings(keys)
+		var sb strings.Builder
+		sb.WriteString("{")
+		for idx, key := range keys {
+			if idx > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(canonicalString(key))
+			sb.WriteString(":")
+			sb.WriteString(canonicalize(values[key]))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case res.IsArray():
+		elems := res.Array()
+		scalarOnly := true
+		for _, elem := range elems {
+			if elem.IsObject() || elem.IsArray() {
+				scalarOnly = false
+				break
+			}
+		}
+		if scalarOnly {
+			sort.SliceStable(elems, func(i, j int) bool {
+				return scalarLess(elems[i], elems[j])
+			})
+		}
+		parts := make([]string, len(elems))
+		for idx, elem := range elems {
+			parts[idx] = canonicalize(elem)
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	case res.Type == gjson.String:
+		return canonicalString(res.String())
+	default:
+		return res.Raw
+	}
+}
+
+// scalarRank orders scalar types for canonical array sorting
+func scalarRank(res gjson.Result) int {
+	switch res.Type {
+	case gjson.Null:
+		return 0
+	case gjson.False:
+		return 1
+	case gjson.True:
+		return 2
+	case gjson.Number:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func scalarLess(x, y gjson.Result) bool {
+	rx, ry := scalarRank(x), scalarRank(y)
+	if rx != ry {
+		return rx < ry
+	}
+	switch x.Type {
+	case gjson.Number:
+		if x.Float() != y.Float() {
+			return x.Float() < y.Float()
+		}
+		return x.Raw < y.Raw
+	case gjson.String:
+		return x.String() < y.String()
+	}
+	return false
+}
+
+// canonicalString encodes s as a JSON string without HTML escaping
+func canonicalString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// TransformKeys renames every object key to the given case style: "camel", "pascal", "snake"
+// or "kebab". When withMapping is true the result is {"data": ..., "mapping": {...}} where
+// mapping records the original name of every renamed key by its transformed JSONPath, so
+// RestoreKeys can undo conversions that are not reversible (e.g. HTTPStatus -> http_status).
+func (a *App) TransformKeys(input string, style string, withMapping bool) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	convert, ok := keyConverters[style]
+	if !ok {
+		return JSONResponse{Success: false, Error: "不支持的命名风格: " + style}
+	}
+
+	var mapping []string
+	result, err := renameKeys(gjson.Parse(validInput), "$", "$", func(_, outPath, key string) string {
+		newKey := convert(key)
+		if newKey != key {
+			mapping = append(mapping, canonicalString(childJSONPath(outPath, newKey))+":"+canonicalString(key))
+		}
+		return newKey
+	})
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	if !withMapping {
+		return indentedResponse(result)
+	}
+	return indentedResponse(`{"data":` + result + `,"mapping":{` + strings.Join(mapping, ",") + `}}`)
+}
+
+// RestoreKeys reverses TransformKeys using the mapping it produced. Keys without an entry
+// in the mapping are left unchanged.
+func (a *App) RestoreKeys(input string, mapping string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	mappingRes := gjson.Parse(mapping)
+	if !mappingRes.IsObject() {
+		return JSONResponse{Success: false, Error: "映射必须是 JSON 对象"}
+	}
+	originals := make(map[string]string)
+	mappingRes.ForEach(func(path, original gjson.Result) bool {
+		originals[path.String()] = original.String()
+		return true
+	})
+
+	// The mapping is keyed by paths in the transformed document, which is the input here
+	result, err := renameKeys(gjson.Parse(validInput), "$", "$", func(inPath, _, key string) string {
+		if original, ok := originals[childJSONPath(inPath, key)]; ok {
+			return original
+		}
+		return key
+	})
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	return indentedResponse(result)
+}
+
+// keyConverters maps TransformKeys styles to their conversion functions
+var keyConverters = map[string]func(string) string{
+	"camel": func(s string) string {
+		words := splitKeyWords(s)
+		for idx := range words {
+			if idx == 0 {
+				words[idx] = strings.ToLower(words[idx])
+			} else {
+				words[idx] = capitalizeWord(words[idx])
+			}
+		}
+		return strings.Join(words, "")
+	},
+	"pascal": func(s string) string {
+		words := splitKeyWords(s)
+		for idx := range words {
+			words[idx] = capitalizeWord(words[idx])
+		}
+		return strings.Join(words, "")
+	},
+	"snake": func(s string) string {
+		return strings.ToLower(strings.Join(splitKeyWords(s), "_"))
+	},
+	"kebab": func(s string) string {
+		return strings.ToLower(strings.Join(splitKeyWords(s), "-"))
+	},
+}
+
+// splitKeyWords splits a key into words on separators and case changes, keeping
+// acronyms together: "HTTPStatus" -> [HTTP Status], "user_id2" -> [user id2]
+func splitKeyWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for idx := 0; idx <= len(runes); idx++ {
+		if idx == len(runes) || runes[idx] == '_' || runes[idx] == '-' || runes[idx] == ' ' || runes[idx] == '.' {
+			if idx > start {
+				words = append(words, string(runes[start:idx]))
+			}
+			start = idx + 1
+			continue
+		}
+		if idx > start && unicode.IsUpper(runes[idx]) {
+			prev := runes[idx-1]
+			nextLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(runes[start:idx]))
+				start = idx
+			}
+		}
+	}
+	if len(words) == 0 {
+		return []string{s}
+	}
+	return words
+}
+
+// capitalizeWord upper-cases the first rune of word and lower-cases the rest
+func capitalizeWord(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// renameKeys rebuilds res as compact JSON with every key passed through rename, which receives
+// the JSONPath of the containing value both before (inPath) and after (outPath) renaming. Two
+// keys of one object renamed to the same name are an error.
+func renameKeys(res gjson.Result, inPath, outPath string, rename func(inPath, outPath, key string) string) (string, error) {
+	var sb strings.Builder
+	var err error
+	switch {
+	case res.IsObject():
+		seen := make(map[string]string)
+		sb.WriteString("{")
+		first := true
+		res.ForEach(func(key, value gjson.Result) bool {
+			newKey := rename(inPath, outPath, key.String())
+			if previous, exists := seen[newKey]; exists {
+				err = errors.New("键转换冲突: " + previous + " 和 " + key.String() + " 都转换为 " + newKey)
+				return false
+			}
+			seen[newKey] = key.String()
+			var child string
+			child, err = renameKeys(value, childJSONPath(inPath, key.String()), childJSONPath(outPath, newKey), rename)
+			if err != nil {
+				return false
+			}
+			if !first {
+				sb.WriteString(",")
+			}
+			first = false
+			sb.WriteString(canonicalString(newKey))
+			sb.WriteString(":")
+			sb.WriteString(child)
+			return true
+		})
+		sb.WriteString("}")
+	case res.IsArray():
+		sb.WriteString("[")
+		idx := 0
+		res.ForEach(func(_, value gjson.Result) bool {
+			var child string
+			index := "[" + strconv.Itoa(idx) + "]"
+			child, err = renameKeys(value, inPath+index, outPath+index, rename)
+			if err != nil {
+				return false
+			}
+			if idx > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(child)
+			idx++
+			return true
+		})
+		sb.WriteString("]")
+	default:
+		return res.Raw, nil
+	}
+	return sb.String(), err
+}
+
+// ApplyDefaults fills in fields that are present in template but missing from input, recursively.
+// Values in input always win, including explicit nulls. An object template, or a template array
+// holding a single object, is applied to every object element of the corresponding input array.
+func (a *App) ApplyDefaults(input string, template string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	validTemplate, err := a.validJSON(template)
+	if err != nil {
+		return JSONResponse{Success: false, Error: "模板无效: " + err.Error()}
+	}
+	return indentedResponse(mergeUnder(gjson.Parse(validTemplate), gjson.Parse(validInput)))
+}
+
+// mergeUnder deep-merges defaults under value, with value taking precedence, and returns compact JSON.
+// Key order follows value, followed by keys only present in defaults.
+func mergeUnder(defaults, value gjson.Result) string {
+	switch {
+	case value.IsObject() && defaults.IsObject():
+		present := make(map[string]bool)
+		var parts []string
+		value.ForEach(func(key, val gjson.Result) bool {
+			present[key.String()] = true
+			parts = append(parts, canonicalString(key.String())+":"+mergeUnder(defaults.Get(gjson.Escape(key.String())), val))
+			return true
+		})
+		defaults.ForEach(func(key, val gjson.Result) bool {
+			if !present[key.String()] {
+				parts = append(parts, canonicalString(key.String())+":"+compactRaw(val))
+			}
+			return true
+		})
+		return "{" + strings.Join(parts, ",") + "}"
+	case value.IsArray():
+		elementDefaults := defaults
+		if defaults.IsArray() {
+			elems := defaults.Array()
+			if len(elems) != 1 || !elems[0].IsObject() {
+				return compactRaw(value)
+			}
+			elementDefaults = elems[0]
+		}
+		if !elementDefaults.IsObject() {
+			return compactRaw(value)
+		}
+		var parts []string
+		value.ForEach(func(_, elem gjson.Result) bool {
+			if elem.IsObject() {
+				parts = append(parts, mergeUnder(elementDefaults, elem))
+			} else {
+				parts = append(parts, compactRaw(elem))
+			}
+			return true
+		})
+		return "[" + strings.Join(parts, ",") + "]"
+	default:
+		return compactRaw(value)
+	}
+}
+
+// compactRaw returns the raw JSON of res without insignificant whitespace
+func compactRaw(res gjson.Result) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(res.Raw)); err != nil {
+		return res.Raw
+	}
+	return buf.String()
+}
+
+// flatSegment is one step of a JSONPath: an object key or an array index
+type flatSegment struct {
+	key   string
+	index bool
+}
+
+// ToSortedFlatLines emits one "path = value" line per leaf, sorted by path with array
+// indices in numeric order, for version-control-friendly diffs. Paths use JSONPath
+// notation ($.a.b[0], $["odd key"]) and values are compact JSON; empty objects and
+// arrays are written as leaves. FromSortedFlatLines reverses the transformation.
+func (a *App) ToSortedFlatLines(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+
+	type flatLine struct {
+		segments []flatSegment
+		text     string
+	}
+	var lines []flatLine
+	var walk func(res gjson.Result, path string, segments []flatSegment)
+	walk = func(res gjson.Result, path string, segments []flatSegment) {
+		if hasChildren(res) {
+			idx := 0
+			res.ForEach(func(key, value gjson.Result) bool {
+				var segment flatSegment
+				var childPath string
+				if res.IsArray() {
+					segment = flatSegment{key: strconv.Itoa(idx), index: true}
+					childPath = path + "[" + segment.key + "]"
+				} else {
+					segment = flatSegment{key: key.String()}
+					childPath = childJSONPath(path, segment.key)
+				}
+				idx++
+				walk(value, childPath, append(append([]flatSegment{}, segments...), segment))
+				return true
+			})
+			return
+		}
+		lines = append(lines, flatLine{segments: segments, text: path + " = " + compactRaw(res)})
+	}
+	walk(gjson.Parse(validInput), "$", nil)
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lessSegments(lines[i].segments, lines[j].segments)
+	})
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(line.text)
+		sb.WriteString("\n")
+	}
+	return JSONResponse{Success: true, Data: sb.String(), Repaired: validInput != input}
+}
+
+// lessSegments orders paths segment by segment, comparing array indices numerically
+func lessSegments(x, y []flatSegment) bool {
+	for idx := 0; idx < len(x) && idx < len(y); idx++ {
+		if x[idx] == y[idx] {
+			continue
+		}
+		if x[idx].index && y[idx].index {
+			nx, _ := strconv.Atoi(x[idx].key)
+			ny, _ := strconv.Atoi(y[idx].key)
+			return nx < ny
+		}
+		if x[idx].index != y[idx].index {
+			return x[idx].index
+		}
+		return x[idx].key < y[idx].key
+	}
+	return len(x) < len(y)
+}
+
+// FromSortedFlatLines rebuilds a JSON document from the "path = value" lines produced by
+// ToSortedFlatLines. Blank lines are ignored; lines may appear in any order.
+func (a *App) FromSortedFlatLines(input string) JSONResponse {
+	root := newExpandNode()
+	hasRoot := false
+	for lineNo, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		segments, rest, err := parseFlatPath(line)
+		if err != nil {
+			return JSONResponse{Success: false, Error: fmt.Sprintf("第 %d 行: %s", lineNo+1, err.Error())}
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "=") || !gjson.Valid(strings.TrimSpace(rest[1:])) {
+			return JSONResponse{Success: false, Error: fmt.Sprintf("第 %d 行: 缺少有效的值", lineNo+1)}
+		}
+		value := strings.TrimSpace(rest[1:])
+
+		node := root
+		for _, segment := range segments {
+			if node.isLeaf {
+				return JSONResponse{Success: false, Error: fmt.Sprintf("第 %d 行: 路径冲突", lineNo+1)}
+			}
+			if len(node.keys) == 0 {
+				node.array = segment.index
+			} else if node.array != segment.index {
+				return JSONResponse{Success: false, Error: fmt.Sprintf("第 %d 行: 路径冲突", lineNo+1)}
+			}
+			child, exists := node.children[segment.key]
+			if !exists {
+				child = newExpandNode()
+				node.children[segment.key] = child
+				node.keys = append(node.keys, segment.key)
+			}
+			node = child
+		}
+		if node.isLeaf || len(node.keys) > 0 {
+			return JSONResponse{Success: false, Error: fmt.Sprintf("第 %d 行: 路径冲突", lineNo+1)}
+		}
+		node.isLeaf = true
+		node.raw = value
+		hasRoot = true
+	}
+	if !hasRoot {
+		return JSONResponse{Success: false, Error: "输入内容为空"}
+	}
+	if idx := root.sparseIndex(); idx != "" {
+		return JSONResponse{Success: false, Error: "无效的数组下标: " + idx}
+	}
+	return indentedResponse(root.render(false))
+}
+
+// sparseIndex returns the first array index under n that lies more than maxExpandedIndex
+// past the number of elements given for its array, or "" when there is none. Such an index
+// would fill a huge array with nulls from a few lines.
+func (n *expandNode) sparseIndex() string {
+	for _, key := range n.keys {
+		if idx, _ := strconv.Atoi(key); n.array && idx > len(n.keys)+maxExpandedIndex {
+			return key
+		}
+		if idx := n.children[key].sparseIndex(); idx != "" {
+			return idx
+		}
+	}
+	return ""
+}
+
+// parseFlatPath parses the JSONPath at the start of line and returns its segments and the rest of the line
+func parseFlatPath(line string) ([]flatSegment, string, error) {
+	if !strings.HasPrefix(line, "$") {
+		return nil, "", errors.New("路径必须以 $ 开头")
+	}
+	var segments []flatSegment
+	i := 1
+	for i < len(line) {
+		switch {
+		case line[i] == '.':
+			j := i + 1
+			for j < len(line) && line[j] != '.' && line[j] != '[' && line[j] != ' ' && line[j] != '=' {
+				j++
+			}
+			segments = append(segments, flatSegment{key: line[i+1 : j]})
+			i = j
+		case strings.HasPrefix(line[i:], `["`):
+			// Bracket-quoted key: decode the JSON string that follows
+			res := gjson.Parse(line[i+1:])
+			if res.Type != gjson.String || !strings.HasPrefix(line[i+1+len(res.Raw):], "]") {
+				return nil, "", errors.New("无效的路径: " + line)
+			}
+			segments = append(segments, flatSegment{key: res.String()})
+			i += 1 + len(res.Raw) + 1
+		case line[i] == '[':
+			j := strings.IndexByte(line[i:], ']')
+			if j < 0 {
+				return nil, "", errors.New("无效的路径: " + line)
+			}
+			if idx, err := strconv.Atoi(line[i+1 : i+j]); err != nil || idx < 0 {
+				return nil, "", errors.New("无效的数组下标: " + line[i+1:i+j])
+			}
+			segments = append(segments, flatSegment{key: line[i+1 : i+j], index: true})
+			i += j + 1
+		default:
+			return segments, line[i:], nil
+		}
+	}
+	return segments, "", nil
+}
+
+// defaultBreadcrumbSeparator joins the segments of a ToLabeledEntries breadcrumb
+const defaultBreadcrumbSeparator = " > "
+
+// ToLabeledEntries flattens the document into an array of {path, key, value, depth} entries,
+// one per leaf in document order, for search indexing and log display. path is a readable
+// breadcrumb such as "user > address > city" joined with separator (" > " when empty), key is
+// the leaf key (the index for array elements) and depth the number of segments. Values keep
+// their JSON type; empty objects and arrays are written as leaves.
+func (a *App) ToLabeledEntries(input string, separator string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	if separator == "" {
+		separator = defaultBreadcrumbSeparator
+	}
+
+	var entries []string
+	var walk func(res gjson.Result, segments []string)
+	walk = func(res gjson.Result, segments []string) {
+		if hasChildren(res) {
+			idx := 0
+			res.ForEach(func(key, value gjson.Result) bool {
+				segment := key.String()
+				if res.IsArray() {
+					segment = strconv.Itoa(idx)
+				}
+				idx++
+				walk(value, append(append([]string{}, segments...), segment))
+				return true
+			})
+			return
+		}
+		key := ""
+		if len(segments) > 0 {
+			key = segments[len(segments)-1]
+		}
+		entries = append(entries, fmt.Sprintf(`{"path":%s,"key":%s,"value":%s,"depth":%d}`,
+			canonicalString(strings.Join(segments, separator)), canonicalString(key), compactRaw(res), len(segments)))
+	}
+	walk(gjson.Parse(validInput), nil)
+
+	resp := indentedResponse("[" + strings.Join(entries, ",") + "]")
+	resp.Repaired = validInput != input
+	return resp
+}
+
+// maxIntegerizedDigits keeps IntegerizeWhereLossless from expanding exponents like 1e300
+const maxIntegerizedDigits = 64
+
+// IntegerizeWhereLossless rewrites numbers with a fraction or exponent that are exactly integral,
+// e.g. 5.0 -> 5 and 1.5e3 -> 1500, recursively. Genuine fractions are left unchanged. Numbers are
+// compared as exact decimals, so large values keep their precision.
+func (a *App) IntegerizeWhereLossless(input string) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	return indentedResponse(integerize(gjson.Parse(validInput)))
+}
+
+// integerize rebuilds res as compact JSON with lossless integer conversions applied
+func integerize(res gjson.Result) string {
+	switch {
+	case res.IsObject():
+		var parts []string
+		res.ForEach(func(key, value gjson.Result) bool {
+			parts = append(parts, key.Raw+":"+integerize(value))
+			return true
+		})
+		return "{" + strings.Join(parts, ",") + "}"
+	case res.IsArray():
+		var parts []string
+		res.ForEach(func(_, value gjson.Result) bool {
+			parts = append(parts, integerize(value))
+			return true
+		})
+		return "[" + strings.Join(parts, ",") + "]"
+	case res.Type == gjson.Number && strings.ContainsAny(res.Raw, ".eE"):
+		// Reject huge exponents before big.Rat expands them in memory
+		if idx := strings.IndexAny(res.Raw, "eE"); idx != -1 {
+			exp, err := strconv.Atoi(strings.TrimPrefix(res.Raw[idx+1:], "+"))
+			if err != nil || exp > maxIntegerizedDigits || exp < -maxIntegerizedDigits {
+				return res.Raw
+			}
+		}
+		rat, ok := new(big.Rat).SetString(res.Raw)
+		if !ok || !rat.IsInt() {
+			return res.Raw
+		}
+		if digits := rat.Num().String(); len(digits) <= maxIntegerizedDigits {
+			return digits
+		}
+		return res.Raw
+	default:
+		return res.Raw
+	}
+}
+
+// NormalizeToArrays wraps scalar or object values at the given paths in single-element arrays,
+// so fields that an API returns as a value when there is one item and as an array otherwise
+// can be handled uniformly. Nulls are left unchanged. With unwrapSingle the inverse is
+// applied: single-element arrays at the paths are replaced by their element. Paths use
+// JSONPath-like syntax where * or [*] matches every array element or object key, e.g.
+// $.items[*].tags.
+func (a *App) NormalizeToArrays(input string, paths []string, unwrapSingle bool) JSONResponse {
+	validInput, err := a.validJSON(input)
+	if err != nil {
+		return JSONResponse{Success: false, Error: err.Error()}
+	}
+	if len(paths) == 0 {
+		return JSONResponse{Success: false, Error: "请至少指定一个路径"}
+	}
+
+	patterns := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		var segments []string
+		if searchPath := toGJSONPath(strings.TrimSpace(path)); searchPath != "" {
+			segments = strings.Split(searchPath, ".")
+		}
+		patterns = append(patterns, segments)
+	}
+
+	return indentedResponse(normalizeArrays(gjson.Parse(validInput), nil, patterns, unwrapSingle))
+}
+
+// normalizeArrays rebuilds res as compact JSON, applying the array normalization to every
+// value whose concrete path matches one of the patterns
+func normalizeArrays(res gjson.Result, path []string, patterns [][]string, unwrapSingle bool) string {
+	var rendered string
+	switch {
+	case res.IsObject():
+		var parts []string
+		res.ForEach(func(key, value gjson.Result) bool {
+			parts = append(parts, key.Raw+":"+normalizeArrays(value, append(path, key.String()), patterns, unwrapSingle))
+			return true
+		})
+		rendered = "{" + strings.Join(parts, ",") + "}"
+	case res.IsArray():
+		var parts []string
+		index := 0
+		res.ForEach(func(_, value gjson.Result) bool {
+			parts = append(parts, normalizeArrays(value, append(path, strconv.Itoa(index)), patterns, unwrapSingle))
+			index++
+			return true
+		})
+		if unwrapSingle && len(parts) == 1 && matchesAnyPattern(path, patterns) {
+			return parts[0]
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	default:
+		rendered = res.Raw
+	}
+
+	if !unwrapSingle && res.Type != gjson.Null && matchesAnyPattern(path, patterns) {
+		return "[" + rendered + "]"
+	}
+	return rendered
+}
+
+// matchesAnyPattern reports whether path matches one of the patterns segment by segment
+func matchesAnyPattern(path []string, patterns [][]string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) != len(path) {
+			continue
+		}
+		matched := true
+		for idx, segment := range pattern {
+			if segment != "*" && segment != path[idx] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// compactJSON compacts s so formatted output can be compared regardless of indentation
+func compactJSON(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		t.Fatalf("invalid JSON %q: %v", s, err)
+	}
+	return buf.String()
+}
+
+func TestExpandDottedKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		numericIndices bool
+		want           string
+	}{
+		{"nested", `{"server.port": 8080, "server.host": "x", "debug": true}`, false, `{"server":{"port":8080,"host":"x"},"debug":true}`},
+		{"mixed depth", `{"a.b.c": 1, "a.d": 2, "e": {"f.g": 3}}`, false, `{"a":{"b":{"c":1},"d":2},"e":{"f":{"g":3}}}`},
+		{"numeric segments stay keys", `{"list.0": "a", "list.1": "b"}`, false, `{"list":{"0":"a","1":"b"}}`},
+		{"numeric indices", `{"list.0": "a", "list.1": "b"}`, true, `{"list":["a","b"]}`},
+		{"sparse indices", `{"list.2": "c", "list.0": "a"}`, true, `{"list":["a",null,"c"]}`},
+		{"index above the cap", `{"list.99999999": "x"}`, true, `{"list":{"99999999":"x"}}`},
+		{"escaped separator", `{"labels.app\\.kubernetes\\.io/name": "x"}`, false, `{"labels":{"app.kubernetes.io/name":"x"}}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.ExpandDottedKeys(tt.input, ".", tt.numericIndices)
+			if !resp.Success {
+				t.Fatalf("ExpandDottedKeys(%s) failed: %s", tt.input, resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("ExpandDottedKeys(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandDottedKeysConflict(t *testing.T) {
+	for _, input := range []string{
+		`{"a": 1, "a.b": 2}`,
+		`{"a.b": 2, "a": 1}`,
+	} {
+		resp := NewApp().ExpandDottedKeys(input, ".", false)
+		if resp.Success || !strings.HasSuffix(resp.Error, ": a") {
+			t.Errorf("ExpandDottedKeys(%s) = %+v, want a conflict on a", input, resp)
+		}
+	}
+}
+
+func TestFlattenDottedKeysRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		separator string
+		flat      string
+	}{
+		{"literal dots escaped", `{"user.name": "a", "server": {"port": 1, "host.name": "h"}}`, ".",
+			`{"user\\.name":"a","server.port":1,"server.host\\.name":"h"}`},
+		{"backslashes escaped", `{"a\\b": {"c.d": 1}}`, ".", `{"a\\\\b.c\\.d":1}`},
+		{"arrays and empty objects kept", `{"e": [{"f": {"g": 2}}], "h": {}}`, ".", `{"e":[{"f.g":2}],"h":{}}`},
+		{"dots need no escape with another separator", `{"user.name": "a", "server": {"host.name": "h"}}`, "/",
+			`{"user.name":"a","server/host.name":"h"}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flat := app.FlattenDottedKeys(tt.input, tt.separator)
+			if !flat.Success {
+				t.Fatalf("FlattenDottedKeys failed: %s", flat.Error)
+			}
+			if got := compactJSON(t, flat.Data); got != tt.flat {
+				t.Errorf("FlattenDottedKeys = %s, want %s", got, tt.flat)
+			}
+			expanded := app.ExpandDottedKeys(flat.Data, tt.separator, false)
+			if !expanded.Success {
+				t.Fatalf("ExpandDottedKeys failed: %s", expanded.Error)
+			}
+			if got, want := compactJSON(t, expanded.Data), compactJSON(t, tt.input); got != want {
+				t.Errorf("round trip = %s, want %s", got, want)
+			}
+		})
+	}
+
+	// Keys escaped while repairing also stay whole
+	repaired, err := JSONRepairWithOptions(`{user.name: 'a', "a\\b.c": 1, plain: 2}`, RepairOptions{EscapeKeyDots: true})
+	if want := `{"user\\.name": "a", "a\\\\b\\.c": 1, "plain": 2}`; err != nil || repaired != want {
+		t.Fatalf("JSONRepairWithOptions with EscapeKeyDots = %s, %v, want %s", repaired, err, want)
+	}
+	if resp := app.ExpandDottedKeys(repaired, ".", false); compactJSON(t, resp.Data) != `{"user.name":"a","a\\b.c":1,"plain":2}` {
+		t.Errorf("ExpandDottedKeys of the repaired keys = %+v", resp)
+	}
+}
+
+func TestFromSortedFlatLinesRejectsHugeIndex(t *testing.T) {
+	resp := NewApp().FromSortedFlatLines("$.list[99999999] = 1")
+	if resp.Success {
+		t.Fatalf("FromSortedFlatLines accepted index 99999999: %d bytes", len(resp.Data))
+	}
+	resp = NewApp().FromSortedFlatLines("$.list[2] = 1")
+	if !resp.Success || compactJSON(t, resp.Data) != `{"list":[null,null,1]}` {
+		t.Errorf("FromSortedFlatLines($.list[2] = 1) = %+v", resp)
+	}
+}
+
+func TestSortedFlatLinesLongArrayRoundTrip(t *testing.T) {
+	// Indices past maxExpandedIndex are fine when the array really has that many elements
+	elems := make([]string, 10001)
+	for idx := range elems {
+		elems[idx] = strconv.Itoa(idx)
+	}
+	input := `{"list":[` + strings.Join(elems, ",") + `]}`
+	app := NewApp()
+	lines := app.ToSortedFlatLines(input)
+	if !lines.Success || !strings.Contains(lines.Data, "$.list[10000] = 10000") {
+		t.Fatalf("ToSortedFlatLines failed: %s", lines.Error)
+	}
+	resp := app.FromSortedFlatLines(lines.Data)
+	if !resp.Success {
+		t.Fatalf("FromSortedFlatLines failed: %s", resp.Error)
+	}
+	if got := compactJSON(t, resp.Data); got != input {
+		t.Errorf("round trip of a %d-element array changed the document", len(elems))
+	}
+}
+
+func TestCollapseSingleKeyWrappers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		keyNames []string
+		want     string
+	}{
+		{"nested wrappers", `{"value": {"value": {"actual": 1}}}`, nil, `{"actual":1}`},
+		{"wrapper inside object", `{"user": {"data": {"name": "x"}}, "n": 1}`, nil, `{"user":{"name":"x"},"n":1}`},
+		{"wrappers in array", `[{"node": 1}, {"node": {"id": 2}}]`, nil, `[1,{"id":2}]`},
+		{"single key outside the allowlist", `{"id": {"count": 1}}`, nil, `{"id":{"count":1}}`},
+		{"object with two keys stays", `{"value": 1, "unit": "s"}`, nil, `{"value":1,"unit":"s"}`},
+		{"custom allowlist", `{"wrapper": {"value": 1}}`, []string{"wrapper"}, `{"value":1}`},
+		{"key order kept", `{"b": 1, "a": {"data": [2]}}`, nil, `{"b":1,"a":[2]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().CollapseSingleKeyWrappers(tt.input, tt.keyNames)
+			if !resp.Success {
+				t.Fatalf("CollapseSingleKeyWrappers(%s) failed: %s", tt.input, resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("CollapseSingleKeyWrappers(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeNonASCII(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"ascii unchanged", `{"a": "b"}`, `{"a": "b"}`},
+		{"bmp character", `{"name": "café"}`, `{"name": "caf\u00e9"}`},
+		{"astral character", `{"face": "😀"}`, `{"face": "\ud83d\ude00"}`},
+		{"non-ascii key and mixed text", `{"键": ["😀x"]}`, `{"\u952e": ["\ud83d\ude00x"]}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.EscapeNonASCII(tt.input)
+			if !resp.Success {
+				t.Fatalf("EscapeNonASCII(%s) failed: %s", tt.input, resp.Error)
+			}
+			if resp.Data != tt.want {
+				t.Errorf("EscapeNonASCII(%s) = %s, want %s", tt.input, resp.Data, tt.want)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal([]byte(resp.Data), &got); err != nil {
+				t.Fatalf("EscapeNonASCII output is invalid JSON: %v", err)
+			}
+			json.Unmarshal([]byte(tt.input), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("EscapeNonASCII(%s) decodes to %v, want %v", tt.input, got, want)
+			}
+		})
+	}
+}
+
+func TestFullyCanonicalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		left  string
+		right string
+	}{
+		{"key order", `{"b": 1, "a": {"d": 2, "c": 3}}`, `{"a": {"c": 3, "d": 2}, "b": 1}`},
+		{"scalar array order", `{"tags": ["x", "a", "m"]}`, `{"tags": ["m", "x", "a"]}`},
+		{"mixed scalar types", `[true, "s", 2, null, 1.5, false]`, `[null, false, true, 1.5, 2, "s"]`},
+		{"nested permutations", `{"z": [{"k": [3, 1, 2], "j": 0}], "y": [2, 1]}`, `{"y": [1, 2], "z": [{"j": 0, "k": [2, 3, 1]}]}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := app.FullyCanonicalize(tt.left), app.FullyCanonicalize(tt.right)
+			if !left.Success || !right.Success {
+				t.Fatalf("FullyCanonicalize failed: %q, %q", left.Error, right.Error)
+			}
+			if left.Data != right.Data {
+				t.Errorf("FullyCanonicalize differs:\n%s\n%s", left.Data, right.Data)
+			}
+		})
+	}
+
+	// Arrays containing objects or arrays keep their order
+	resp := app.FullyCanonicalize(`{"rows": [{"b": 2}, {"a": 1}], "grid": [[2, 1], [0]]}`)
+	if !resp.Success {
+		t.Fatalf("FullyCanonicalize failed: %s", resp.Error)
+	}
+	if got, want := compactJSON(t, resp.Data), `{"grid":[[1,2],[0]],"rows":[{"b":2},{"a":1}]}`; got != want {
+		t.Errorf("FullyCanonicalize = %s, want %s", got, want)
+	}
+}
+
+func TestTransformKeys(t *testing.T) {
+	tests := []struct {
+		style string
+		input string
+		want  string
+	}{
+		{"snake", `{"userId": 1, "HTTPStatus": 200, "nested": {"firstName": "a"}}`, `{"user_id":1,"http_status":200,"nested":{"first_name":"a"}}`},
+		{"camel", `{"user_id": 1, "list": [{"Item-Name": "x"}]}`, `{"userId":1,"list":[{"itemName":"x"}]}`},
+		{"pascal", `{"user_id": 1}`, `{"UserId":1}`},
+		{"kebab", `{"userID2": true}`, `{"user-id2":true}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			resp := app.TransformKeys(tt.input, tt.style, false)
+			if !resp.Success {
+				t.Fatalf("TransformKeys failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("TransformKeys(%s, %s) = %s, want %s", tt.input, tt.style, got, tt.want)
+			}
+		})
+	}
+
+	if resp := app.TransformKeys(`{"user_id": 1, "userId": 2}`, "camel", false); resp.Success {
+		t.Errorf("TransformKeys with colliding keys = %+v, want an error", resp)
+	}
+	if resp := app.TransformKeys(`{}`, "upper", false); resp.Success {
+		t.Errorf("TransformKeys with an unknown style = %+v, want an error", resp)
+	}
+}
+
+func TestRestoreKeysRoundTrip(t *testing.T) {
+	// Naive snake -> camel conversion of these keys would give httpStatus, userId and urlList
+	input := `{"HTTPStatus": 200, "userID": 7, "URLList": [{"XMLData": "x"}], "plain": 1}`
+	app := NewApp()
+	resp := app.TransformKeys(input, "snake", true)
+	if !resp.Success {
+		t.Fatalf("TransformKeys failed: %s", resp.Error)
+	}
+	result := gjson.Parse(resp.Data)
+	if got, want := compactJSON(t, result.Get("data").Raw), `{"http_status":200,"user_id":7,"url_list":[{"xml_data":"x"}],"plain":1}`; got != want {
+		t.Errorf("TransformKeys data = %s, want %s", got, want)
+	}
+
+	restored := app.RestoreKeys(result.Get("data").Raw, result.Get("mapping").Raw)
+	if !restored.Success {
+		t.Fatalf("RestoreKeys failed: %s", restored.Error)
+	}
+	if got, want := compactJSON(t, restored.Data), compactJSON(t, input); got != want {
+		t.Errorf("RestoreKeys = %s, want %s", got, want)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	template := `{"name": "", "active": true, "tags": [], "address": {"city": "unknown", "zip": null}, "items": [{"qty": 1, "unit": "pcs"}]}`
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty input", `{}`, `{"name":"","active":true,"tags":[],"address":{"city":"unknown","zip":null},"items":[{"qty":1,"unit":"pcs"}]}`},
+		{"input wins", `{"name": "a", "active": false}`, `{"name":"a","active":false,"tags":[],"address":{"city":"unknown","zip":null},"items":[{"qty":1,"unit":"pcs"}]}`},
+		{"explicit null kept", `{"name": null}`, `{"name":null,"active":true,"tags":[],"address":{"city":"unknown","zip":null},"items":[{"qty":1,"unit":"pcs"}]}`},
+		{"nested object", `{"address": {"zip": "1000"}}`, `{"address":{"zip":"1000","city":"unknown"},"name":"","active":true,"tags":[],"items":[{"qty":1,"unit":"pcs"}]}`},
+		{"array elements", `{"items": [{"qty": 3}, {"unit": "kg"}, 7]}`, `{"items":[{"qty":3,"unit":"pcs"},{"unit":"kg","qty":1},7],"name":"","active":true,"tags":[],"address":{"city":"unknown","zip":null}}`},
+		{"scalar array not merged", `{"tags": ["x"]}`, `{"tags":["x"],"name":"","active":true,"address":{"city":"unknown","zip":null},"items":[{"qty":1,"unit":"pcs"}]}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.ApplyDefaults(tt.input, template)
+			if !resp.Success {
+				t.Fatalf("ApplyDefaults failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("ApplyDefaults(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	if resp := app.ApplyDefaults(`{}`, `]`); resp.Success || !strings.HasPrefix(resp.Error, "模板无效") {
+		t.Errorf("ApplyDefaults with an invalid template = %+v, want an error", resp)
+	}
+}
+
+func TestSortedFlatLines(t *testing.T) {
+	input := `{"b": {"y": [10, 2, {"k": null}], "x": "s"}, "a": 1, "odd key": {}, "e": []}`
+	want := "$.a = 1\n" +
+		"$.b.x = \"s\"\n" +
+		"$.b.y[0] = 10\n" +
+		"$.b.y[1] = 2\n" +
+		"$.b.y[2].k = null\n" +
+		"$.e = []\n" +
+		"$[\"odd key\"] = {}\n"
+	app := NewApp()
+	resp := app.ToSortedFlatLines(input)
+	if !resp.Success || resp.Data != want {
+		t.Fatalf("ToSortedFlatLines = %+v, want %q", resp, want)
+	}
+
+	// Reordered keys give the same lines
+	reordered := app.ToSortedFlatLines(`{"e": [], "odd key": {}, "a": 1, "b": {"x": "s", "y": [10, 2, {"k": null}]}}`)
+	if reordered.Data != want {
+		t.Errorf("ToSortedFlatLines of reordered input = %q, want %q", reordered.Data, want)
+	}
+
+	// Array indices sort numerically
+	lines := app.ToSortedFlatLines(`[0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11]`).Data
+	if idx2, idx10 := strings.Index(lines, "$[2] ="), strings.Index(lines, "$[10] ="); idx2 < 0 || idx10 < idx2 {
+		t.Errorf("ToSortedFlatLines sorts indices as text:\n%s", lines)
+	}
+
+	restored := app.FromSortedFlatLines(resp.Data)
+	if !restored.Success {
+		t.Fatalf("FromSortedFlatLines failed: %s", restored.Error)
+	}
+	var got, original interface{}
+	json.Unmarshal([]byte(restored.Data), &got)
+	json.Unmarshal([]byte(input), &original)
+	if !reflect.DeepEqual(got, original) {
+		t.Errorf("FromSortedFlatLines = %s, want %s", restored.Data, input)
+	}
+}
+
+func TestIntegerizeWhereLossless(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"integral float", `{"count": 5.0, "id": 42.00}`, `{"count":5,"id":42}`},
+		{"fraction unchanged", `{"ratio": 5.5, "small": 0.001}`, `{"ratio":5.5,"small":0.001}`},
+		{"exponent", `[1.5e3, 2E2, 1e-2, -3.0]`, `[1500,200,1e-2,-3]`},
+		{"large integer preserved", `{"big": 12345678901234567890, "bigf": 12345678901234567890.0}`, `{"big":12345678901234567890,"bigf":12345678901234567890}`},
+		{"huge exponent unchanged", `[1e400, 1.0e-400]`, `[1e400,1.0e-400]`},
+		{"nested", `{"a": [{"b": 7.000}], "s": "5.0"}`, `{"a":[{"b":7}],"s":"5.0"}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.IntegerizeWhereLossless(tt.input)
+			if !resp.Success {
+				t.Fatalf("IntegerizeWhereLossless failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("IntegerizeWhereLossless(%s) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeToArrays(t *testing.T) {
+	input := `{"items": [
+		{"tags": "a", "owner": {"id": 1}},
+		{"tags": ["b", "c"], "owner": [{"id": 2}]},
+		{"tags": ["d"], "owner": null},
+		{"name": "no tags"}
+	]}`
+	tests := []struct {
+		name   string
+		paths  []string
+		unwrap bool
+		want   string
+	}{
+		{"wrap scalars", []string{"$.items[*].tags"}, false,
+			`{"items":[{"tags":["a"],"owner":{"id":1}},{"tags":["b","c"],"owner":[{"id":2}]},{"tags":["d"],"owner":null},{"name":"no tags"}]}`},
+		{"wrap objects and keep nulls", []string{"items.*.owner"}, false,
+			`{"items":[{"tags":"a","owner":[{"id":1}]},{"tags":["b","c"],"owner":[{"id":2}]},{"tags":["d"],"owner":null},{"name":"no tags"}]}`},
+		{"several paths", []string{"$.items[*].tags", "$.items[*].owner"}, false,
+			`{"items":[{"tags":["a"],"owner":[{"id":1}]},{"tags":["b","c"],"owner":[{"id":2}]},{"tags":["d"],"owner":null},{"name":"no tags"}]}`},
+		{"concrete index", []string{"$.items[0].tags"}, false,
+			`{"items":[{"tags":["a"],"owner":{"id":1}},{"tags":["b","c"],"owner":[{"id":2}]},{"tags":["d"],"owner":null},{"name":"no tags"}]}`},
+		{"unwrap single elements", []string{"$.items[*].tags", "$.items[*].owner"}, true,
+			`{"items":[{"tags":"a","owner":{"id":1}},{"tags":["b","c"],"owner":{"id":2}},{"tags":"d","owner":null},{"name":"no tags"}]}`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.NormalizeToArrays(input, tt.paths, tt.unwrap)
+			if !resp.Success {
+				t.Fatalf("NormalizeToArrays failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("NormalizeToArrays(%v) = %s, want %s", tt.paths, got, tt.want)
+			}
+		})
+	}
+	if resp := app.NormalizeToArrays(input, nil, false); resp.Success {
+		t.Errorf("NormalizeToArrays without paths = %+v, want an error", resp)
+	}
+}
+
+func TestToLabeledEntries(t *testing.T) {
+	input := `{"user": {"name": "Ann", "address": {"city": "Oslo", "zip": null}}, "tags": ["a", {"x": 1.50}], "empty": {}}`
+	tests := []struct {
+		name      string
+		separator string
+		want      string
+	}{
+		{"default separator", "",
+			`[{"path":"user > name","key":"name","value":"Ann","depth":2},` +
+				`{"path":"user > address > city","key":"city","value":"Oslo","depth":3},` +
+				`{"path":"user > address > zip","key":"zip","value":null,"depth":3},` +
+				`{"path":"tags > 0","key":"0","value":"a","depth":2},` +
+				`{"path":"tags > 1 > x","key":"x","value":1.50,"depth":3},` +
+				`{"path":"empty","key":"empty","value":{},"depth":1}]`},
+		{"custom separator", "/",
+			`[{"path":"user/name","key":"name","value":"Ann","depth":2},` +
+				`{"path":"user/address/city","key":"city","value":"Oslo","depth":3},` +
+				`{"path":"user/address/zip","key":"zip","value":null,"depth":3},` +
+				`{"path":"tags/0","key":"0","value":"a","depth":2},` +
+				`{"path":"tags/1/x","key":"x","value":1.50,"depth":3},` +
+				`{"path":"empty","key":"empty","value":{},"depth":1}]`},
+	}
+	app := NewApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.ToLabeledEntries(input, tt.separator)
+			if !resp.Success {
+				t.Fatalf("ToLabeledEntries failed: %s", resp.Error)
+			}
+			if got := compactJSON(t, resp.Data); got != tt.want {
+				t.Errorf("ToLabeledEntries = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	resp := app.ToLabeledEntries(`"scalar"`, "")
+	if got, want := compactJSON(t, resp.Data), `[{"path":"","key":"","value":"scalar","depth":0}]`; !resp.Success || got != want {
+		t.Errorf("ToLabeledEntries of a scalar = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// zodIdentifierRe matches object keys that can be written without quotes
+var zodIdentifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// ConvertToZodSchema converts JSON to Zod schemas for runtime validation in TypeScript, with a
+// z.infer type for every schema. Nested objects get their own named schema, declared before
+// the schemas using them. In arrays of objects, fields that are null in some elements are
+// marked .nullable() and fields missing from some elements .optional().
+func (a *App) ConvertToZodSchema(input string, schemaName string) JSONResponse {
+	return a.ConvertToZodSchemaOpts(input, schemaName, ConvertOptions{})
+}
+
+// ConvertToZodSchemaOpts is ConvertToZodSchema with its settings in a ConvertOptions
+func (a *App) ConvertToZodSchemaOpts(input string, schemaName string, opts ConvertOptions) JSONResponse {
+	var obj interface{}
+	if err := unmarshalPrecise([]byte(input), &obj, true, opts.PreciseFields); err != nil {
+		resp := a.ProcessJSON(input, "4", opts.TrimWhitespace, opts.KeepOrder)
+		if !resp.Success {
+			return resp
+		}
+		unmarshalPrecise([]byte(resp.Data), &obj, true, opts.PreciseFields)
+	} else if opts.TrimWhitespace {
+		obj = a.trimStrings(obj)
+	}
+
+	schemaName = strings.TrimSuffix(schemaName, "Schema")
+	if schemaName == "" {
+		schemaName = "Root"
+	}
+
+	gen := &zodGenerator{opts: &opts, names: make(map[string]bool)}
+	if v, ok := obj.(map[string]interface{}); ok {
+		gen.objectSchema(v, nil, schemaName, "$")
+	} else {
+		gen.names[schemaName] = true
+		gen.define(schemaName, gen.schema(obj, schemaName+"Item", "$"))
+	}
+
+	var builder strings.Builder
+	builder.WriteString("import { z } from \"zod\";\n\n")
+	builder.WriteString(strings.Join(gen.schemas, "\n"))
+	return JSONResponse{Success: true, Data: builder.String()}
+}
+
+// zodGenerator collects named schemas in dependency order
+type zodGenerator struct {
+	opts    *ConvertOptions
+	names   map[string]bool
+	schemas []string
+}
+
+// reserve returns name, or name with a numeric suffix when it is already taken
+func (gen *zodGenerator) reserve(name string) string {
+	unique := name
+	for n := 2; gen.names[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	gen.names[unique] = true
+	return unique
+}
+
+// define emits the schema constant and its inferred type
+func (gen *zodGenerator) define(name string, expr string) {
+	gen.schemas = append(gen.schemas, "export const "+name+"Schema = "+expr+";\n\n"+
+		"export type "+name+" = z.infer<typeof "+name+"Schema>;\n")
+}
+
+// schema returns the Zod expression for value; name is used for nested object schemas
+func (gen *zodGenerator) schema(value interface{}, name string, path string) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return gen.objectSchema(v, nil, name, path)
+	case []interface{}:
+		if len(v) == 0 {
+			return "z.array(z.unknown())"
+		}
+		if merged, _, ok := mergeObjectSamples(v); ok {
+			return "z.array(" + gen.objectSchema(merged, v, name, path+"[*]") + ")"
+		}
+		return "z.array(" + gen.schema(mergeSamples(v), name, path+"[*]") + ")"
+	case float64:
+		return "z.number()"
+	case bool:
+		return "z.boolean()"
+	case string:
+		return "z.string()"
+	default:
+		return "z.null()"
+	}
+}
+
+// objectSchema defines a named z.object schema for obj and returns its constant name.
+// samples holds the array elements obj was merged from, if any.
+func (gen *zodGenerator) objectSchema(obj map[string]interface{}, samples []interface{}, name string, path string) string {
+	name = gen.reserve(zodTypeName(name))
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString("z.object({\n")
+	for _, key := range keys {
+		value := obj[key]
+		expr := gen.schema(value, toPascalCase(key), childJSONPath(path, key))
+		if samples != nil {
+			nullable, optional := fieldPresence(samples, key)
+			if nullable && value != nil {
+				expr += ".nullable()"
+			}
+			if optional {
+				expr += ".optional()"
+			}
+		}
+		builder.WriteString(gen.opts.pathComment("//", childJSONPath(path, key)))
+		builder.WriteString("    ")
+		if zodIdentifierRe.MatchString(key) {
+			builder.WriteString(key)
+		} else {
+			builder.WriteString(canonicalString(key))
+		}
+		builder.WriteString(": ")
+		builder.WriteString(expr)
+		builder.WriteString(",\n")
+	}
+	builder.WriteString("})")
+
+	gen.define(name, builder.String())
+	return name + "Schema"
+}
+
+// zodTypeName turns name into a valid TypeScript identifier
+func zodTypeName(name string) string {
+	var builder strings.Builder
+	for _, r := range name {
+		if r == '_' || r == '$' || r < 128 && (r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			builder.WriteRune(r)
+		}
+	}
+	identifier := builder.String()
+	if identifier == "" || identifier[0] >= '0' && identifier[0] <= '9' {
+		identifier = "Item" + identifier
+	}
+	return identifier
+}
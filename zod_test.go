@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertToZodSchema(t *testing.T) {
+	input := `{
+		"users": [
+			{"id": 1, "name": "a", "nick": null, "address": {"city": "x"}},
+			{"id": 2, "name": null, "tags": ["t"]}
+		],
+		"meta": {"address": {"zip": "1"}},
+		"my-key": true,
+		"empty": []
+	}`
+	resp := NewApp().ConvertToZodSchema(input, "RootSchema")
+	if !resp.Success {
+		t.Fatalf("ConvertToZodSchema failed: %s", resp.Error)
+	}
+	for _, want := range []string{
+		"import { z } from \"zod\";\n",
+		// Nullable and optional modifiers from the merged array elements
+		"export const UsersSchema = z.object({\n    address: Address2Schema.optional(),\n    id: z.number(),\n    name: z.string().nullable(),\n    nick: z.null().optional(),\n    tags: z.array(z.string()).optional(),\n});\n",
+		// Nested object schemas with deduplicated names
+		"export const AddressSchema = z.object({\n    zip: z.string(),\n});\n",
+		"export const Address2Schema = z.object({\n    city: z.string(),\n});\n",
+		"export const MetaSchema = z.object({\n    address: AddressSchema,\n});\n",
+		"export const RootSchema = z.object({\n    empty: z.array(z.unknown()),\n    meta: MetaSchema,\n    \"my-key\": z.boolean(),\n    users: z.array(UsersSchema),\n});\n",
+		"export type Root = z.infer<typeof RootSchema>;\n",
+		"export type Users = z.infer<typeof UsersSchema>;\n",
+	} {
+		if !strings.Contains(resp.Data, want) {
+			t.Errorf("output lacks %q:\n%s", want, resp.Data)
+		}
+	}
+
+	// Every schema is declared before the schemas that use it
+	order := []string{"const AddressSchema", "const MetaSchema", "const Address2Schema", "const UsersSchema", "const RootSchema"}
+	last := -1
+	for _, name := range order {
+		idx := strings.Index(resp.Data, name)
+		if idx < last {
+			t.Errorf("%s is declared out of dependency order:\n%s", name, resp.Data)
+		}
+		last = idx
+	}
+}
+
+func TestConvertToZodSchemaTopLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"array of numbers", `[1, 2]`, "export const RootSchema = z.array(z.number());\n"},
+		{"array of objects", `[{"a": 1}, {"a": null, "b": "x"}]`,
+			"export const RootItemSchema = z.object({\n    a: z.number().nullable(),\n    b: z.string().optional(),\n});\n\nexport type RootItem = z.infer<typeof RootItemSchema>;\n\nexport const RootSchema = z.array(RootItemSchema);\n"},
+		{"scalar", `"s"`, "export const RootSchema = z.string();\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewApp().ConvertToZodSchema(tt.input, "")
+			if !resp.Success || !strings.Contains(resp.Data, tt.want) {
+				t.Errorf("ConvertToZodSchema = %+v, want output containing %q", resp, tt.want)
+			}
+		})
+	}
+}